@@ -0,0 +1,41 @@
+package urlshort
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mappingDocument is the document form of mapping data, which can
+// define aliases for the destinations of its redirects.
+type mappingDocument struct {
+	Aliases   map[string]string `yaml:"aliases" json:"aliases"`
+	Redirects []MappingEntry    `yaml:"redirects" json:"redirects"`
+}
+
+// aliasRef matches a URL referring to an alias: the alias name, a
+// colon and an optional path starting with a single slash.
+var aliasRef = regexp.MustCompile(`^([A-Za-z0-9_-]+):(/[^/].*|/)?$`)
+
+// expand returns the redirects of the document with the alias
+// references in their URLs expanded. The error of the redirect i, if
+// any, is passed to locate, as by validateEntries.
+func (doc *mappingDocument) expand(locate func(i int, err *EntryError)) ([]MappingEntry, error) {
+	entries := make([]MappingEntry, len(doc.Redirects))
+	for i, entry := range doc.Redirects {
+		m := aliasRef.FindStringSubmatch(entry.URL)
+		if m == nil {
+			entries[i] = entry
+			continue
+		}
+		base, ok := doc.Aliases[m[1]]
+		if !ok {
+			err := &EntryError{Path: entry.Path, Problem: fmt.Sprintf("undefined alias %q", m[1])}
+			locate(i, err)
+			return nil, err
+		}
+		entry.URL = strings.TrimSuffix(base, "/") + m[2]
+		entries[i] = entry
+	}
+	return entries, nil
+}
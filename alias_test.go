@@ -0,0 +1,132 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAliasExpansion(t *testing.T) {
+	yml := []byte(`
+aliases:
+  shop: https://shop.example.com
+  docs: https://docs.example.com/
+redirects:
+  - path: /product
+    url: shop:/product/1
+  - path: /shop
+    url: "shop:"
+  - path: /docs
+    url: docs:/intro
+  - path: /plain
+    url: https://example.com/page
+`)
+	jsn := []byte(`{
+  "aliases": {"shop": "https://shop.example.com", "docs": "https://docs.example.com/"},
+  "redirects": [
+    {"path": "/product", "url": "shop:/product/1"},
+    {"path": "/shop", "url": "shop:"},
+    {"path": "/docs", "url": "docs:/intro"},
+    {"path": "/plain", "url": "https://example.com/page"}
+  ]
+}`)
+	want := map[string]string{
+		"/product": "https://shop.example.com/product/1",
+		"/shop":    "https://shop.example.com",
+		"/docs":    "https://docs.example.com/intro",
+		"/plain":   "https://example.com/page",
+	}
+
+	for _, tt := range []struct {
+		name  string
+		parse func() ([]MappingEntry, error)
+	}{
+		{"yaml", func() ([]MappingEntry, error) { return ParseYAML(yml) }},
+		{"json", func() ([]MappingEntry, error) { return ParseJSON(jsn) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := tt.parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(want))
+			}
+			for _, entry := range entries {
+				if entry.URL != want[entry.Path] {
+					t.Errorf("%s: got URL %q, want %q", entry.Path, entry.URL, want[entry.Path])
+				}
+			}
+		})
+	}
+}
+
+func TestAliasHandler(t *testing.T) {
+	h, err := YAMLHandler([]byte(`
+aliases:
+  shop: https://shop.example.com
+redirects:
+  - path: /product
+    url: shop:/product/1
+`), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/product"), http.StatusMovedPermanently, "https://shop.example.com/product/1")
+}
+
+func TestAliasUndefined(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		parse func() ([]MappingEntry, error)
+		want  string
+	}{
+		{
+			name: "yaml",
+			parse: func() ([]MappingEntry, error) {
+				return ParseYAML([]byte(`aliases:
+  shop: https://shop.example.com
+redirects:
+  - path: /a
+    url: shop:/a
+  - path: /b
+    url: blog:/b
+`))
+			},
+			want: `line 6: entry /b: undefined alias "blog"`,
+		},
+		{
+			name: "json",
+			parse: func() ([]MappingEntry, error) {
+				return ParseJSON([]byte(`{"redirects": [{"path": "/b", "url": "blog:/b"}]}`))
+			},
+			want: `entry 1 (/b): undefined alias "blog"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse()
+			var entryErr *EntryError
+			if !errors.As(err, &entryErr) {
+				t.Fatalf("got error %v, want an *EntryError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestAliasListFormat checks that URLs looking like alias references
+// are used as they are in the list format, which has no aliases.
+func TestAliasListFormat(t *testing.T) {
+	entries, err := ParseYAML([]byte(`
+- path: /a
+  url: shop:/a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].URL != "shop:/a" {
+		t.Errorf("got URL %q, want %q", entries[0].URL, "shop:/a")
+	}
+}
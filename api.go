@@ -0,0 +1,150 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Mappings is a read-only view of a set of redirects, such as a Map
+// or a DynamicHandler.
+type Mappings interface {
+	// Lookup returns the URL path is mapped to.
+	Lookup(path string) (url string, ok bool)
+	// Entries returns every redirect, sorted by path.
+	Entries() []MappingEntry
+}
+
+// Map is a static mapping of paths to URLs, as accepted by
+// MapHandler, that implements Mappings.
+type Map map[string]string
+
+// Lookup returns the URL path is mapped to.
+func (m Map) Lookup(path string) (string, bool) {
+	url, ok := m[path]
+	return url, ok
+}
+
+// Entries returns every redirect in m, sorted by path.
+func (m Map) Entries() []MappingEntry {
+	return sortedEntries(m)
+}
+
+// sortedEntries returns the entries of the mapping of paths to URLs
+// m, sorted by path.
+func sortedEntries(m map[string]string) []MappingEntry {
+	entries := make([]MappingEntry, 0, len(m))
+	for path, url := range m {
+		entries = append(entries, MappingEntry{Path: path, URL: url})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// APIHandler returns an http.Handler serving a read-only JSON API
+// over m, for tooling. It is meant to be mounted next to, not in
+// front of, the redirect handler, and serves:
+//
+//   - GET /api/mappings: every redirect, as the JSON array of
+//     {"path": ..., "url": ...} objects accepted by JSONHandler,
+//     sorted by path. With offset and limit query parameters, as in
+//     ?offset=100&limit=50, only that page of the redirects is served
+//     (see Page), and the total number of redirects is given in the
+//     X-Total-Count header.
+//   - GET /api/resolve?path=/foo: the redirect of the path given in
+//     the query, as a single object, or a 404 if it is not mapped.
+//     Paths are resolved by m.Lookup alone, without the options of
+//     any handler serving m, or by the Entry method of m if it has
+//     one, as CompiledMap and DynamicHandler do, so that the notes of
+//     the entry are included. Disabled entries get a 404, as they are
+//     not redirected.
+//
+// Errors of the endpoints are reported as {"error": ...} objects,
+// while other paths and methods get the plain text 404 and 405
+// responses of http.ServeMux. Every read goes through m, so a
+// DynamicHandler is read live, under its lock.
+func APIHandler(m Mappings) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/mappings", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has("offset") && !query.Has("limit") {
+			writeJSON(w, http.StatusOK, m.Entries())
+			return
+		}
+		offset, err := pageParam(query, "offset")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit, err := pageParam(query, "limit")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		entries, total := Page(m, offset, limit)
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		writeJSON(w, http.StatusOK, entries)
+	})
+	mux.HandleFunc("GET /api/resolve", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Query().Get("path")
+		if path == "" {
+			writeJSONError(w, http.StatusBadRequest, "missing path parameter")
+			return
+		}
+		entry, ok := lookupEntry(m, path)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "path not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, entry)
+	})
+	return mux
+}
+
+// pageParam returns the pagination parameter name of query, which is
+// 0 if it is not set.
+func pageParam(query url.Values, name string) (int, error) {
+	if !query.Has(name) {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(query.Get(name))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+	return n, nil
+}
+
+// lookupEntry returns the entry of path in m, with its notes if m
+// keeps them. Disabled entries are not found.
+func lookupEntry(m Mappings, path string) (MappingEntry, bool) {
+	if em, ok := m.(interface {
+		Entry(path string) (MappingEntry, bool)
+	}); ok {
+		entry, ok := em.Entry(path)
+		if !ok || entry.disabled() {
+			return MappingEntry{}, false
+		}
+		return entry, true
+	}
+	url, ok := m.Lookup(path)
+	return MappingEntry{Path: path, URL: url}, ok
+}
+
+// writeJSON writes v as the JSON body of a response with the given
+// status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes msg as the JSON body of an error response
+// with the given status code.
+func writeJSONError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
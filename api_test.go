@@ -0,0 +1,114 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+// notesYAML maps two paths, one of them annotated with notes.
+var notesYAML = []byte(`
+- path: /a
+  url: https://a.example.com
+  notes:
+    owner: web
+    ticket: WEB-42
+- path: /b
+  url: https://b.example.com
+`)
+
+// TestNotes threads notes from YAML through a DynamicHandler, the
+// API and an export, and checks they survive each step.
+func TestNotes(t *testing.T) {
+	entries, err := ParseYAML(notesYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDynamicHandler(nil, notFound)
+	if err := d.Upsert(entries); err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+
+	want := map[string]string{"owner": "web", "ticket": "WEB-42"}
+	api := APIHandler(d)
+	var resolved MappingEntry
+	w := serve(api, "/api/resolve?path=/a")
+	wantStatus(t, w, http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &resolved); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(resolved.Notes, want) {
+		t.Errorf("resolve got notes %v, want %v", resolved.Notes, want)
+	}
+
+	var listed []MappingEntry
+	w = serve(api, "/api/mappings")
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || !maps.Equal(listed[0].Notes, want) || listed[1].Notes != nil {
+		t.Errorf("mappings got %+v, want the notes of /a only", listed)
+	}
+
+	yml, err := ExportYAML(d.Entries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := ParseYAML(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(exported[0].Notes, want) {
+		t.Errorf("export got notes %v, want %v", exported[0].Notes, want)
+	}
+}
+
+func TestNotesCompiledMap(t *testing.T) {
+	entries, err := ParseYAML(notesYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm, err := Compile(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := cm.Entry("/a")
+	if !ok || entry.Notes["ticket"] != "WEB-42" {
+		t.Errorf("Entry(/a) = %+v, %v, want the notes of /a", entry, ok)
+	}
+}
+
+func TestAPIHandlerResolve(t *testing.T) {
+	api := APIHandler(Map{"/a": "https://a.example.com"})
+	wantStatus(t, serve(api, "/api/resolve?path=/a"), http.StatusOK)
+	wantStatus(t, serve(api, "/api/resolve?path=/missing"), http.StatusNotFound)
+	wantStatus(t, serve(api, "/api/resolve"), http.StatusBadRequest)
+}
+
+func TestAPIHandlerResolveDisabled(t *testing.T) {
+	disabled := false
+	entries := []MappingEntry{
+		{Path: "/a", URL: "https://a.example.com"},
+		{Path: "/b", URL: "https://b.example.com", Enabled: &disabled},
+	}
+	d := NewDynamicHandler(nil, notFound)
+	if err := d.Upsert(entries); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := Compile(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, m := range map[string]Mappings{"dynamic": d, "compiled": cm} {
+		t.Run(name, func(t *testing.T) {
+			api := APIHandler(m)
+			wantStatus(t, serve(api, "/api/resolve?path=/a"), http.StatusOK)
+			wantStatus(t, serve(api, "/api/resolve?path=/b"), http.StatusNotFound)
+		})
+	}
+
+	d.SetEnabled("/b", true)
+	wantStatus(t, serve(APIHandler(d), "/api/resolve?path=/b"), http.StatusOK)
+}
@@ -0,0 +1,178 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by a BreakerStore whose circuit is open,
+// without querying the underlying store.
+var ErrBreakerOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of the circuit of a BreakerStore.
+type BreakerState int
+
+const (
+	// BreakerClosed passes every lookup to the underlying store.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every lookup with ErrBreakerOpen.
+	BreakerOpen
+	// BreakerHalfOpen passes a limited number of probe lookups to the
+	// underlying store to find out whether it has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerStore is a Store wrapping another Store in a circuit breaker,
+// so that a failing backend is given time to recover instead of being
+// queried for every request. It is safe for concurrent use.
+//
+// The circuit starts closed. After threshold consecutive failed
+// lookups it opens, and every lookup fails at once with
+// ErrBreakerOpen, which StoreHandler answers like any other store
+// error (see WithErrorHandler). After the open duration the circuit
+// becomes half-open and lets up to probes lookups through at a time:
+// it closes again once probes of them have succeeded in a row, and
+// opens again as soon as one fails. Lookups cut short by their
+// context are neither failures nor successes.
+//
+// To keep serving the paths looked up recently while the circuit is
+// open, wrap the BreakerStore in a CachingStore.
+type BreakerStore struct {
+	store     Store
+	threshold int
+	openFor   time.Duration
+	probes    int
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+	trips     uint64
+}
+
+// NewBreakerStore returns a BreakerStore wrapping store, opening
+// after threshold consecutive failures for openFor, and probing with
+// up to probes lookups when half-open. Non-positive threshold and
+// probes count as 1.
+func NewBreakerStore(store Store, threshold int, openFor time.Duration, probes int) *BreakerStore {
+	return &BreakerStore{
+		store:     store,
+		threshold: max(threshold, 1),
+		openFor:   openFor,
+		probes:    max(probes, 1),
+		now:       time.Now,
+	}
+}
+
+// Get looks path up in the underlying store, unless the circuit is
+// open.
+func (b *BreakerStore) Get(ctx context.Context, path string) (string, bool, error) {
+	probe, err := b.allow()
+	if err != nil {
+		return "", false, err
+	}
+
+	url, ok, err := b.store.Get(ctx, path)
+	b.done(probe, err, ctx.Err() != nil)
+	return url, ok, err
+}
+
+// allow reports whether a lookup may go through, and whether it is a
+// probe.
+func (b *BreakerStore) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if b.now().Sub(b.openedAt) < b.openFor {
+			return false, ErrBreakerOpen
+		}
+		b.state = BreakerHalfOpen
+		b.successes = 0
+	}
+	if b.state == BreakerHalfOpen {
+		if b.inFlight >= b.probes {
+			return false, ErrBreakerOpen
+		}
+		b.inFlight++
+		return true, nil
+	}
+	return false, nil
+}
+
+// done records the outcome of a lookup allowed by allow.
+func (b *BreakerStore) done(probe bool, err error, canceled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.inFlight--
+	}
+	if canceled && err != nil {
+		return
+	}
+	if probe && b.state != BreakerHalfOpen {
+		// The circuit was opened by another probe meanwhile.
+		return
+	}
+
+	switch {
+	case err != nil && (probe || b.state == BreakerClosed):
+		b.failures++
+		if probe || b.failures >= b.threshold {
+			b.trip()
+		}
+	case probe:
+		b.successes++
+		if b.successes >= b.probes {
+			b.state = BreakerClosed
+			b.failures = 0
+		}
+	case b.state == BreakerClosed:
+		b.failures = 0
+	}
+}
+
+// trip opens the circuit.
+func (b *BreakerStore) trip() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+	b.failures = 0
+	b.trips++
+}
+
+// State returns the current state of the circuit. An open circuit
+// whose open duration has elapsed is reported as open until the next
+// lookup makes it half-open.
+func (b *BreakerStore) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Trips returns the number of times the circuit has opened.
+func (b *BreakerStore) Trips() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.trips
+}
@@ -0,0 +1,213 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBackend = errors.New("backend down")
+
+// breakerStep is a lookup made through a BreakerStore after advancing
+// its clock, with the underlying store failing or not.
+type breakerStep struct {
+	advance  time.Duration
+	fail     bool
+	rejected bool // rejected with ErrBreakerOpen without a query
+	state    BreakerState
+}
+
+func TestBreakerStore(t *testing.T) {
+	const (
+		ok   = false
+		fail = true
+	)
+	tests := []struct {
+		name      string
+		threshold int
+		probes    int
+		steps     []breakerStep
+		trips     uint64
+	}{
+		{
+			name: "trips after threshold", threshold: 3, probes: 1,
+			steps: []breakerStep{
+				{fail: fail, state: BreakerClosed},
+				{fail: fail, state: BreakerClosed},
+				{fail: fail, state: BreakerOpen},
+				{rejected: true, state: BreakerOpen},
+				{advance: 59 * time.Second, rejected: true, state: BreakerOpen},
+				{advance: time.Second, fail: ok, state: BreakerClosed},
+				{fail: ok, state: BreakerClosed},
+			},
+			trips: 1,
+		},
+		{
+			name: "success resets failures", threshold: 3, probes: 1,
+			steps: []breakerStep{
+				{fail: fail, state: BreakerClosed},
+				{fail: fail, state: BreakerClosed},
+				{fail: ok, state: BreakerClosed},
+				{fail: fail, state: BreakerClosed},
+				{fail: fail, state: BreakerClosed},
+				{fail: fail, state: BreakerOpen},
+			},
+			trips: 1,
+		},
+		{
+			name: "failed probe reopens", threshold: 1, probes: 1,
+			steps: []breakerStep{
+				{fail: fail, state: BreakerOpen},
+				{advance: time.Minute, fail: fail, state: BreakerOpen},
+				// The open duration starts over from the failed probe.
+				{advance: 30 * time.Second, rejected: true, state: BreakerOpen},
+				{advance: 30 * time.Second, fail: ok, state: BreakerClosed},
+			},
+			trips: 2,
+		},
+		{
+			name: "probes in a row", threshold: 1, probes: 3,
+			steps: []breakerStep{
+				{fail: fail, state: BreakerOpen},
+				{advance: time.Minute, fail: ok, state: BreakerHalfOpen},
+				{fail: ok, state: BreakerHalfOpen},
+				{fail: ok, state: BreakerClosed},
+			},
+			trips: 1,
+		},
+		{
+			name: "probe fails after successes", threshold: 1, probes: 3,
+			steps: []breakerStep{
+				{fail: fail, state: BreakerOpen},
+				{advance: time.Minute, fail: ok, state: BreakerHalfOpen},
+				{fail: ok, state: BreakerHalfOpen},
+				{fail: fail, state: BreakerOpen},
+				{rejected: true, state: BreakerOpen},
+				// Successes before the failure do not count again.
+				{advance: time.Minute, fail: ok, state: BreakerHalfOpen},
+			},
+			trips: 2,
+		},
+		{
+			name: "non-positive counts", threshold: 0, probes: -1,
+			steps: []breakerStep{
+				{fail: fail, state: BreakerOpen},
+				{advance: time.Minute, fail: ok, state: BreakerClosed},
+			},
+			trips: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			var failing bool
+			queries := 0
+			store := StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+				queries++
+				if failing {
+					return "", false, errBackend
+				}
+				return "https://example.com", true, nil
+			})
+			b := NewBreakerStore(store, tt.threshold, time.Minute, tt.probes)
+			b.now = clock.Now
+
+			for i, step := range tt.steps {
+				clock.Add(step.advance)
+				failing = step.fail
+				before := queries
+				_, _, err := b.Get(context.Background(), "/a")
+				switch {
+				case step.rejected && (!errors.Is(err, ErrBreakerOpen) || queries != before):
+					t.Fatalf("step %d: got error %v after %d queries, want ErrBreakerOpen without one", i, err, queries-before)
+				case !step.rejected && step.fail && !errors.Is(err, errBackend):
+					t.Fatalf("step %d: got error %v, want %v", i, err, errBackend)
+				case !step.rejected && !step.fail && err != nil:
+					t.Fatalf("step %d: got error %v, want none", i, err)
+				}
+				if got := b.State(); got != step.state {
+					t.Fatalf("step %d: got state %s, want %s", i, got, step.state)
+				}
+			}
+			if got := b.Trips(); got != tt.trips {
+				t.Errorf("got %d trips, want %d", got, tt.trips)
+			}
+		})
+	}
+}
+
+func TestBreakerStoreProbeLimit(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	failing := true
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	store := StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		if failing {
+			return "", false, errBackend
+		}
+		entered <- struct{}{}
+		<-release
+		return "https://example.com", true, nil
+	})
+	b := NewBreakerStore(store, 1, time.Minute, 2)
+	b.now = clock.Now
+	b.Get(context.Background(), "/a")
+
+	clock.Add(time.Minute)
+	failing = false
+	errs := make(chan error)
+	for range 2 {
+		go func() {
+			_, _, err := b.Get(context.Background(), "/a")
+			errs <- err
+		}()
+		<-entered
+	}
+	if _, _, err := b.Get(context.Background(), "/a"); !errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("got error %v for a third probe, want ErrBreakerOpen", err)
+	}
+	if got := b.State(); got != BreakerHalfOpen {
+		t.Errorf("got state %s while probing, want half-open", got)
+	}
+
+	close(release)
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("probe got error %v", err)
+		}
+	}
+	if got := b.State(); got != BreakerClosed {
+		t.Errorf("got state %s after the probes succeeded, want closed", got)
+	}
+}
+
+func TestBreakerStoreCanceled(t *testing.T) {
+	store := StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		return "", false, ctx.Err()
+	})
+	b := NewBreakerStore(store, 1, time.Minute, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range 3 {
+		if _, _, err := b.Get(ctx, "/a"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("got error %v, want %v", err, context.Canceled)
+		}
+	}
+	if got := b.State(); got != BreakerClosed {
+		t.Errorf("got state %s after canceled lookups, want closed", got)
+	}
+}
+
+func TestBreakerStateString(t *testing.T) {
+	for state, want := range map[BreakerState]string{
+		BreakerClosed:   "closed",
+		BreakerOpen:     "open",
+		BreakerHalfOpen: "half-open",
+		BreakerState(9): "unknown",
+	} {
+		if got := state.String(); got != want {
+			t.Errorf("got %q for state %d, want %q", got, int(state), want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+)
+
+// source loads the entries of a source of redirects given to New,
+// once every option has been applied to cfg.
+type source func(cfg *config) ([]MappingEntry, error)
+
+// New returns an http.Handler redirecting the paths of the sources of
+// redirects given among opts, such as WithMap, WithYAML and WithJSON,
+// and customised by the other opts. Requests for other paths are
+// passed to fallback.
+//
+// The sources are loaded in the order they were given, and a path
+// defined by several of them is redirected as the last one defines
+// it. Mapping data is decoded with the options of WithDecodeOptions
+// wherever they appear among opts. The errors are those of
+// YAMLHandler and JSONHandler for invalid data, and of WithMaxEntries,
+// which counts the entries of every source.
+//
+// New is the general form of the constructors of this package: for
+// example, YAMLHandler(yml, fallback, opts...) builds the same
+// handler as New(fallback, WithYAML(yml), opts...).
+func New(fallback http.Handler, opts ...Option) (http.Handler, error) {
+	h, err := build(fallback, newConfig(opts))
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// build builds the handler of the sources of redirects of cfg.
+func build(fallback http.Handler, cfg *config) (http.HandlerFunc, error) {
+	var entries []MappingEntry
+	for _, load := range cfg.sources {
+		loaded, err := load(cfg)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, loaded...)
+	}
+
+	return entriesHandler(entries, fallback, cfg)
+}
+
+// WithMap adds the mapping of paths to URLs pathsToUrls, as accepted
+// by MapHandler, to the redirects of New.
+func WithMap(pathsToUrls map[string]string) Option {
+	return func(c *config) {
+		c.sources = append(c.sources, func(*config) ([]MappingEntry, error) {
+			return Map(pathsToUrls).Entries(), nil
+		})
+	}
+}
+
+// WithYAML adds the redirects of the YAML mapping data yml, in the
+// format accepted by YAMLHandler, to the redirects of New.
+func WithYAML(yml []byte) Option {
+	return func(c *config) {
+		c.sources = append(c.sources, func(cfg *config) ([]MappingEntry, error) {
+			return ParseYAML(yml, cfg.decodeOptions...)
+		})
+	}
+}
+
+// WithJSON adds the redirects of the JSON mapping data jsn, in the
+// format accepted by JSONHandler, to the redirects of New.
+func WithJSON(jsn []byte) Option {
+	return func(c *config) {
+		c.sources = append(c.sources, func(cfg *config) ([]MappingEntry, error) {
+			return ParseJSON(jsn, cfg.decodeOptions...)
+		})
+	}
+}
+
+// WithCaseInsensitive makes the handler match paths regardless of
+// case. Request paths are normalized with LowercaseAll, after any
+// other normalizer, and so are the mapping keys of the handlers
+// built from a static set of redirects, such as New, MapHandler,
+// YAMLHandler and DirHandler. Keys of the other handlers, such as
+// StoreHandler, DynamicHandler and CompiledMapHandler, are matched as
+// they are written, so they should be written in lower case.
+func WithCaseInsensitive() Option {
+	return func(c *config) {
+		c.caseInsensitive = true
+	}
+}
+
+// foldEntries returns entries with their paths lower-cased if the
+// handler is case-insensitive, and entries unchanged otherwise.
+func (c *config) foldEntries(entries []MappingEntry) []MappingEntry {
+	if !c.caseInsensitive {
+		return entries
+	}
+	folded := make([]MappingEntry, len(entries))
+	for i, entry := range entries {
+		entry.Path = strings.ToLower(entry.Path)
+		if len(entry.Paths) > 0 {
+			entry.Paths = make([]string, len(entry.Paths))
+			for j, path := range entries[i].Paths {
+				entry.Paths[j] = strings.ToLower(path)
+			}
+		}
+		folded[i] = entry
+	}
+	return folded
+}
+
+// foldKeys returns a copy of pathsToUrls with its keys lower-cased.
+func foldKeys(pathsToUrls map[string]string) map[string]string {
+	folded := make(map[string]string, len(pathsToUrls))
+	for path, url := range pathsToUrls {
+		folded[strings.ToLower(path)] = url
+	}
+	return folded
+}
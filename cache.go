@@ -0,0 +1,214 @@
+package urlshort
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// StoreFunc is an adapter to use a function as a Store.
+type StoreFunc func(ctx context.Context, path string) (url string, ok bool, err error)
+
+// Get calls f(ctx, path).
+func (f StoreFunc) Get(ctx context.Context, path string) (string, bool, error) {
+	return f(ctx, path)
+}
+
+// HTTPStore returns a Store looking paths up from a remote service
+// with a GET request made with client (http.DefaultClient if nil)
+// under the context of the lookup, to resolveURL with the path in its
+// "path" query parameter. The service answers with a 200 and a JSON
+// {"path": ..., "url": ...} object for mapped paths, and with a 404
+// for the others, as the /api/resolve endpoint of APIHandler does.
+// Any other answer is an error.
+//
+// Wrap it in a CachingStore to avoid a round trip per request.
+func HTTPStore(client *http.Client, resolveURL string) Store {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		u, err := url.Parse(resolveURL)
+		if err != nil {
+			return "", false, err
+		}
+		q := u.Query()
+		q.Set("path", path)
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return "", false, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", false, err
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			return "", false, nil
+		default:
+			return "", false, fmt.Errorf("resolve %s: unexpected status %s", path, resp.Status)
+		}
+		var entry MappingEntry
+		if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+			return "", false, fmt.Errorf("resolve %s: %w", path, err)
+		}
+		return entry.URL, true, nil
+	})
+}
+
+// CacheStats counts the lookups of a CachingStore.
+type CacheStats struct {
+	// Hits and NegativeHits count the lookups answered from the
+	// cache, for mapped and unmapped paths respectively.
+	Hits, NegativeHits uint64
+	// Misses counts the lookups passed to the underlying store.
+	Misses uint64
+	// Evictions counts the paths dropped from the cache to make room
+	// for others.
+	Evictions uint64
+}
+
+// CachingStore is a Store caching the lookups of another Store in
+// memory, for stores too large to load whole or too slow to query on
+// every request. It is safe for concurrent use.
+//
+// It holds up to a fixed number of paths, evicting the least recently
+// used one to make room for a new one. Mapped paths are cached for
+// one TTL, and unmapped paths for another, usually shorter, so that
+// requests for paths that do not exist do not all reach the
+// underlying store either. Errors are never cached.
+type CachingStore struct {
+	store       Store
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+	stats CacheStats
+	// generation is incremented by Purge, so that lookups started
+	// before it do not cache what they found.
+	generation uint64
+}
+
+// cacheItem is the cached lookup of a path.
+type cacheItem struct {
+	path    string
+	url     string
+	ok      bool
+	expires time.Time
+}
+
+// NewCachingStore returns a CachingStore caching up to size lookups
+// of store, those of mapped paths for ttl and those of unmapped paths
+// for negativeTTL. A non-positive TTL disables caching of the
+// corresponding lookups, and a non-positive size disables caching
+// altogether.
+func NewCachingStore(store Store, size int, ttl, negativeTTL time.Duration) *CachingStore {
+	return &CachingStore{
+		store:       store,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		items:       make(map[string]*list.Element),
+		lru:         list.New(),
+	}
+}
+
+// Get returns the cached lookup of path if it has not expired, and
+// otherwise looks path up in the underlying store.
+func (c *CachingStore) Get(ctx context.Context, path string) (string, bool, error) {
+	c.mu.Lock()
+	if el, ok := c.items[path]; ok {
+		item := el.Value.(*cacheItem)
+		if c.now().Before(item.expires) {
+			c.lru.MoveToFront(el)
+			if item.ok {
+				c.stats.Hits++
+			} else {
+				c.stats.NegativeHits++
+			}
+			c.mu.Unlock()
+			return item.url, item.ok, nil
+		}
+		c.lru.Remove(el)
+		delete(c.items, path)
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	url, ok, err := c.store.Get(ctx, path)
+	if err != nil {
+		return "", false, err
+	}
+	c.add(path, url, ok, generation)
+	return url, ok, nil
+}
+
+// add caches the lookup of path, started in the given generation of
+// the cache, if lookups like it are cached and the cache was not
+// purged since.
+func (c *CachingStore) add(path, url string, ok bool, generation uint64) {
+	ttl := c.ttl
+	if !ok {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 || c.size <= 0 {
+		return
+	}
+	item := &cacheItem{path: path, url: url, ok: ok, expires: c.now().Add(ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if el, ok := c.items[path]; ok {
+		el.Value = item
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[path] = c.lru.PushFront(item)
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).path)
+		c.stats.Evictions++
+	}
+}
+
+// Purge drops every cached lookup, for example after the underlying
+// store was changed. Lookups of the underlying store in flight are
+// not cached when they complete, as they may have read it before the
+// change.
+func (c *CachingStore) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.items)
+	c.lru.Init()
+}
+
+// Stats returns the current counts of the lookups of c.
+func (c *CachingStore) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
@@ -0,0 +1,208 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore is a Store mapping /a, /b and /c, and failing for /down,
+// counting its queries.
+type countingStore struct {
+	queries atomic.Int32
+}
+
+func (s *countingStore) Get(ctx context.Context, path string) (string, bool, error) {
+	s.queries.Add(1)
+	switch path {
+	case "/a", "/b", "/c":
+		return "https://example.com" + path, true, nil
+	case "/down":
+		return "", false, errBackend
+	}
+	return "", false, nil
+}
+
+// newTestCache returns a CachingStore of a countingStore, driven by a
+// testClock.
+func newTestCache(size int, ttl, negativeTTL time.Duration) (*CachingStore, *countingStore, *testClock) {
+	store := &countingStore{}
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewCachingStore(store, size, ttl, negativeTTL)
+	c.now = clock.Now
+	return c, store, clock
+}
+
+// get looks path up in c, failing t on an error.
+func get(t *testing.T, c *CachingStore, path string) (string, bool) {
+	t.Helper()
+	url, ok, err := c.Get(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", path, err)
+	}
+	return url, ok
+}
+
+func TestCachingStoreTTL(t *testing.T) {
+	c, store, clock := newTestCache(10, time.Minute, 10*time.Second)
+
+	for range 3 {
+		if url, ok := get(t, c, "/a"); !ok || url != "https://example.com/a" {
+			t.Fatalf("got %q, %v for /a, want its URL", url, ok)
+		}
+		if _, ok := get(t, c, "/missing"); ok {
+			t.Fatal("got /missing mapped")
+		}
+	}
+	if n := store.queries.Load(); n != 2 {
+		t.Errorf("got %d queries, want 2", n)
+	}
+
+	// The negative TTL runs out first.
+	clock.Add(10 * time.Second)
+	get(t, c, "/a")
+	get(t, c, "/missing")
+	if n := store.queries.Load(); n != 3 {
+		t.Errorf("got %d queries after the negative TTL, want 3", n)
+	}
+	clock.Add(50 * time.Second)
+	get(t, c, "/a")
+	if n := store.queries.Load(); n != 4 {
+		t.Errorf("got %d queries after the TTL, want 4", n)
+	}
+
+	want := CacheStats{Hits: 3, NegativeHits: 2, Misses: 4}
+	if got := c.Stats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestCachingStoreDisabled(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		size             int
+		ttl, negativeTTL time.Duration
+		queries          int32
+	}{
+		{"no negative caching", 10, time.Minute, 0, 4},
+		{"no positive caching", 10, 0, time.Minute, 4},
+		{"no size", 0, time.Minute, time.Minute, 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, store, _ := newTestCache(tt.size, tt.ttl, tt.negativeTTL)
+			for range 3 {
+				get(t, c, "/a")
+				get(t, c, "/missing")
+			}
+			if n := store.queries.Load(); n != tt.queries {
+				t.Errorf("got %d queries, want %d", n, tt.queries)
+			}
+		})
+	}
+}
+
+func TestCachingStoreEviction(t *testing.T) {
+	c, store, _ := newTestCache(2, time.Minute, time.Minute)
+	get(t, c, "/a")
+	get(t, c, "/b")
+	get(t, c, "/a") // /b is now the least recently used.
+	get(t, c, "/c")
+
+	get(t, c, "/a")
+	get(t, c, "/c")
+	if n := store.queries.Load(); n != 3 {
+		t.Errorf("got %d queries, want 3", n)
+	}
+	get(t, c, "/b")
+	if n := store.queries.Load(); n != 4 {
+		t.Errorf("got %d queries for the evicted path, want 4", n)
+	}
+
+	want := CacheStats{Hits: 3, Misses: 4, Evictions: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestCachingStoreErrors(t *testing.T) {
+	c, store, _ := newTestCache(10, time.Minute, time.Minute)
+	for range 2 {
+		if _, _, err := c.Get(context.Background(), "/down"); !errors.Is(err, errBackend) {
+			t.Fatalf("got error %v, want %v", err, errBackend)
+		}
+	}
+	if n := store.queries.Load(); n != 2 {
+		t.Errorf("got %d queries, want errors not to be cached", n)
+	}
+}
+
+func TestCachingStorePurge(t *testing.T) {
+	c, store, _ := newTestCache(10, time.Minute, time.Minute)
+	get(t, c, "/a")
+	get(t, c, "/missing")
+	c.Purge()
+	get(t, c, "/a")
+	get(t, c, "/missing")
+	if n := store.queries.Load(); n != 4 {
+		t.Errorf("got %d queries, want every lookup to miss after Purge", n)
+	}
+}
+
+func TestCachingStorePurgeInFlight(t *testing.T) {
+	var version atomic.Int32
+	entered, release := make(chan struct{}), make(chan struct{})
+	store := StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		v := version.Load()
+		if v == 0 {
+			entered <- struct{}{}
+			<-release
+		}
+		return fmt.Sprintf("https://example.com/v%d", v), true, nil
+	})
+	c := NewCachingStore(store, 10, time.Minute, time.Minute)
+
+	stale := make(chan string)
+	go func() {
+		url, _, _ := c.Get(context.Background(), "/a")
+		stale <- url
+	}()
+	<-entered
+	// The store changes while the lookup is in flight.
+	version.Store(1)
+	c.Purge()
+	close(release)
+	if url := <-stale; url != "https://example.com/v0" {
+		t.Errorf("got %q for the lookup in flight, want the old URL", url)
+	}
+
+	url, _, _ := c.Get(context.Background(), "/a")
+	if url != "https://example.com/v1" {
+		t.Errorf("got %q after Purge, want the new URL rather than the one in flight", url)
+	}
+}
+
+func TestHTTPStore(t *testing.T) {
+	api := httptest.NewServer(APIHandler(Map{"/a": "https://a.example.com"}))
+	defer api.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	store := HTTPStore(api.Client(), api.URL+"/api/resolve")
+	url, ok, err := store.Get(context.Background(), "/a")
+	if err != nil || !ok || url != "https://a.example.com" {
+		t.Errorf("got %q, %v, %v for /a, want its URL", url, ok, err)
+	}
+	if _, ok, err := store.Get(context.Background(), "/missing"); err != nil || ok {
+		t.Errorf("got %v, %v for /missing, want it unmapped", ok, err)
+	}
+	if _, _, err := HTTPStore(nil, failing.URL).Get(context.Background(), "/a"); err == nil {
+		t.Error("got no error for a 500 answer")
+	}
+}
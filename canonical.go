@@ -0,0 +1,42 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// CanonicalHostMiddleware returns an http.Handler that redirects
+// requests sent to any host other than canonical, such as
+// "www.example.com" when canonical is "example.com" or the other way
+// round, to the same path and query on canonical with a 301, and
+// passes requests sent to canonical to next. It is meant to wrap the
+// redirect handler, so that paths are only ever mapped on the
+// canonical host.
+//
+// Hosts are compared case-insensitively. If canonical has no port,
+// the port of requests is ignored when comparing and dropped when
+// redirecting. The redirect keeps the scheme of the request, https if
+// the connection used TLS and http otherwise.
+func CanonicalHostMiddleware(canonical string, next http.Handler) http.Handler {
+	canonical = strings.ToLower(canonical)
+	_, _, err := net.SplitHostPort(canonical)
+	hasPort := err == nil
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.ToLower(r.Host)
+		if !hasPort {
+			host = requestHost(r)
+		}
+		if host == canonical {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		redirectTo(w, scheme+"://"+canonical+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,43 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCanonicalHostMiddleware(t *testing.T) {
+	next := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+	for _, tt := range []struct {
+		name      string
+		canonical string
+		target    string
+		want      string
+	}{
+		{"www to apex", "example.com", "http://www.example.com/a?x=1", "http://example.com/a?x=1"},
+		{"apex to www", "www.example.com", "http://example.com/a?x=1", "http://www.example.com/a?x=1"},
+		{"keeps https", "example.com", "https://www.example.com/b", "https://example.com/b"},
+		{"drops port", "example.com", "http://www.example.com:8080/", "http://example.com/"},
+		{"keeps canonical port", "example.com:8080", "http://example.com/", "http://example.com:8080/"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CanonicalHostMiddleware(tt.canonical, next)
+			wantRedirect(t, serve(h, tt.target), http.StatusMovedPermanently, tt.want)
+		})
+	}
+}
+
+func TestCanonicalHostMiddlewarePassesThrough(t *testing.T) {
+	next := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+	for _, tt := range []struct {
+		canonical string
+		target    string
+	}{
+		{"example.com", "http://example.com/a"},
+		{"Example.com", "http://EXAMPLE.com/a"},
+		{"www.example.com", "http://www.example.com:8080/a"},
+		{"example.com:8080", "http://example.com:8080/a"},
+	} {
+		h := CanonicalHostMiddleware(tt.canonical, next)
+		wantRedirect(t, serve(h, tt.target), http.StatusMovedPermanently, "https://a.example.com")
+	}
+}
@@ -0,0 +1,38 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithCanonicalLink makes the handler answer matched requests with an
+// empty 200 and a header naming the destination as the canonical URL
+// of the path, instead of a redirect:
+//
+//	Link: <https://www.some-url.com/demo>; rel="canonical"
+//
+// This only makes sense when something in front of the handler, such
+// as a CDN or an edge function, serves or navigates to the content of
+// the path itself and only asks the handler for its canonical URL,
+// so that search engines index the destination rather than the short
+// path. Clients reaching the handler directly see an empty page, so
+// for links followed by users a redirect, which search engines also
+// treat as a canonical signal when permanent, remains the right
+// choice.
+//
+// The destination is sent as it is, after the other options on
+// destinations have run, except that any "<" or ">" in it is
+// percent-encoded. Search engines expect canonical URLs to be
+// absolute.
+func WithCanonicalLink() Option {
+	return func(c *config) {
+		c.canonicalLink = true
+	}
+}
+
+// canonicalTo writes a response giving url as the canonical URL.
+func canonicalTo(w http.ResponseWriter, url string) {
+	url = strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url)
+	w.Header().Add("Link", "<"+url+`>; rel="canonical"`)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,47 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCanonicalLink(t *testing.T) {
+	urls := map[string]string{
+		"/demo":    "https://www.some-url.com/demo",
+		"/escaped": "https://example.com/<b>",
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		link   string
+	}{
+		{"format", nil, "/demo", `<https://www.some-url.com/demo>; rel="canonical"`},
+		{"angle brackets", nil, "/escaped", `<https://example.com/%3Cb%3E>; rel="canonical"`},
+		{"other options first", []Option{WithQueryForwarding()}, "/demo?page=2", `<https://www.some-url.com/demo?page=2>; rel="canonical"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(MapHandler(urls, notFound, append(tt.opts, WithCanonicalLink())...), tt.target)
+			wantStatus(t, w, http.StatusOK)
+			if got := w.Header().Values("Link"); len(got) != 1 || got[0] != tt.link {
+				t.Errorf("got Link %q, want %q", got, tt.link)
+			}
+			if loc := w.Header().Get("Location"); loc != "" {
+				t.Errorf("got Location %q, want none", loc)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("got body %q, want none", w.Body)
+			}
+		})
+	}
+}
+
+func TestCanonicalLinkUnmatched(t *testing.T) {
+	h := MapHandler(map[string]string{"/demo": "https://example.com"}, notFound, WithCanonicalLink())
+	w := serve(h, "/missing")
+	wantStatus(t, w, http.StatusNotFound)
+	if got := w.Header().Get("Link"); got != "" {
+		t.Errorf("got Link %q for an unmatched path, want none", got)
+	}
+}
@@ -0,0 +1,74 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithCanonicalCase makes the handler match request paths regardless
+// of case, as WithCaseInsensitive does, and redirect the requests whose
+// path matches a mapping key written with a different casing to the
+// path cased as the key first, so that clients and search engines only
+// ever see the canonical casing of each path. With the key "/Docs",
+// a request for /docs is redirected to /Docs, with the status of the
+// handler (see WithStatus) and its query kept, and the request for
+// /Docs that follows is redirected to the destination.
+//
+// Clients reaching a path through a non-canonical casing thus follow
+// two redirects, the second of which may be cached separately, and
+// the first one is not counted by the options observing redirects,
+// such as WithHitBudget. The rest of a prefix match keeps the casing
+// of the request.
+//
+// Only the handlers built from a static set of redirects, such as New,
+// MapHandler, YAMLHandler and DirHandler, know the casing of their
+// keys; the others match regardless of case without redirecting, as
+// with WithCaseInsensitive.
+func WithCanonicalCase() Option {
+	return func(c *config) {
+		c.caseInsensitive = true
+		c.canonicalCase = true
+	}
+}
+
+// canonicalPaths returns the keys of entries, keyed by their
+// lower-cased form, if the handler is set to redirect to them, and nil
+// otherwise. When several keys fold to the same form the last one
+// wins, as its entry does.
+func (c *config) canonicalPaths(entries []MappingEntry) map[string]string {
+	if !c.canonicalCase {
+		return nil
+	}
+	canonical := make(map[string]string)
+	for _, entry := range expandPaths(entries) {
+		canonical[strings.ToLower(entry.Path)] = entry.Path
+	}
+	return canonical
+}
+
+// canonicalLocation returns the location to redirect r to, to reach
+// the path mapped by key with the casing of the key, if its casing
+// differs.
+func (h *handler) canonicalLocation(r *http.Request, key string) (string, bool) {
+	canon, ok := h.canonical[key]
+	if !ok {
+		return "", false
+	}
+	path := h.cfg.requestPath(r)
+	if len(path) < len(canon) || path[:len(canon)] == canon || !strings.EqualFold(path[:len(canon)], canon) {
+		return "", false
+	}
+	base, ok := strings.CutSuffix(r.URL.Path, path)
+	if !ok {
+		return "", false
+	}
+
+	u := &url.URL{Path: base + canon + path[len(canon):], RawQuery: r.URL.RawQuery}
+	loc := u.String()
+	if strings.HasPrefix(loc, "//") {
+		// A scheme-relative location would lead to another host.
+		return "", false
+	}
+	return loc, true
+}
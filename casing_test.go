@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCanonicalCase(t *testing.T) {
+	urls := map[string]string{
+		"/Docs":  "https://docs.example.com",
+		"/about": "https://example.com/about",
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		status int
+		loc    string
+	}{
+		{"different casing", nil, "/docs", http.StatusMovedPermanently, "/Docs"},
+		{"upper casing", nil, "/DOCS", http.StatusMovedPermanently, "/Docs"},
+		{"exact casing", nil, "/Docs", http.StatusMovedPermanently, "https://docs.example.com"},
+		{"lower-case key", nil, "/ABOUT", http.StatusMovedPermanently, "/about"},
+		{"query kept", nil, "/docs?q=1", http.StatusMovedPermanently, "/Docs?q=1"},
+		{"handler status", []Option{WithStatus(http.StatusFound)}, "/docs", http.StatusFound, "/Docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, append(tt.opts, WithCanonicalCase())...)
+			wantRedirect(t, serve(h, tt.target), tt.status, tt.loc)
+		})
+	}
+}
+
+func TestCanonicalCaseDoubleHop(t *testing.T) {
+	h, err := YAMLHandler([]byte("- path: /Docs\n  url: https://docs.example.com\n"), notFound, WithCanonicalCase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(h, "/dOcS")
+	wantRedirect(t, w, http.StatusMovedPermanently, "/Docs")
+	wantRedirect(t, serve(h, w.Header().Get("Location")), http.StatusMovedPermanently, "https://docs.example.com")
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+}
+
+func TestCanonicalCasePrefix(t *testing.T) {
+	urls := map[string]string{"/Docs/": "https://docs.example.com/"}
+	h := MapHandler(urls, notFound, WithCanonicalCase(), WithPrefixMatch())
+
+	// The rest of the path keeps the casing of the request in the
+	// redirect to the canonical path, and is then matched lower-cased,
+	// as with WithCaseInsensitive.
+	wantRedirect(t, serve(h, "/docs/Intro"), http.StatusMovedPermanently, "/Docs/Intro")
+	wantRedirect(t, serve(h, "/Docs/Intro"), http.StatusMovedPermanently, "https://docs.example.com/intro")
+}
+
+func TestCanonicalCaseHitBudget(t *testing.T) {
+	h, err := YAMLHandler([]byte("- path: /Promo\n  url: https://shop.example.com\n  maxHits: 1\n"), notFound, WithCanonicalCase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		wantRedirect(t, serve(h, "/promo"), http.StatusMovedPermanently, "/Promo")
+	}
+	wantRedirect(t, serve(h, "/Promo"), http.StatusMovedPermanently, "https://shop.example.com")
+	wantStatus(t, serve(h, "/Promo"), http.StatusGone)
+}
+
+func TestCanonicalCaseWithoutKeys(t *testing.T) {
+	// Dynamic handlers match their keys as they are written, in lower
+	// case, regardless of the case of the request, without redirecting.
+	d := NewDynamicHandler(map[string]string{"/docs": "https://docs.example.com"}, notFound, WithCanonicalCase())
+	wantRedirect(t, serve(d, "/DOCS"), http.StatusMovedPermanently, "https://docs.example.com")
+
+	h := MapHandler(map[string]string{"/Docs": "https://docs.example.com"}, notFound, WithCaseInsensitive())
+	wantRedirect(t, serve(h, "/docs"), http.StatusMovedPermanently, "https://docs.example.com")
+}
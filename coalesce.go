@@ -0,0 +1,60 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// WithCoalescedLookups makes a StoreHandler query its store once for
+// the requests for the same path that arrive while a query for it is
+// in flight, sharing its result, error included, among them. This
+// spares the store bursts of identical queries, such as those for a
+// popular path missing from a CachingStore. Other handlers ignore it.
+//
+// The shared query runs with the context of the request that started
+// it, without its cancellation, so that a canceled request does not
+// fail the others waiting for the same path; a request canceled while
+// waiting gives up on its own. The store should therefore bound the
+// time its queries take, as HTTPStore does with a client with a
+// timeout.
+func WithCoalescedLookups() Option {
+	return func(c *config) {
+		c.coalesceLookups = true
+	}
+}
+
+// storeResult is the result of a Store lookup shared by coalesced
+// lookups.
+type storeResult struct {
+	url string
+	ok  bool
+}
+
+// coalescedLookup returns a lookupFunc querying store, as by
+// storeLookup, once for concurrent lookups of the same path.
+func coalescedLookup(store Store) lookupFunc {
+	var group singleflight.Group
+	return func(r *http.Request, path string) (MappingEntry, bool, error) {
+		ctx := r.Context()
+		ch := group.DoChan(path, func() (any, error) {
+			url, ok, err := store.Get(context.WithoutCancel(ctx), path)
+			return storeResult{url: url, ok: ok}, err
+		})
+
+		select {
+		case res := <-ch:
+			if res.Err != nil {
+				return MappingEntry{}, false, res.Err
+			}
+			found := res.Val.(storeResult)
+			if !found.ok {
+				return MappingEntry{}, false, nil
+			}
+			return MappingEntry{Path: path, URL: found.url}, true, nil
+		case <-ctx.Done():
+			return MappingEntry{}, false, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// slowStore is a Store counting its queries, each of which waits
+// until release is closed and then returns url, or err.
+type slowStore struct {
+	calls   atomic.Int32
+	release chan struct{}
+	url     string
+	err     error
+
+	mu       sync.Mutex
+	canceled bool
+}
+
+func newSlowStore(url string, err error) *slowStore {
+	return &slowStore{release: make(chan struct{}), url: url, err: err}
+}
+
+func (s *slowStore) Get(ctx context.Context, path string) (string, bool, error) {
+	s.calls.Add(1)
+	<-s.release
+	s.mu.Lock()
+	s.canceled = s.canceled || ctx.Err() != nil
+	s.mu.Unlock()
+	return s.url, s.url != "" && s.err == nil, s.err
+}
+
+// waitingContext is a context reporting on waiting when its Done
+// method is called, which the coalesced lookup does once it has
+// joined the query in flight.
+type waitingContext struct {
+	context.Context
+	waiting chan<- struct{}
+}
+
+func (c waitingContext) Done() <-chan struct{} {
+	c.waiting <- struct{}{}
+	return c.Context.Done()
+}
+
+// serveWaiting serves n concurrent requests for target with h,
+// returning their responses once they are all waiting for the query
+// in flight and release is closed.
+func serveWaiting(h http.Handler, target string, n int, release chan struct{}) []*httptest.ResponseRecorder {
+	waiting := make(chan struct{})
+	ws := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range ws {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws[i] = httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+			ctx := waitingContext{Context: context.Background(), waiting: waiting}
+			h.ServeHTTP(ws[i], r.WithContext(ctx))
+		}()
+	}
+	for range n {
+		<-waiting
+	}
+	close(release)
+	wg.Wait()
+	return ws
+}
+
+func TestCoalescedLookups(t *testing.T) {
+	store := newSlowStore("https://example.com", nil)
+	h := StoreHandler(store, notFound, WithCoalescedLookups())
+
+	for _, w := range serveWaiting(h, "/cold", 20, store.release) {
+		wantRedirect(t, w, http.StatusMovedPermanently, "https://example.com")
+	}
+	if n := store.calls.Load(); n != 1 {
+		t.Errorf("got %d store queries for 20 concurrent requests, want 1", n)
+	}
+
+	// Results are shared, not cached.
+	wantRedirect(t, serve(h, "/cold"), http.StatusMovedPermanently, "https://example.com")
+	if n := store.calls.Load(); n != 2 {
+		t.Errorf("got %d store queries after a later request, want 2", n)
+	}
+}
+
+func TestCoalescedLookupsShareMissesAndErrors(t *testing.T) {
+	miss := newSlowStore("", nil)
+	h := StoreHandler(miss, notFound, WithCoalescedLookups())
+	for _, w := range serveWaiting(h, "/missing", 5, miss.release) {
+		wantStatus(t, w, http.StatusNotFound)
+	}
+	if n := miss.calls.Load(); n != 1 {
+		t.Errorf("got %d store queries for missing path, want 1", n)
+	}
+
+	errDown := errors.New("store down")
+	failing := newSlowStore("", errDown)
+	var mu sync.Mutex
+	var errs []error
+	h = StoreHandler(failing, notFound, WithCoalescedLookups(),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+	for _, w := range serveWaiting(h, "/a", 5, failing.release) {
+		wantStatus(t, w, http.StatusBadGateway)
+	}
+	if n := failing.calls.Load(); n != 1 {
+		t.Errorf("got %d store queries for failing path, want 1", n)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, errDown) {
+			t.Errorf("got error %v, want %v", err, errDown)
+		}
+	}
+}
+
+func TestCoalescedLookupsCanceled(t *testing.T) {
+	store := newSlowStore("https://example.com", nil)
+	var got error
+	h := StoreHandler(store, notFound, WithCoalescedLookups(),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			got = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+
+	// The first request starts the query and waits for it.
+	waiting := make(chan struct{})
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		h.ServeHTTP(w, r.WithContext(waitingContext{Context: context.Background(), waiting: waiting}))
+		first <- w
+	}()
+	<-waiting
+
+	// The second joins it, and gives up when it is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	second := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		h.ServeHTTP(w, r.WithContext(waitingContext{Context: ctx, waiting: waiting}))
+		second <- w
+	}()
+	<-waiting
+	cancel()
+	wantStatus(t, <-second, http.StatusServiceUnavailable)
+	if !errors.Is(got, context.Canceled) {
+		t.Errorf("got error %v, want %v", got, context.Canceled)
+	}
+
+	close(store.release)
+	wantRedirect(t, <-first, http.StatusMovedPermanently, "https://example.com")
+	if n := store.calls.Load(); n != 1 {
+		t.Errorf("got %d store queries, want 1", n)
+	}
+}
+
+func TestCoalescedLookupsWithoutCancellation(t *testing.T) {
+	store := newSlowStore("https://example.com", nil)
+	h := StoreHandler(store, notFound, WithCoalescedLookups())
+
+	// The request starting the query is canceled, which neither fails
+	// the other request waiting for it nor cancels the query.
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan struct{})
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		h.ServeHTTP(w, r.WithContext(waitingContext{Context: ctx, waiting: waiting}))
+		first <- w
+	}()
+	<-waiting
+	second := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/a", nil)
+		h.ServeHTTP(w, r.WithContext(waitingContext{Context: context.Background(), waiting: waiting}))
+		second <- w
+	}()
+	<-waiting
+	cancel()
+	wantStatus(t, <-first, http.StatusBadGateway)
+
+	close(store.release)
+	wantRedirect(t, <-second, http.StatusMovedPermanently, "https://example.com")
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.canceled {
+		t.Error("store query was canceled with the request that started it")
+	}
+}
+
+func TestStoreHandlerUncoalesced(t *testing.T) {
+	store := newSlowStore("https://example.com", nil)
+	close(store.release)
+	h := StoreHandler(store, notFound)
+	for range 3 {
+		wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://example.com")
+	}
+	if n := store.calls.Load(); n != 3 {
+		t.Errorf("got %d store queries for 3 requests, want 3", n)
+	}
+}
@@ -0,0 +1,57 @@
+package urlshort
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLDocument is YAML mapping data parsed by
+// ParseYAMLPreservingComments for tools that edit it. Root is the
+// document node of the data, with its comments and the order and
+// style of its content; edits made to it are kept by Export.
+type YAMLDocument struct {
+	Root *yaml.Node
+}
+
+// ParseYAMLPreservingComments parses raw YAML mapping as ParseYAML
+// does, returning the entries along with the document they were
+// parsed from, comments included. See YAMLHandler for the expected
+// format, and DecodeOption for the meaning of opts.
+func ParseYAMLPreservingComments(yml []byte, opts ...DecodeOption) (*YAMLDocument, []MappingEntry, error) {
+	entries, err := ParseYAML(yml, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var root yaml.Node
+	err = yaml.Unmarshal(yml, &root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &YAMLDocument{Root: &root}, entries, nil
+}
+
+// Export encodes the document with its comments, indented like the
+// output of ExportYAML. Unlike ExportYAML, it keeps the order of the
+// entries as they are in the document. Blank lines between entries
+// are not kept, as the document does not record them. An empty
+// document is exported as no bytes.
+func (d *YAMLDocument) Export() ([]byte, error) {
+	if d.Root == nil || d.Root.Kind == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d.Root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
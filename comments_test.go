@@ -0,0 +1,81 @@
+package urlshort
+
+import "testing"
+
+func TestParseYAMLPreservingComments(t *testing.T) {
+	yml := `# Redirects of the marketing site.
+- path: /sale # until the end of the month
+  url: https://shop.example.com/sale
+# Kept for old printed flyers.
+- path: /flyer
+  url: https://example.com/flyer
+`
+	doc, entries, err := ParseYAMLPreservingComments([]byte(yml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Path != "/sale" || entries[1].URL != "https://example.com/flyer" {
+		t.Errorf("got entries %+v", entries)
+	}
+
+	out, err := doc.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != yml {
+		t.Errorf("Export() =\n%s\nwant\n%s", out, yml)
+	}
+}
+
+func TestYAMLDocumentEdit(t *testing.T) {
+	doc, _, err := ParseYAMLPreservingComments([]byte(`- path: /a # the first one
+  url: https://a.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The document node holds the sequence of entries, whose first
+	// entry maps "path", then "url", to their values.
+	url := doc.Root.Content[0].Content[0].Content[3]
+	url.Value = "https://a2.example.com"
+
+	out, err := doc.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `- path: /a # the first one
+  url: https://a2.example.com
+`
+	if string(out) != want {
+		t.Errorf("Export() =\n%s\nwant\n%s", out, want)
+	}
+	entries, err := ParseYAML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].URL != "https://a2.example.com" {
+		t.Errorf("got URL %q after edit, want %q", entries[0].URL, "https://a2.example.com")
+	}
+}
+
+func TestParseYAMLPreservingCommentsEmpty(t *testing.T) {
+	doc, entries, err := ParseYAMLPreservingComments(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got entries %+v, want none", entries)
+	}
+	out, err := doc.Export()
+	if err != nil || len(out) != 0 {
+		t.Errorf("Export() = %q, %v, want no bytes", out, err)
+	}
+}
+
+func TestParseYAMLPreservingCommentsInvalid(t *testing.T) {
+	_, _, err := ParseYAMLPreservingComments([]byte("- path: /a\n  url: https://a.example.com\n  status: 200\n"))
+	if err == nil {
+		t.Error("accepted an invalid entry")
+	}
+}
@@ -0,0 +1,452 @@
+package urlshort
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CompiledMap is a validated set of entries, sorted by path with one
+// entry per path, built by Compile. It can be serialized at build
+// time with MarshalBinary and loaded at run time with UnmarshalBinary,
+// which trusts the data and does not validate it again. It implements
+// Mappings; the zero value maps nothing.
+type CompiledMap struct {
+	entries []MappingEntry
+}
+
+// Compile validates entries with ValidateEntries and compiles them
+// into a CompiledMap. When several entries have the same path the
+// last one wins, as it does for the other handlers built from
+// entries. entries is not modified.
+func Compile(entries []MappingEntry) (CompiledMap, error) {
+	if err := ValidateEntries(entries); err != nil {
+		return CompiledMap{}, err
+	}
+
+	sorted := sortByPath(expandPaths(entries))
+	compiled := sorted[:0]
+	for i, entry := range sorted {
+		if i+1 < len(sorted) && sorted[i+1].Path == entry.Path {
+			continue
+		}
+		compiled = append(compiled, entry)
+	}
+	return CompiledMap{entries: slices.Clip(compiled)}, nil
+}
+
+// find returns the entry of path.
+func (cm CompiledMap) find(path string) (MappingEntry, bool) {
+	i, ok := slices.BinarySearchFunc(cm.entries, path, func(e MappingEntry, path string) int {
+		return strings.Compare(e.Path, path)
+	})
+	if !ok {
+		return MappingEntry{}, false
+	}
+	return cm.entries[i], true
+}
+
+// Lookup returns the URL path is redirected to. Gone paths are not
+// redirected.
+func (cm CompiledMap) Lookup(path string) (string, bool) {
+	entry, ok := cm.find(path)
+	if !ok || entry.Gone {
+		return "", false
+	}
+	return entry.URL, true
+}
+
+// Entry returns the entry of path.
+func (cm CompiledMap) Entry(path string) (MappingEntry, bool) {
+	return cm.find(path)
+}
+
+// Entries returns every entry of cm, sorted by path.
+func (cm CompiledMap) Entries() []MappingEntry {
+	return slices.Clone(cm.entries)
+}
+
+// compiledMagic starts the serialized form of a CompiledMap, and ends
+// with its version, which is bumped whenever the layout changes.
+const compiledMagic = "urlshort\x02"
+
+// compiledFlags has the bits of the flags of the entries of a
+// serialized CompiledMap known to this version.
+const compiledFlags = 1<<10 - 1
+
+// MarshalBinary serializes cm. The format is the magic string
+// "urlshort" followed by the version byte 2 and then, as unsigned
+// varints of encoding/binary followed by their data where relevant:
+//
+//   - the number of entries;
+//   - for each entry, in order of path: the length and bytes of the
+//     path, the length and bytes of the URL, and a flags value whose
+//     bit 0 is set for gone entries, bit 1 for entries with a
+//     schedule, bit 2 for entries with variants, bit 3 for entries
+//     with a backup, bit 4 for entries with notes, bit 5 for
+//     temporary entries, bit 6 for entries with a MaxHits, bit 7 for
+//     entries with an expiry, bit 8 for disabled entries and bit 9
+//     for entries with a status;
+//   - for entries with a schedule, the number of rules and then, for
+//     each rule, the number of days followed by the length and bytes
+//     of each day, and the length and bytes of From, To and URL;
+//   - for entries with variants, the number of variants and then, for
+//     each variant, the length and bytes of its URL and its weight;
+//   - for entries with a backup, the length and bytes of the backup;
+//   - for entries with a status, its value;
+//   - for entries with a MaxHits, its value;
+//   - for entries with an expiry, the length and bytes of the expiry
+//     as encoded by time.Time.MarshalBinary;
+//   - for entries with notes, the number of notes and then, for each
+//     note in order of key, the length and bytes of its key and of its
+//     value.
+func (cm CompiledMap) MarshalBinary() ([]byte, error) {
+	b := []byte(compiledMagic)
+	b = binary.AppendUvarint(b, uint64(len(cm.entries)))
+	for _, entry := range cm.entries {
+		b = appendString(b, entry.Path)
+		b = appendString(b, entry.URL)
+		var flags uint64
+		if entry.Gone {
+			flags |= 1
+		}
+		if len(entry.Schedule) > 0 {
+			flags |= 2
+		}
+		if len(entry.Variants) > 0 {
+			flags |= 4
+		}
+		if entry.Backup != "" {
+			flags |= 8
+		}
+		if len(entry.Notes) > 0 {
+			flags |= 16
+		}
+		if entry.Temporary {
+			flags |= 32
+		}
+		if entry.MaxHits > 0 {
+			flags |= 64
+		}
+		if entry.Expires != nil {
+			flags |= 128
+		}
+		if entry.disabled() {
+			flags |= 256
+		}
+		if entry.Status != 0 {
+			flags |= 512
+		}
+		b = binary.AppendUvarint(b, flags)
+		if len(entry.Schedule) > 0 {
+			b = appendSchedule(b, entry.Schedule)
+		}
+		if len(entry.Variants) > 0 {
+			b = appendVariants(b, entry.Variants)
+		}
+		if entry.Backup != "" {
+			b = appendString(b, entry.Backup)
+		}
+		if entry.Status != 0 {
+			b = binary.AppendUvarint(b, uint64(entry.Status))
+		}
+		if entry.MaxHits > 0 {
+			b = binary.AppendUvarint(b, uint64(entry.MaxHits))
+		}
+		if entry.Expires != nil {
+			t, err := entry.Expires.MarshalBinary()
+			if err != nil {
+				return nil, fmt.Errorf("compiled map: entry %s: %w", entry.Path, err)
+			}
+			b = appendString(b, string(t))
+		}
+		if len(entry.Notes) > 0 {
+			b = appendNotes(b, entry.Notes)
+		}
+	}
+	return b, nil
+}
+
+// appendSchedule appends the serialized rules to b.
+func appendSchedule(b []byte, rules []ScheduleRule) []byte {
+	b = binary.AppendUvarint(b, uint64(len(rules)))
+	for _, rule := range rules {
+		b = binary.AppendUvarint(b, uint64(len(rule.Days)))
+		for _, day := range rule.Days {
+			b = appendString(b, day)
+		}
+		b = appendString(b, rule.From)
+		b = appendString(b, rule.To)
+		b = appendString(b, rule.URL)
+	}
+	return b
+}
+
+// appendVariants appends the serialized variants to b.
+func appendVariants(b []byte, variants []Variant) []byte {
+	b = binary.AppendUvarint(b, uint64(len(variants)))
+	for _, v := range variants {
+		b = appendString(b, v.URL)
+		b = binary.AppendUvarint(b, uint64(v.Weight))
+	}
+	return b
+}
+
+// appendNotes appends the serialized notes to b.
+func appendNotes(b []byte, notes map[string]string) []byte {
+	keys := make([]string, 0, len(notes))
+	for key := range notes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	b = binary.AppendUvarint(b, uint64(len(keys)))
+	for _, key := range keys {
+		b = appendString(b, key)
+		b = appendString(b, notes[key])
+	}
+	return b
+}
+
+// appendString appends s to b as its length followed by its bytes.
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// UnmarshalBinary loads cm from data written by MarshalBinary. Only
+// the format of data is checked: its entries are assumed to have been
+// validated and sorted by Compile. Data written by another version of
+// the format, or with entry flags unknown to this one, is rejected
+// rather than misread, as are variants that could not be picked from.
+func (cm *CompiledMap) UnmarshalBinary(data []byte) error {
+	rest, ok := bytes.CutPrefix(data, []byte(compiledMagic))
+	if !ok {
+		return errors.New("compiled map: unknown format or version")
+	}
+	r := bytes.NewReader(rest)
+	n, err := readCount(r)
+	if err != nil {
+		return fmt.Errorf("compiled map: %w", err)
+	}
+
+	entries := make([]MappingEntry, n)
+	for i := range entries {
+		path, err := readString(r)
+		if err != nil {
+			return fmt.Errorf("compiled map: entry %d: %w", i, err)
+		}
+		url, err := readString(r)
+		if err != nil {
+			return fmt.Errorf("compiled map: entry %d: %w", i, err)
+		}
+		flags, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("compiled map: entry %d: %w", i, noEOF(err))
+		}
+		if flags&^compiledFlags != 0 {
+			return fmt.Errorf("compiled map: entry %d: unknown flags %#x", i, flags&^compiledFlags)
+		}
+		entries[i] = MappingEntry{Path: path, URL: url, Gone: flags&1 != 0, Temporary: flags&32 != 0}
+		if flags&256 != 0 {
+			entries[i].Enabled = new(bool)
+		}
+		if flags&2 != 0 {
+			entries[i].Schedule, err = readSchedule(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, err)
+			}
+		}
+		if flags&4 != 0 {
+			entries[i].Variants, err = readVariants(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, err)
+			}
+		}
+		if flags&8 != 0 {
+			entries[i].Backup, err = readString(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, err)
+			}
+		}
+		if flags&512 != 0 {
+			status, err := binary.ReadUvarint(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, noEOF(err))
+			}
+			entries[i].Status = int(status)
+		}
+		if flags&64 != 0 {
+			maxHits, err := binary.ReadUvarint(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, noEOF(err))
+			}
+			entries[i].MaxHits = int(maxHits)
+		}
+		if flags&128 != 0 {
+			entries[i].Expires, err = readTime(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, err)
+			}
+		}
+		if flags&16 != 0 {
+			entries[i].Notes, err = readNotes(r)
+			if err != nil {
+				return fmt.Errorf("compiled map: entry %d: %w", i, err)
+			}
+		}
+	}
+	if r.Len() > 0 {
+		return errors.New("compiled map: trailing data")
+	}
+
+	cm.entries = entries
+	return nil
+}
+
+// readSchedule reads rules written by appendSchedule from r.
+func readSchedule(r *bytes.Reader) ([]ScheduleRule, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]ScheduleRule, n)
+	for i := range rules {
+		days, err := readCount(r)
+		if err != nil {
+			return nil, err
+		}
+		if days > 0 {
+			rules[i].Days = make([]string, days)
+		}
+		for j := range rules[i].Days {
+			if rules[i].Days[j], err = readString(r); err != nil {
+				return nil, err
+			}
+		}
+		for _, field := range []*string{&rules[i].From, &rules[i].To, &rules[i].URL} {
+			if *field, err = readString(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rules, nil
+}
+
+// readVariants reads variants written by appendVariants from r.
+func readVariants(r *bytes.Reader) ([]Variant, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errors.New("no variants")
+	}
+	variants := make([]Variant, n)
+	total := 0
+	for i := range variants {
+		if variants[i].URL, err = readString(r); err != nil {
+			return nil, err
+		}
+		weight, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		// Variants are picked in proportion to weights summed as ints.
+		if weight == 0 || weight > uint64(math.MaxInt-total) {
+			return nil, fmt.Errorf("variant %d: invalid weight %d", i+1, weight)
+		}
+		variants[i].Weight = int(weight)
+		total += variants[i].Weight
+	}
+	return variants, nil
+}
+
+// readTime reads a time written as the length and bytes of its
+// binary encoding from r.
+func readTime(r *bytes.Reader) (*time.Time, error) {
+	data, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+	t := new(time.Time)
+	if err := t.UnmarshalBinary([]byte(data)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// readNotes reads notes written by appendNotes from r.
+func readNotes(r *bytes.Reader) (map[string]string, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		key, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if notes[key], err = readString(r); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
+
+// readCount reads a number of items from r, each taking at least a
+// byte.
+func readCount(r *bytes.Reader) (int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, noEOF(err)
+	}
+	if n > uint64(r.Len()) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return int(n), nil
+}
+
+// readString reads a string written as its length followed by its
+// bytes from r.
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	r.Read(b)
+	return string(b), nil
+}
+
+// noEOF turns io.EOF, which means truncated data when more is
+// expected, into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// CompiledMapHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map any paths to
+// their corresponding URL in cm. If the path is not provided in cm,
+// then the fallback http.Handler will be called instead. The entries
+// of cm are used as they are, without further checks.
+//
+// See MapHandler for the meaning of opts.
+func CompiledMapHandler(cm CompiledMap, fallback http.Handler, opts ...Option) http.HandlerFunc {
+	lookup := func(_ *http.Request, path string) (MappingEntry, bool, error) {
+		entry, ok := cm.find(path)
+		return entry, ok, nil
+	}
+	return newHandler(lookup, fallback, newConfig(opts)).ServeHTTP
+}
@@ -0,0 +1,156 @@
+package urlshort
+
+import (
+	"encoding/binary"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	cm, err := Compile([]MappingEntry{
+		{Path: "/b", URL: "https://b1.example.com"},
+		{Paths: []string{"/c", "/a"}, URL: "https://ac.example.com"},
+		{Path: "/b", URL: "https://b2.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MappingEntry{
+		{Path: "/a", URL: "https://ac.example.com"},
+		{Path: "/b", URL: "https://b2.example.com"},
+		{Path: "/c", URL: "https://ac.example.com"},
+	}
+	if got := cm.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	_, err := Compile([]MappingEntry{{Path: "/a", URL: "https://a.example.com", Status: http.StatusOK}})
+	if err == nil {
+		t.Error("Compile accepted an entry with a non-redirect status")
+	}
+}
+
+func TestCompiledMapRoundTrip(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cm, err := Compile([]MappingEntry{
+		{Path: "/plain", URL: "https://plain.example.com"},
+		{Path: "/gone", Gone: true},
+		{Path: "/tmp", URL: "https://tmp.example.com", Temporary: true},
+		{Path: "/see", URL: "https://see.example.com", Status: http.StatusSeeOther},
+		{Path: "/split", Variants: []Variant{{URL: "https://v1.example.com", Weight: 3}, {URL: "https://v2.example.com", Weight: 1}}},
+		{
+			Path: "/full",
+			URL:  "https://full.example.com",
+			Schedule: []ScheduleRule{
+				{Days: []string{"mon", "tue"}, From: "09:00", To: "17:00", URL: "https://office.example.com"},
+				{From: "17:00", To: "09:00", URL: "https://night.example.com"},
+			},
+			Backup:  "https://backup.example.com",
+			MaxHits: 10,
+			Expires: &expires,
+			Enabled: new(bool),
+			Notes:   map[string]string{"owner": "web", "ticket": "42"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := cm.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got CompiledMap
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Entries(), cm.Entries()) {
+		t.Errorf("round trip gave\n%+v\nwant\n%+v", got.Entries(), cm.Entries())
+	}
+}
+
+func TestCompiledMapUnmarshalInvalid(t *testing.T) {
+	cm, err := Compile([]MappingEntry{{Path: "/a", URL: "https://a.example.com", Backup: "https://b.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := cm.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"older version", append([]byte("urlshort\x01"), data[len(compiledMagic):]...)},
+		{"newer version", append([]byte("urlshort\x03"), data[len(compiledMagic):]...)},
+		{"unknown flags", compiledEntry(1 << 10)},
+		{"unknown high flags", compiledEntry(1<<10 | 1)},
+		{"no variants", compiledEntry(4, 0)},
+		{"zero weight", compiledEntry(4, 2, 1, 'a', 1, 1, 'b', 0)},
+		{"zero weights", compiledEntry(4, 1, 1, 'a', 0)},
+		{"overflowing weights", compiledEntry(4, 2,
+			1, 'a', 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+			1, 'b', 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)},
+		{"truncated", data[:len(data)-1]},
+		{"truncated count", []byte(compiledMagic)},
+		{"trailing data", append(data[:len(data):len(data)], 0)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got CompiledMap
+			if err := got.UnmarshalBinary(tt.data); err == nil {
+				t.Errorf("UnmarshalBinary accepted %q", tt.data)
+			}
+		})
+	}
+}
+
+// compiledEntry returns a serialized CompiledMap of one entry for /a,
+// without a URL, with flags followed by the bytes of rest.
+func compiledEntry(flags uint64, rest ...byte) []byte {
+	b := []byte(compiledMagic)
+	b = append(b, 1, 2, '/', 'a', 0)
+	b = binary.AppendUvarint(b, flags)
+	return append(b, rest...)
+}
+
+func TestCompiledMapUnmarshalVariants(t *testing.T) {
+	var got CompiledMap
+	if err := got.UnmarshalBinary(compiledEntry(4, 2, 1, 'a', 3, 1, 'b', 1)); err != nil {
+		t.Fatal(err)
+	}
+	want := []Variant{{URL: "a", Weight: 3}, {URL: "b", Weight: 1}}
+	if entry, _ := got.Entry("/a"); !reflect.DeepEqual(entry.Variants, want) {
+		t.Errorf("got variants %+v, want %+v", entry.Variants, want)
+	}
+}
+
+func TestCompiledMapHandler(t *testing.T) {
+	cm, err := Compile([]MappingEntry{
+		{Path: "/a", URL: "https://a.example.com"},
+		{Path: "/old", Gone: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := cm.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded CompiledMap
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	h := CompiledMapHandler(loaded, notFound)
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	wantStatus(t, serve(h, "/old"), http.StatusGone)
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+	wantStatus(t, serve(CompiledMapHandler(CompiledMap{}, notFound), "/a"), http.StatusNotFound)
+}
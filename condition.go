@@ -0,0 +1,55 @@
+package urlshort
+
+import "net/http"
+
+// WithCondition makes the redirect of path conditional on cond: a
+// request for path is redirected only if cond reports true for it,
+// and is otherwise handled as if path was not mapped, so it usually
+// reaches the fallback. Giving several conditions for the same path
+// requires all of them to hold.
+//
+// path is the mapping key the condition applies to, normalized as
+// request paths are, with WithNormalizer and WithCaseInsensitive, so
+// that the condition holds whatever the form of the path of the
+// request matching the key. With WithPrefixMatch, a condition on a
+// prefix key applies to every path under it.
+//
+// This suits feature flag rollouts where the flag is set outside the
+// handler, for example:
+//
+//	urlshort.WithCondition("/dashboard", urlshort.CookieEquals("beta", "1"))
+func WithCondition(path string, cond func(r *http.Request) bool) Option {
+	return func(c *config) {
+		if c.conditions == nil {
+			c.conditions = make(map[string][]func(r *http.Request) bool)
+		}
+		c.conditions[path] = append(c.conditions[path], cond)
+	}
+}
+
+// CookieEquals returns a condition for WithCondition that holds for
+// requests carrying a cookie with the given name and value. It does
+// not hold when the cookie is absent or has any other value,
+// including the empty one.
+func CookieEquals(name, value string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		cookie, err := r.Cookie(name)
+		return err == nil && cookie.Value == value
+	}
+}
+
+// conditionsHold reports whether every condition on key, the mapping
+// key r matched, holds.
+func (c *config) conditionsHold(r *http.Request, key string) bool {
+	for path, conds := range c.conditions {
+		if c.normalize(path) != key {
+			continue
+		}
+		for _, cond := range conds {
+			if !cond(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
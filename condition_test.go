@@ -0,0 +1,94 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveWithCookie serves a GET request for target carrying cookie, if
+// not nil, with h.
+func serveWithCookie(h http.Handler, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCondition(t *testing.T) {
+	beta := &http.Cookie{Name: "beta", Value: "1"}
+	tests := []struct {
+		name   string
+		urls   map[string]string
+		opts   []Option
+		target string
+		loc    string
+	}{
+		{
+			name:   "exact",
+			urls:   map[string]string{"/dashboard": "https://new.example.com"},
+			opts:   []Option{WithCondition("/dashboard", CookieEquals("beta", "1"))},
+			target: "/dashboard",
+			loc:    "https://new.example.com",
+		},
+		{
+			name:   "case-insensitive",
+			urls:   map[string]string{"/dashboard": "https://new.example.com"},
+			opts:   []Option{WithCaseInsensitive(), WithCondition("/dashboard", CookieEquals("beta", "1"))},
+			target: "/DASHBOARD",
+			loc:    "https://new.example.com",
+		},
+		{
+			name:   "case-insensitive condition",
+			urls:   map[string]string{"/dashboard": "https://new.example.com"},
+			opts:   []Option{WithCaseInsensitive(), WithCondition("/Dashboard", CookieEquals("beta", "1"))},
+			target: "/dashboard",
+			loc:    "https://new.example.com",
+		},
+		{
+			name:   "normalizer",
+			urls:   map[string]string{"/dashboard": "https://new.example.com"},
+			opts:   []Option{WithNormalizer(LowercaseAll), WithCondition("/dashboard", CookieEquals("beta", "1"))},
+			target: "/Dashboard",
+			loc:    "https://new.example.com",
+		},
+		{
+			name:   "prefix",
+			urls:   map[string]string{"/docs/": "https://docs.example.com/"},
+			opts:   []Option{WithPrefixMatch(), WithCondition("/docs/", CookieEquals("beta", "1"))},
+			target: "/docs/x",
+			loc:    "https://docs.example.com/x",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(tt.urls, notFound, tt.opts...)
+			wantRedirect(t, serveWithCookie(h, tt.target, beta), http.StatusMovedPermanently, tt.loc)
+			wantStatus(t, serveWithCookie(h, tt.target, nil), http.StatusNotFound)
+			wantStatus(t, serveWithCookie(h, tt.target, &http.Cookie{Name: "beta", Value: "0"}), http.StatusNotFound)
+		})
+	}
+}
+
+func TestConditionOtherPaths(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://a.example.com", "/b": "https://b.example.com"}, notFound,
+		WithCondition("/a", CookieEquals("beta", "1")))
+	wantRedirect(t, serve(h, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+}
+
+func TestConditionsAllHold(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound,
+		WithCondition("/a", CookieEquals("beta", "1")),
+		WithCondition("/a", func(r *http.Request) bool { return r.Header.Get("X-Team") == "qa" }))
+	wantStatus(t, serveWithCookie(h, "/a", &http.Cookie{Name: "beta", Value: "1"}), http.StatusNotFound)
+
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	r.AddCookie(&http.Cookie{Name: "beta", Value: "1"})
+	r.Header.Set("X-Team", "qa")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	wantRedirect(t, w, http.StatusMovedPermanently, "https://a.example.com")
+}
@@ -0,0 +1,67 @@
+package urlshort
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// cors is the CORS configuration of a handler.
+type cors struct {
+	origins []string
+	methods string
+}
+
+// WithCORS makes the handler answer cross-origin requests for matched
+// paths from the given origins, such as "https://app.example.com", or
+// from any origin if origins holds "*". Preflight OPTIONS requests
+// for matched paths are answered with a 204 carrying Allow and
+// Access-Control-Allow-* headers instead of being redirected, and
+// other requests get an Access-Control-Allow-Origin header along with
+// their redirect, so that scripts can read the response. methods are
+// the methods allowed, GET and HEAD if there are none.
+//
+// The handlers of this package redirect requests whatever their
+// method, so methods only tells clients which methods they may use
+// cross-origin; requests with other methods are still redirected, but
+// browsers will not send them. Requests for paths that are not
+// matched, including OPTIONS ones, reach the fallback as usual, and
+// requests from origins that are not allowed get no CORS headers.
+func WithCORS(origins []string, methods []string) Option {
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead}
+	}
+	c := &cors{
+		origins: slices.Clone(origins),
+		methods: strings.Join(methods, ", "),
+	}
+	return func(cfg *config) {
+		cfg.cors = c
+	}
+}
+
+// handle adds the CORS headers of r to w, and answers r if it is a
+// preflight request, reporting whether it did.
+func (c *cors) handle(w http.ResponseWriter, r *http.Request) bool {
+	h := w.Header()
+	origin := r.Header.Get("Origin")
+	allowed := origin != "" && (slices.Contains(c.origins, "*") || slices.Contains(c.origins, origin))
+	h.Add("Vary", "Origin")
+	if allowed {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if r.Method != http.MethodOptions {
+		return false
+	}
+
+	h.Set("Allow", "OPTIONS, "+c.methods)
+	if allowed {
+		h.Set("Access-Control-Allow-Methods", c.methods)
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
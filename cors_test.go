@@ -0,0 +1,112 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// serveOrigin serves a request with the given method, target and
+// Origin header with h, along with the extra header values kv.
+func serveOrigin(h http.Handler, method, target, origin string, kv ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		r.Header.Set(kv[i], kv[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+var corsURLs = map[string]string{"/a": "https://a.example.com"}
+
+func TestCORSRedirect(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		allow   string
+	}{
+		{"allowed origin", []string{"https://app.example.com"}, "https://app.example.com", "https://app.example.com"},
+		{"one of several", []string{"https://x.example.com", "https://app.example.com"}, "https://app.example.com", "https://app.example.com"},
+		{"any origin", []string{"*"}, "https://app.example.com", "https://app.example.com"},
+		{"disallowed origin", []string{"https://app.example.com"}, "https://evil.example.com", ""},
+		{"no origin", []string{"*"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(corsURLs, notFound, WithCORS(tt.origins, nil))
+			w := serveOrigin(h, http.MethodGet, "/a", tt.origin)
+			wantRedirect(t, w, http.StatusMovedPermanently, "https://a.example.com")
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allow {
+				t.Errorf("got Access-Control-Allow-Origin %q, want %q", got, tt.allow)
+			}
+			if !slices.Contains(w.Header().Values("Vary"), "Origin") {
+				t.Errorf("got Vary %q, want Origin", w.Header().Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	h := MapHandler(corsURLs, notFound, WithCORS([]string{"https://app.example.com"}, []string{http.MethodGet, http.MethodPost}))
+
+	w := serveOrigin(h, http.MethodOptions, "/a", "https://app.example.com",
+		"Access-Control-Request-Method", http.MethodPost,
+		"Access-Control-Request-Headers", "X-Token")
+	wantStatus(t, w, http.StatusNoContent)
+	for name, want := range map[string]string{
+		"Location":                     "",
+		"Allow":                        "OPTIONS, GET, POST",
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Allow-Headers": "X-Token",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("got %s %q, want %q", name, got, want)
+		}
+	}
+	if vary := w.Header().Values("Vary"); !slices.Contains(vary, "Access-Control-Request-Headers") {
+		t.Errorf("got Vary %q, want Access-Control-Request-Headers", vary)
+	}
+}
+
+func TestCORSPreflightDisallowed(t *testing.T) {
+	h := MapHandler(corsURLs, notFound, WithCORS([]string{"https://app.example.com"}, nil))
+
+	w := serveOrigin(h, http.MethodOptions, "/a", "https://evil.example.com",
+		"Access-Control-Request-Method", http.MethodGet)
+	wantStatus(t, w, http.StatusNoContent)
+	if got := w.Header().Get("Allow"); got != "OPTIONS, GET, HEAD" {
+		t.Errorf("got Allow %q, want the default methods", got)
+	}
+	for _, name := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Methods", "Access-Control-Allow-Headers"} {
+		if got := w.Header().Get(name); got != "" {
+			t.Errorf("got %s %q for a disallowed origin, want none", name, got)
+		}
+	}
+}
+
+func TestCORSUnmatched(t *testing.T) {
+	h := MapHandler(corsURLs, notFound, WithCORS([]string{"*"}, nil))
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := serveOrigin(h, method, "/missing", "https://app.example.com")
+		wantStatus(t, w, http.StatusNotFound)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("%s: got Access-Control-Allow-Origin %q for an unmatched path, want none", method, got)
+		}
+	}
+}
+
+func TestCORSOff(t *testing.T) {
+	h := MapHandler(corsURLs, notFound)
+	w := serveOrigin(h, http.MethodOptions, "/a", "https://app.example.com")
+	wantRedirect(t, w, http.StatusMovedPermanently, "https://a.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("got Access-Control-Allow-Origin %q without WithCORS, want none", got)
+	}
+}
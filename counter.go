@@ -0,0 +1,105 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+	"sync"
+)
+
+// HitCounter is an http.Handler that counts the requests redirected
+// by the handler of this package it wraps, per mapping key. For exact
+// matches the key is the request path, for prefix matches it is the
+// matching prefix, and requests redirected to the default destination
+// of their host are counted under the empty key. It is safe for
+// concurrent use.
+type HitCounter struct {
+	next http.Handler
+
+	mu      sync.Mutex
+	counts  map[string]uint64
+	tracked Mappings
+	keys    *config
+}
+
+// NewHitCounter returns a HitCounter serving requests with next.
+func NewHitCounter(next http.Handler) *HitCounter {
+	return &HitCounter{
+		next:   next,
+		counts: make(map[string]uint64),
+	}
+}
+
+func (c *HitCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, rec := withRedirect(r)
+	c.next.ServeHTTP(w, r)
+	if !rec.matched {
+		return
+	}
+
+	c.mu.Lock()
+	c.counts[rec.key]++
+	c.mu.Unlock()
+}
+
+// Counts returns a copy of the current counts.
+func (c *HitCounter) Counts() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.counts)
+}
+
+// Reset zeroes every count and returns the counts from before the
+// reset. Both happen atomically, so every hit is either in the
+// returned counts or counted after the reset, never both or neither,
+// which makes it suitable for periodic flush-and-report.
+func (c *HitCounter) Reset() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := c.counts
+	c.counts = make(map[string]uint64)
+	return counts
+}
+
+// Track sets the configured redirects of the handler wrapped by c,
+// such as the Map given to MapHandler or a DynamicHandler, for
+// UnusedPaths to report on. opts are the options of that handler, so
+// that the paths of the redirects are normalized into keys as the
+// handler normalizes requests, as with WithCaseInsensitive; other
+// options are ignored.
+func (c *HitCounter) Track(m Mappings, opts ...Option) {
+	keys := newConfig(opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tracked = m
+	c.keys = keys
+}
+
+// UnusedPaths returns, sorted and as written, the paths of the
+// redirects set by Track that have not been hit since c was created
+// or last reset. The redirects are read from the Mappings at every
+// call, so paths added to a DynamicHandler are included as soon as
+// they are added. Without Track, it returns nil.
+func (c *HitCounter) UnusedPaths() []string {
+	c.mu.Lock()
+	tracked, keys := c.tracked, c.keys
+	c.mu.Unlock()
+	if tracked == nil {
+		return nil
+	}
+
+	entries := tracked.Entries()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var unused []string
+	for _, entry := range entries {
+		if c.counts[keys.normalize(entry.Path)] == 0 {
+			unused = append(unused, entry.Path)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,99 @@
+package urlshort
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestHitCounter(t *testing.T) {
+	paths := Map{"/a": "https://a.example.com", "/b": "https://b.example.com"}
+	c := NewHitCounter(MapHandler(paths, notFound))
+	c.Track(paths)
+
+	for _, target := range []string{"/a", "/a", "/missing"} {
+		serve(c, target)
+	}
+	if got, want := c.Counts(), map[string]uint64{"/a": 2}; !maps.Equal(got, want) {
+		t.Errorf("Counts() = %v, want %v", got, want)
+	}
+	if got, want := c.UnusedPaths(), []string{"/b"}; !slices.Equal(got, want) {
+		t.Errorf("UnusedPaths() = %v, want %v", got, want)
+	}
+
+	if got, want := c.Reset(), map[string]uint64{"/a": 2}; !maps.Equal(got, want) {
+		t.Errorf("Reset() = %v, want %v", got, want)
+	}
+	if got := c.Counts(); len(got) != 0 {
+		t.Errorf("Counts() after Reset = %v, want none", got)
+	}
+	if got, want := c.UnusedPaths(), []string{"/a", "/b"}; !slices.Equal(got, want) {
+		t.Errorf("UnusedPaths() after Reset = %v, want %v", got, want)
+	}
+}
+
+func TestHitCounterCaseInsensitive(t *testing.T) {
+	paths := Map{"/Docs": "https://docs.example.com", "/Blog": "https://blog.example.com"}
+	opts := []Option{WithCaseInsensitive()}
+	c := NewHitCounter(MapHandler(paths, notFound, opts...))
+	c.Track(paths, opts...)
+
+	for _, target := range []string{"/docs", "/DOCS"} {
+		serve(c, target)
+	}
+	if got, want := c.Counts(), map[string]uint64{"/docs": 2}; !maps.Equal(got, want) {
+		t.Errorf("Counts() = %v, want %v", got, want)
+	}
+	if got, want := c.UnusedPaths(), []string{"/Blog"}; !slices.Equal(got, want) {
+		t.Errorf("UnusedPaths() = %v, want %v", got, want)
+	}
+}
+
+func TestHitCounterUntracked(t *testing.T) {
+	c := NewHitCounter(MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound))
+	if got := c.UnusedPaths(); got != nil {
+		t.Errorf("UnusedPaths() = %v, want nil", got)
+	}
+}
+
+// TestHitCounterConcurrentReset checks that every hit is reported by
+// exactly one Reset, or remains counted after the last one.
+func TestHitCounterConcurrentReset(t *testing.T) {
+	const workers, hits = 8, 200
+	c := NewHitCounter(MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound))
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range hits {
+				serve(c, "/a")
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	resetting := make(chan uint64)
+	go func() {
+		var reported uint64
+		for {
+			select {
+			case <-stop:
+				resetting <- reported
+				return
+			default:
+				reported += c.Reset()["/a"]
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+
+	reported := <-resetting
+	reported += c.Reset()["/a"]
+	if reported != workers*hits {
+		t.Errorf("reported %d hits, want %d", reported, workers*hits)
+	}
+}
@@ -0,0 +1,224 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Action is what a handler does with a request, as reported in a
+// Decision.
+type Action string
+
+const (
+	// ActionRedirect redirects the request to the destination.
+	ActionRedirect Action = "redirect"
+	// ActionInterstitial answers with the page of WithInterstitial.
+	ActionInterstitial Action = "interstitial"
+	// ActionRefresh answers with the Refresh header of WithRefresh.
+	ActionRefresh Action = "refresh"
+	// ActionProxy reverse proxies the request, as by WithProxy.
+	ActionProxy Action = "proxy"
+	// ActionJSON answers with the destination as JSON, as by
+	// WithJSONResponse.
+	ActionJSON Action = "json"
+	// ActionCanonicalLink answers with the Link header of
+	// WithCanonicalLink.
+	ActionCanonicalLink Action = "canonical-link"
+	// ActionGone answers with a 410 Gone.
+	ActionGone Action = "gone"
+	// ActionCORSPreflight answers a CORS preflight request, as by
+	// WithCORS.
+	ActionCORSPreflight Action = "cors-preflight"
+	// ActionUnavailable answers with a 503 Service Unavailable, in
+	// maintenance mode or over the limit of WithConcurrencyLimit.
+	ActionUnavailable Action = "unavailable"
+	// ActionForbidden answers with a 403 Forbidden, as by
+	// WithAllowedSchemes.
+	ActionForbidden Action = "forbidden"
+	// ActionDenied answers with the status of WithDenyPatterns.
+	ActionDenied Action = "denied"
+	// ActionFallback passes the request to the fallback handler.
+	ActionFallback Action = "fallback"
+	// ActionError answers as WithErrorHandler says, because the
+	// destination could not be determined.
+	ActionError Action = "error"
+)
+
+// Decision describes what a handler of this package does with a
+// request. See Resolve.
+type Decision struct {
+	// Path is the path of the request, as used for matching.
+	Path string `json:"path"`
+	// Matched reports whether the request matched a mapping key or
+	// the default destination of its host, even if it is not
+	// redirected in the end, for example because the destination was
+	// found unreachable.
+	Matched bool `json:"matched"`
+	// Key is the mapping key that matched, which differs from Path
+	// for prefix matches, and is empty for requests that matched no
+	// key.
+	Key string `json:"key,omitempty"`
+	// Action is what the handler does with the request.
+	Action Action `json:"action"`
+	// Mapped is the URL the key is mapped to, after its schedule or
+	// variants selected it, or the backup URL if it was used instead.
+	Mapped string `json:"mapped,omitempty"`
+	// Destination is the URL the request is sent to, once every
+	// option has rewritten Mapped, for the actions sending the client
+	// to a destination.
+	Destination string `json:"destination,omitempty"`
+	// Status is the status code of the response, or 0 when it is not
+	// up to the handler, as for ActionFallback, ActionProxy and
+	// ActionError.
+	Status int `json:"status,omitempty"`
+}
+
+// resolution is the decision of a handler about a request, along
+// with what the handler needs to carry it out.
+type resolution struct {
+	Decision
+
+	err          error
+	maintenance  *maintenance
+	cors         bool
+	pathRedirect bool
+	maxHits      int
+	release      func()
+	matchTime    time.Duration
+}
+
+// act returns res with the given action and status code.
+func (res resolution) act(action Action, status int) resolution {
+	res.Action = action
+	res.Status = status
+	return res
+}
+
+// redirectPath returns res redirecting to loc, another path of the
+// handler, such as the path with a trailing slash of WithDirectoryKeys.
+func (res resolution) redirectPath(loc string, status int) resolution {
+	res.Matched = true
+	res.Destination = loc
+	res.pathRedirect = true
+	return res.act(ActionRedirect, status)
+}
+
+// fail returns res with ActionError for err.
+func (res resolution) fail(err error) resolution {
+	res.err = err
+	return res.act(ActionError, 0)
+}
+
+// resolveKey is the context key under which Resolve stores the
+// *resolveRecord handlers fill in instead of writing a response.
+type resolveKey struct{}
+
+// resolveRecord holds the decision of the last handler of this
+// package that resolved a request for Resolve.
+type resolveRecord struct {
+	decision Decision
+	err      error
+	resolved bool
+}
+
+// ErrNotResolved is returned by Resolve when no handler of this
+// package was reached by the request.
+var ErrNotResolved = errors.New("urlshort: request not resolved by a handler of this package")
+
+// Resolve returns what h, a handler of this package possibly wrapped
+// in middleware, would do with r, without writing a response. The
+// error is the one the destination could not be determined because
+// of, for ActionError, the one reporting an invalid destination (see
+// WithDestinationPolicy), and nil otherwise.
+//
+// The decision is reached as for a real request: preflight checks
+// are sent, picking a variant advances the cycle of
+// WithWeightedRoundRobin, and middleware wrapping h runs, although
+// the redirect is not recorded for HitCounter and the like. When the
+// decision is ActionFallback, the fallback of the handler is called
+// with r and a response writer discarding everything, so that a
+// handler of this package serving as fallback reports its own
+// decision instead: the fallback must be safe to call for r.
+func Resolve(h http.Handler, r *http.Request) (Decision, error) {
+	rec := &resolveRecord{}
+	h.ServeHTTP(discardWriter{}, r.WithContext(context.WithValue(r.Context(), resolveKey{}, rec)))
+	if !rec.resolved {
+		return Decision{}, ErrNotResolved
+	}
+	return rec.decision, rec.err
+}
+
+// discardWriter is an http.ResponseWriter discarding the response.
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header {
+	return http.Header{}
+}
+
+func (discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (discardWriter) WriteHeader(int) {}
+
+// PreviewHandler returns an http.Handler answering GET requests with
+// a "path" query parameter, as in "?path=/foo", with the Decision h
+// would reach for a GET request for that path, as JSON, so that a
+// configuration can be checked without following redirects:
+//
+//	{"path": "/foo", "matched": true, "key": "/foo", "action": "redirect",
+//	 "mapped": "https://example.com", "destination": "https://example.com/?ref=qa",
+//	 "status": 301}
+//
+// The path may have a query. The request resolved has the headers and
+// host of the preview request, so that conditions, host defaults and
+// content negotiation can be previewed too. An error of Resolve is
+// reported in an "error" field, along with the decision. Requests
+// without a path, or with a path that is not absolute, are answered
+// with a 400, and requests no handler of this package resolved with a
+// 502, both with an {"error": ...} object. See Resolve for the side
+// effects of resolving.
+//
+// Like APIHandler, it is meant for tooling, mounted next to h at a
+// path of its own, such as "/preview", and should not be exposed
+// publicly.
+func PreviewHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		path := r.URL.Query().Get("path")
+		if path == "" {
+			writeJSONError(w, http.StatusBadRequest, "missing path parameter")
+			return
+		}
+		u, err := url.Parse(path)
+		if err != nil || u.Scheme != "" || u.Host != "" || len(u.Path) == 0 || u.Path[0] != '/' {
+			writeJSONError(w, http.StatusBadRequest, "invalid path parameter")
+			return
+		}
+
+		pr := r.Clone(r.Context())
+		pr.URL = u
+		pr.RequestURI = u.RequestURI()
+		decision, err := Resolve(h, pr)
+		if err == ErrNotResolved {
+			writeJSONError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		var preview struct {
+			Decision
+			Error string `json:"error,omitempty"`
+		}
+		preview.Decision = decision
+		if err != nil {
+			preview.Error = err.Error()
+		}
+		writeJSON(w, http.StatusOK, preview)
+	})
+}
@@ -0,0 +1,124 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// decisionYAML maps /a to a URL with a query and marks /old gone.
+var decisionYAML = []byte(`
+- path: /a
+  url: https://a.example.com/?id=1
+- path: /old
+  gone: true
+`)
+
+func TestResolve(t *testing.T) {
+	inner := MapHandler(map[string]string{"/b": "https://b.example.com"}, notFound)
+	h, err := YAMLHandler(decisionYAML, inner, WithQueryForwarding())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		target string
+		want   Decision
+	}{
+		{"/a?ref=qa", Decision{Path: "/a", Matched: true, Key: "/a", Action: ActionRedirect, Mapped: "https://a.example.com/?id=1", Destination: "https://a.example.com/?id=1&ref=qa", Status: http.StatusMovedPermanently}},
+		{"/old", Decision{Path: "/old", Matched: true, Key: "/old", Action: ActionGone, Status: http.StatusGone}},
+		// The fallback is a handler of this package, which reports its
+		// own decision.
+		{"/b", Decision{Path: "/b", Matched: true, Key: "/b", Action: ActionRedirect, Mapped: "https://b.example.com", Destination: "https://b.example.com", Status: http.StatusMovedPermanently}},
+		{"/missing", Decision{Path: "/missing", Action: ActionFallback}},
+	} {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := Resolve(h, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveNotResolved(t *testing.T) {
+	_, err := Resolve(notFound, httptest.NewRequest(http.MethodGet, "/a", nil))
+	if err != ErrNotResolved {
+		t.Errorf("got error %v, want ErrNotResolved", err)
+	}
+}
+
+// TestResolveNoResponse checks that Resolve does not write to the
+// response, nor counts the redirect.
+func TestResolveNoResponse(t *testing.T) {
+	c := NewHitCounter(MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound))
+	if _, err := Resolve(c, httptest.NewRequest(http.MethodGet, "/a", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if counts := c.Counts(); len(counts) != 0 {
+		t.Errorf("got counts %v after Resolve, want none", counts)
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	h, err := YAMLHandler(decisionYAML, notFound, WithQueryForwarding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	preview := PreviewHandler(h)
+
+	for _, tt := range []struct {
+		target string
+		want   Decision
+	}{
+		{"/preview?path=/a%3Fref%3Dqa", Decision{Path: "/a", Matched: true, Key: "/a", Action: ActionRedirect, Mapped: "https://a.example.com/?id=1", Destination: "https://a.example.com/?id=1&ref=qa", Status: http.StatusMovedPermanently}},
+		{"/preview?path=/missing", Decision{Path: "/missing", Action: ActionFallback}},
+	} {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(preview, tt.target)
+			wantStatus(t, w, http.StatusOK)
+			var got Decision
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewHandlerErrors(t *testing.T) {
+	h, err := YAMLHandler(decisionYAML, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+		target  string
+		status  int
+	}{
+		{"missing path", PreviewHandler(h), "/preview", http.StatusBadRequest},
+		{"relative path", PreviewHandler(h), "/preview?path=a", http.StatusBadRequest},
+		{"absolute URL", PreviewHandler(h), "/preview?path=https://evil.example.com/a", http.StatusBadRequest},
+		{"not resolved", PreviewHandler(notFound), "/preview?path=/a", http.StatusBadGateway},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.handler, tt.target)
+			wantStatus(t, w, tt.status)
+			var body struct{ Error string }
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == "" {
+				t.Errorf("got body %q, want an error object", w.Body)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	PreviewHandler(h).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/preview?path=/a", nil))
+	wantStatus(t, w, http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,46 @@
+package urlshort
+
+// DecodeOption customises how mapping data is decoded by ParseYAML,
+// ParseJSON and, through WithDecodeOptions, the handlers built from
+// mapping data. The supported options are:
+//
+//   - DisallowUnknownFields, for both formats.
+//
+// Other knobs of the underlying decoders, such as the UseNumber
+// method of json.Decoder, only change how values of unknown type are
+// decoded and would have no effect on entries.
+type DecodeOption func(*decodeConfig)
+
+// decodeConfig holds the decoding behaviour selected by a list of
+// DecodeOptions.
+type decodeConfig struct {
+	disallowUnknownFields bool
+}
+
+// newDecodeConfig applies opts, in order, over the default
+// decodeConfig.
+func newDecodeConfig(opts []DecodeOption) *decodeConfig {
+	cfg := &decodeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// DisallowUnknownFields makes decoding fail on entry fields that do
+// not exist, such as a misspelt "ulr", instead of ignoring them. It
+// maps to json.Decoder.DisallowUnknownFields and
+// yaml.Decoder.KnownFields.
+func DisallowUnknownFields() DecodeOption {
+	return func(c *decodeConfig) {
+		c.disallowUnknownFields = true
+	}
+}
+
+// WithDecodeOptions makes the handlers built from mapping data, such
+// as YAMLHandler and DirHandler, decode it with opts.
+func WithDecodeOptions(opts ...DecodeOption) Option {
+	return func(c *config) {
+		c.decodeOptions = append(c.decodeOptions, opts...)
+	}
+}
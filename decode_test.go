@@ -0,0 +1,51 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDisallowUnknownFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(data []byte, opts ...DecodeOption) ([]MappingEntry, error)
+		data  string
+	}{
+		{"YAML", ParseYAML, "- path: /a\n  url: https://a.example.com\n  ulr: https://b.example.com\n"},
+		{"YAML document", ParseYAML, "redirects:\n  - path: /a\n    url: https://a.example.com\n    ulr: https://b.example.com\n"},
+		{"YAML document field", ParseYAML, "redirect:\nredirects:\n  - path: /a\n    url: https://a.example.com\n"},
+		{"JSON", ParseJSON, `[{"path": "/a", "url": "https://a.example.com", "ulr": "https://b.example.com"}]`},
+		{"JSON document", ParseJSON, `{"redirects": [{"path": "/a", "url": "https://a.example.com", "ulr": "https://b.example.com"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := tt.parse([]byte(tt.data))
+			if err != nil || len(entries) != 1 || entries[0].URL != "https://a.example.com" {
+				t.Errorf("got %+v, %v by default, want the unknown field ignored", entries, err)
+			}
+			if _, err := tt.parse([]byte(tt.data), DisallowUnknownFields()); err == nil {
+				t.Error("got no error with DisallowUnknownFields")
+			}
+		})
+	}
+}
+
+func TestWithDecodeOptions(t *testing.T) {
+	yml := []byte("- path: /a\n  url: https://a.example.com\n  ulr: https://b.example.com\n")
+
+	h, err := YAMLHandler(yml, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	if _, err := YAMLHandler(yml, notFound, WithDecodeOptions(DisallowUnknownFields())); err == nil || !strings.Contains(err.Error(), "ulr") {
+		t.Errorf("got error %v from YAMLHandler, want one naming the unknown field", err)
+	}
+
+	fsys := fstest.MapFS{"redirects.json": {Data: []byte(`[{"path": "/a", "url": "https://a.example.com", "ulr": "https://b.example.com"}]`)}}
+	if _, err := FSHandler(fsys, "redirects.json", notFound, WithDecodeOptions(DisallowUnknownFields())); err == nil || !strings.Contains(err.Error(), "ulr") {
+		t.Errorf("got error %v from FSHandler, want one naming the unknown field", err)
+	}
+}
@@ -0,0 +1,29 @@
+package urlshort
+
+import "net/http"
+
+// WithResponseWriter makes the handler write the response of every
+// matched request, whether a redirect or proxied, through the
+// http.ResponseWriter returned by decorate, for example to sign or
+// log it. Requests passed to the fallback are written to the original
+// http.ResponseWriter.
+//
+// The decorator is applied once the request is known to be matched,
+// after the decision has been recorded for LoggingHandler and any
+// other middleware of this package, and right before the response is
+// written. When several decorators are given, each wraps the
+// http.ResponseWriter returned by the previous one, so the last one
+// sees the response first.
+func WithResponseWriter(decorate func(w http.ResponseWriter) http.ResponseWriter) Option {
+	return func(c *config) {
+		c.decorators = append(c.decorators, decorate)
+	}
+}
+
+// decorate returns w wrapped in the configured decorators.
+func (c *config) decorate(w http.ResponseWriter) http.ResponseWriter {
+	for _, decorate := range c.decorators {
+		w = decorate(w)
+	}
+	return w
+}
@@ -0,0 +1,46 @@
+package urlshort
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// WithDenyPatterns makes the handler answer requests whose path, as
+// used for matching, matches any of patterns with a plain text
+// response of the given status code, such as http.StatusNotFound or
+// http.StatusForbidden, before looking the path up at all. The path
+// is checked both as requested and as normalized by WithNormalizer
+// and WithCaseInsensitive, so that a pattern for "/admin" also denies
+// "/ADMIN" where it would be matched as "/admin". It guards against
+// paths that must never redirect, such as admin paths included in a
+// mapping by mistake, however the mapping is matched: such requests
+// are not passed to the fallback or redirected to the default
+// destination of their host either. A later WithDenyPatterns replaces
+// an earlier one.
+//
+// WithDenyPatterns panics if status is not a 4xx client error status
+// code.
+func WithDenyPatterns(status int, patterns ...*regexp.Regexp) Option {
+	if status < 400 || status > 499 {
+		panic(fmt.Sprintf("urlshort: invalid deny status code %d", status))
+	}
+	return func(c *config) {
+		c.denyPatterns = patterns
+		c.denyStatus = status
+	}
+}
+
+// denied reports whether path, or path normalized, matches a pattern
+// of WithDenyPatterns.
+func (c *config) denied(path string) bool {
+	if len(c.denyPatterns) == 0 {
+		return false
+	}
+	normalized := c.normalize(path)
+	for _, re := range c.denyPatterns {
+		if re.MatchString(path) || re.MatchString(normalized) {
+			return true
+		}
+	}
+	return false
+}
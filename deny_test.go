@@ -0,0 +1,103 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var adminPaths = regexp.MustCompile(`^/(admin|wp-admin)(/|$)`)
+
+func TestDenyPatterns(t *testing.T) {
+	urls := map[string]string{
+		"/admin":       "https://admin.example.com",
+		"/admin/users": "https://admin.example.com/users",
+		"/administer":  "https://example.com/administer",
+		"/docs/":       "https://docs.example.com/",
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		status int
+		loc    string
+	}{
+		{"mapped denied", nil, "/admin", http.StatusForbidden, ""},
+		{"nested denied", nil, "/admin/users", http.StatusForbidden, ""},
+		{"unmapped denied", nil, "/wp-admin/", http.StatusForbidden, ""},
+		{"not matching", nil, "/administer", http.StatusMovedPermanently, "https://example.com/administer"},
+		{"unmapped allowed", nil, "/missing", http.StatusNotFound, ""},
+		{"case-insensitive", []Option{WithCaseInsensitive()}, "/ADMIN", http.StatusForbidden, ""},
+		{"normalizer", []Option{WithNormalizer(LowercaseFirstSegment)}, "/Admin/users", http.StatusForbidden, ""},
+		{"case-sensitive", nil, "/ADMIN", http.StatusNotFound, ""},
+		{"prefix match", []Option{WithPrefixMatch()}, "/docs/admin", http.StatusMovedPermanently, "https://docs.example.com/admin"},
+		{"host default", []Option{WithHostDefaults(map[string]string{"example.com": "https://home.example.com"})}, "/wp-admin", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, append(tt.opts, WithDenyPatterns(http.StatusForbidden, adminPaths))...)
+			w := serve(h, tt.target)
+			if tt.loc == "" {
+				wantStatus(t, w, tt.status)
+				if loc := w.Header().Get("Location"); loc != "" {
+					t.Errorf("got Location %q, want none", loc)
+				}
+				return
+			}
+			wantRedirect(t, w, tt.status, tt.loc)
+		})
+	}
+}
+
+func TestDenyPatternsSkipLookup(t *testing.T) {
+	looked := false
+	h := FuncHandler(map[string]func(r *http.Request) (string, error){
+		"/admin": func(r *http.Request) (string, error) {
+			looked = true
+			return "https://admin.example.com", nil
+		},
+	}, notFound, WithDenyPatterns(http.StatusNotFound, adminPaths))
+
+	w := serve(h, "/admin")
+	wantStatus(t, w, http.StatusNotFound)
+	if got := w.Body.String(); got != "Not Found\n" {
+		t.Errorf("got body %q, want the plain status text", got)
+	}
+	if looked {
+		t.Error("denied path was looked up")
+	}
+}
+
+func TestDenyPatternsResolve(t *testing.T) {
+	h := MapHandler(map[string]string{"/admin": "https://admin.example.com"}, notFound,
+		WithDenyPatterns(http.StatusForbidden, adminPaths))
+	d, err := Resolve(h, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Action != ActionDenied || d.Status != http.StatusForbidden || d.Matched {
+		t.Errorf("got decision %+v, want a denied one with status 403", d)
+	}
+}
+
+func TestDenyPatternsReplaced(t *testing.T) {
+	h := MapHandler(map[string]string{"/admin": "https://admin.example.com", "/secret": "https://example.com"}, notFound,
+		WithDenyPatterns(http.StatusForbidden, adminPaths),
+		WithDenyPatterns(http.StatusGone, regexp.MustCompile(`^/secret$`)))
+	wantRedirect(t, serve(h, "/admin"), http.StatusMovedPermanently, "https://admin.example.com")
+	wantStatus(t, serve(h, "/secret"), http.StatusGone)
+}
+
+func TestDenyPatternsInvalidStatus(t *testing.T) {
+	for _, code := range []int{http.StatusOK, http.StatusMovedPermanently, http.StatusInternalServerError} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WithDenyPatterns(%d) did not panic", code)
+				}
+			}()
+			WithDenyPatterns(code, adminPaths)
+		}()
+	}
+}
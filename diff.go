@@ -0,0 +1,51 @@
+package urlshort
+
+// Change describes a path mapped to different URLs by two mappings,
+// Old by the current one and New by the proposed one.
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// MappingDiff is the difference between two mappings of paths to
+// URLs, as computed by Diff. Each of its fields is sorted by path.
+type MappingDiff struct {
+	// Added are the entries of the paths mapped by the proposed
+	// mapping only.
+	Added []MappingEntry
+	// Removed are the entries of the paths mapped by the current
+	// mapping only.
+	Removed []MappingEntry
+	// Changed are the paths mapped to different URLs by the two
+	// mappings.
+	Changed []Change
+}
+
+// Empty reports whether d has no differences.
+func (d MappingDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff returns the difference between the current mapping of paths to
+// URLs and a proposed one, such as the one a reload would apply, so
+// that it can be reviewed before it is applied. Paths mapped to the
+// same URL by both are left out.
+func Diff(current, proposed map[string]string) MappingDiff {
+	var d MappingDiff
+	for _, entry := range sortedEntries(proposed) {
+		old, ok := current[entry.Path]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, entry)
+		case old != entry.URL:
+			d.Changed = append(d.Changed, Change{Path: entry.Path, Old: old, New: entry.URL})
+		}
+	}
+	for _, entry := range sortedEntries(current) {
+		if _, ok := proposed[entry.Path]; !ok {
+			d.Removed = append(d.Removed, entry)
+		}
+	}
+	return d
+}
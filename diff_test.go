@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	current := map[string]string{
+		"/same":    "https://same.example.com",
+		"/changed": "https://old.example.com",
+		"/moved":   "https://example.com/v1",
+		"/gone-b":  "https://b.example.com",
+		"/gone-a":  "https://a.example.com",
+	}
+	proposed := map[string]string{
+		"/same":    "https://same.example.com",
+		"/changed": "https://new.example.com",
+		"/moved":   "https://example.com/v2",
+		"/new-b":   "https://nb.example.com",
+		"/new-a":   "https://na.example.com",
+	}
+
+	got := Diff(current, proposed)
+	want := MappingDiff{
+		Added: []MappingEntry{
+			{Path: "/new-a", URL: "https://na.example.com"},
+			{Path: "/new-b", URL: "https://nb.example.com"},
+		},
+		Removed: []MappingEntry{
+			{Path: "/gone-a", URL: "https://a.example.com"},
+			{Path: "/gone-b", URL: "https://b.example.com"},
+		},
+		Changed: []Change{
+			{Path: "/changed", Old: "https://old.example.com", New: "https://new.example.com"},
+			{Path: "/moved", Old: "https://example.com/v1", New: "https://example.com/v2"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got.Empty() {
+		t.Error("Empty() = true for a diff with changes")
+	}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	m := map[string]string{"/a": "https://a.example.com"}
+	for _, d := range []MappingDiff{Diff(m, m), Diff(nil, nil), Diff(m, map[string]string{"/a": "https://a.example.com"})} {
+		if !d.Empty() {
+			t.Errorf("got %+v, want an empty diff", d)
+		}
+	}
+}
+
+func TestDiffFromNothing(t *testing.T) {
+	m := map[string]string{"/a": "https://a.example.com"}
+	if d := Diff(nil, m); len(d.Added) != 1 || len(d.Removed) != 0 || len(d.Changed) != 0 {
+		t.Errorf("Diff(nil, m) = %+v, want /a added", d)
+	}
+	if d := Diff(m, nil); len(d.Removed) != 1 || len(d.Added) != 0 || len(d.Changed) != 0 {
+		t.Errorf("Diff(m, nil) = %+v, want /a removed", d)
+	}
+}
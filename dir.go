@@ -0,0 +1,65 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// DirHandler will parse every mapping file in dir and then
+// return an http.HandlerFunc (which also implements
+// http.Handler) that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in any of
+// the files, then the fallback http.Handler will be called
+// instead.
+//
+// Files are selected by extension: .yaml and .yml files are
+// parsed as YAML, .json files as JSON, and everything else
+// (including subdirectories) is ignored. Files are merged in
+// alphabetical order of their names, so when two files map the
+// same path the one that sorts later wins.
+//
+// If any file cannot be read or parsed, the returned error
+// joins one error per offending file, each prefixed with the
+// file name.
+//
+// See MapHandler for the meaning of opts.
+func DirHandler(dir string, fallback http.Handler, opts ...Option) (http.HandlerFunc, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newConfig(opts)
+	var entries []MappingEntry
+	var errs []error
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		format, ok := formatFromName(file.Name())
+		if !ok {
+			continue
+		}
+
+		name := filepath.Join(dir, file.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fileEntries, err := parseMapping(format, data, cfg.decodeOptions...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		entries = append(entries, fileEntries...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return entriesHandler(entries, fallback, cfg)
+}
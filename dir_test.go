@@ -0,0 +1,104 @@
+package urlshort
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the files of contents, keyed by name, to dir.
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	t.Helper()
+	for name, data := range contents {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDirHandler(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml":    "- path: /a\n  url: https://a.example.com\n- path: /shared\n  url: https://a.example.com/shared\n",
+		"b.json":    `[{"path": "/b", "url": "https://b.example.com"}, {"path": "/shared", "url": "https://b.example.com/shared"}]`,
+		"c.yml":     "- path: /c\n  url: https://c.example.com\n",
+		"D.YAML":    "- path: /d\n  url: https://d.example.com\n",
+		"notes.txt": "not a mapping",
+		"e.toml":    "path = '/e'",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "sub.yaml"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := DirHandler(dir, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/a":      "https://a.example.com",
+		"/b":      "https://b.example.com",
+		"/c":      "https://c.example.com",
+		"/d":      "https://d.example.com",
+		"/shared": "https://b.example.com/shared",
+	} {
+		wantRedirect(t, serve(h, path), http.StatusMovedPermanently, want)
+	}
+	wantStatus(t, serve(h, "/e"), http.StatusNotFound)
+}
+
+func TestDirHandlerPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"10-base.yaml":  "- path: /a\n  url: https://base.example.com\n",
+		"20-local.yaml": "- path: /a\n  url: https://local.example.com\n",
+		"9-early.json":  `[{"path": "/a", "url": "https://early.example.com"}]`,
+	})
+	h, err := DirHandler(dir, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Names sort as strings, so 9-early.json comes last.
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://early.example.com")
+}
+
+func TestDirHandlerErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"bad.yaml": "- path: [",
+		"bad.json": "{",
+		"ok.yaml":  "- path: /a\n  url: https://a.example.com\n",
+	})
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "dangling.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := DirHandler(dir, notFound)
+	if err == nil {
+		t.Fatal("got no error for invalid files")
+	}
+	if h != nil {
+		t.Error("got a handler along with the error")
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 3 {
+		t.Fatalf("got error %v, want one per invalid file", err)
+	}
+	for _, name := range []string{"bad.yaml", "bad.json", "dangling.yml"} {
+		if !strings.Contains(err.Error(), filepath.Join(dir, name)) {
+			t.Errorf("got error %v, want one naming %s", err, name)
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want one wrapping fs.ErrNotExist", err)
+	}
+}
+
+func TestDirHandlerMissingDir(t *testing.T) {
+	if _, err := DirHandler(filepath.Join(t.TempDir(), "missing"), notFound); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// DirectoryKeyMode selects how requests for a path without a trailing
+// slash are matched against a directory-like mapping key, that is one
+// ending in a slash, such as a request for "/blog" against the key
+// "/blog/". See WithDirectoryKeys.
+type DirectoryKeyMode int
+
+const (
+	// DirectoryKeysExact matches keys only as they are written, so
+	// "/blog" does not match "/blog/". It is the default.
+	DirectoryKeysExact DirectoryKeyMode = iota
+	// DirectoryKeysRedirect redirects requests for "/blog" to
+	// "/blog/", which is then resolved like any other path, as web
+	// servers do for directories.
+	DirectoryKeysRedirect
+	// DirectoryKeysEqual resolves requests for "/blog" as if they were
+	// for "/blog/", in a single redirect.
+	DirectoryKeysEqual
+)
+
+// WithDirectoryKeys sets how the handler matches requests for a path
+// that is not mapped and does not end in a slash when the path with a
+// trailing slash is mapped. An exact match, with or without the
+// slash, always takes precedence, and the path with the slash takes
+// precedence over prefix matches (see WithPrefixMatch).
+//
+// With DirectoryKeysRedirect, the request is redirected with the
+// status of WithStatus to the last segment of its path followed by a
+// slash and its query, as in "./blog/?q=1", which the client resolves
+// against the URL it requested, so that the redirect also works
+// behind MountAt or http.StripPrefix. The other options on
+// destinations do not apply to this redirect.
+//
+// The path is checked after the normalizers of WithNormalizer have
+// run. With a normalizer removing trailing slashes, requests for
+// "/blog/" are then resolved with the key "/blog/" in both modes,
+// rather than redirected to themselves.
+func WithDirectoryKeys(mode DirectoryKeyMode) Option {
+	return func(c *config) {
+		c.directoryKeys = mode
+	}
+}
+
+// errAddSlash is returned by handler.find for requests to redirect to
+// their path with a trailing slash, with DirectoryKeysRedirect.
+var errAddSlash = errors.New("add trailing slash")
+
+// lookupDirectory returns the entry of the directory-like key of
+// path, if the configuration asks for it.
+func (h *handler) lookupDirectory(r *http.Request, path string) (entry MappingEntry, ok bool, err error) {
+	if h.cfg.directoryKeys == DirectoryKeysExact || strings.HasSuffix(path, "/") {
+		return MappingEntry{}, false, nil
+	}
+	entry, ok, err = h.lookup(r, path+"/")
+	if ok && err == nil && h.cfg.directoryKeys == DirectoryKeysRedirect && !strings.HasSuffix(h.cfg.requestPath(r), "/") {
+		return MappingEntry{}, false, errAddSlash
+	}
+	return entry, ok, err
+}
+
+// slashLocation returns the location redirecting r to its path with
+// a trailing slash.
+func slashLocation(r *http.Request) string {
+	path := r.URL.EscapedPath()
+	loc := "./" + path[strings.LastIndex(path, "/")+1:] + "/"
+	if r.URL.RawQuery != "" {
+		loc += "?" + r.URL.RawQuery
+	}
+	return loc
+}
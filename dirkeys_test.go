@@ -0,0 +1,80 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDirectoryKeys(t *testing.T) {
+	m := map[string]string{
+		"/blog/": "https://blog.example.com",
+		"/docs":  "https://docs.example.com/no-slash",
+		"/docs/": "https://docs.example.com/slash",
+	}
+	for _, tt := range []struct {
+		name   string
+		mode   DirectoryKeyMode
+		target string
+		status int
+		loc    string
+	}{
+		{"exact without slash", DirectoryKeysExact, "/blog", http.StatusNotFound, ""},
+		{"exact with slash", DirectoryKeysExact, "/blog/", http.StatusMovedPermanently, "https://blog.example.com"},
+		{"redirect without slash", DirectoryKeysRedirect, "/blog", http.StatusMovedPermanently, "./blog/"},
+		{"redirect keeps query", DirectoryKeysRedirect, "/blog?q=1", http.StatusMovedPermanently, "./blog/?q=1"},
+		{"redirect with slash", DirectoryKeysRedirect, "/blog/", http.StatusMovedPermanently, "https://blog.example.com"},
+		{"redirect unmapped", DirectoryKeysRedirect, "/news", http.StatusNotFound, ""},
+		{"equal without slash", DirectoryKeysEqual, "/blog", http.StatusMovedPermanently, "https://blog.example.com"},
+		{"equal with slash", DirectoryKeysEqual, "/blog/", http.StatusMovedPermanently, "https://blog.example.com"},
+		{"exact key wins", DirectoryKeysEqual, "/docs", http.StatusMovedPermanently, "https://docs.example.com/no-slash"},
+		{"exact key wins redirect", DirectoryKeysRedirect, "/docs", http.StatusMovedPermanently, "https://docs.example.com/no-slash"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(MapHandler(m, notFound, WithDirectoryKeys(tt.mode)), tt.target)
+			if tt.loc == "" {
+				wantStatus(t, w, tt.status)
+				return
+			}
+			wantRedirect(t, w, tt.status, tt.loc)
+		})
+	}
+}
+
+// TestDirectoryKeysMounted checks that the redirect adding a slash is
+// relative, so that it works behind http.StripPrefix.
+func TestDirectoryKeysMounted(t *testing.T) {
+	h := http.StripPrefix("/go", MapHandler(map[string]string{"/blog/": "https://blog.example.com"}, notFound,
+		WithDirectoryKeys(DirectoryKeysRedirect), WithStatus(http.StatusFound)))
+	wantRedirect(t, serve(h, "/go/blog"), http.StatusFound, "./blog/")
+}
+
+func TestDirectoryKeysPrefixMatch(t *testing.T) {
+	h := MapHandler(map[string]string{
+		"/blog/": "https://blog.example.com/index",
+		"/blog":  "https://blog.example.com",
+	}, notFound, WithDirectoryKeys(DirectoryKeysEqual), WithPrefixMatch())
+	w := serve(h, "/blog/post")
+	if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, "https://blog.example.com") {
+		t.Errorf("got Location %q for a prefix match", loc)
+	}
+}
+
+// TestDirectoryKeysNormalizer checks that requests with a trailing
+// slash removed by a normalizer still reach the keys ending in one,
+// without being redirected to themselves.
+func TestDirectoryKeysNormalizer(t *testing.T) {
+	trimSlash := func(path string) string { return strings.TrimSuffix(path, "/") }
+	for _, tt := range []struct {
+		mode DirectoryKeyMode
+		loc  string
+	}{
+		{DirectoryKeysEqual, "https://blog.example.com"},
+		{DirectoryKeysRedirect, "./blog/"},
+	} {
+		h := MapHandler(map[string]string{"/blog/": "https://blog.example.com"}, notFound,
+			WithDirectoryKeys(tt.mode), WithNormalizer(trimSlash))
+		wantRedirect(t, serve(h, "/blog/"), http.StatusMovedPermanently, "https://blog.example.com")
+		wantRedirect(t, serve(h, "/blog"), http.StatusMovedPermanently, tt.loc)
+	}
+}
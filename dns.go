@@ -0,0 +1,104 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HostResolver resolves host names, as *net.Resolver does.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+// CheckDNS checks that the host of every destination of entries, be
+// it the URL, backup, variant or schedule rule of an entry, resolves
+// with resolver, or with net.DefaultResolver if it is nil. It is
+// meant to run at startup, so that a misspelt domain fails a deploy
+// rather than the requests for it. No HTTP request is sent.
+//
+// Destinations without a host, such as relative ones, which stay on
+// the site of the request, are skipped, and so are those whose host
+// is an IP address. Every host is looked up once, concurrently, with
+// each lookup given up to timeout if it is positive, and the whole
+// check is cut short if ctx is done.
+//
+// The returned error joins one *EntryError per destination whose host
+// did not resolve, sorted by path.
+func CheckDNS(ctx context.Context, entries []MappingEntry, resolver HostResolver, timeout time.Duration) error {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	paths := make(map[string][]string)
+	for _, entry := range expandPaths(entries) {
+		for _, dest := range destinations(entry) {
+			u, err := url.Parse(dest)
+			if err != nil {
+				continue
+			}
+			host := u.Hostname()
+			if host == "" || net.ParseIP(host) != nil {
+				continue
+			}
+			paths[host] = append(paths[host], entry.Path)
+		}
+	}
+
+	var mu sync.Mutex
+	var errs []*EntryError
+	var wg sync.WaitGroup
+	for host, hostPaths := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			_, err := resolver.LookupHost(ctx, host)
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, path := range hostPaths {
+				errs = append(errs, &EntryError{Path: path, Problem: fmt.Sprintf("host %q does not resolve: %v", host, err)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Path != errs[j].Path {
+			return errs[i].Path < errs[j].Path
+		}
+		return errs[i].Problem < errs[j].Problem
+	})
+	joined := make([]error, len(errs))
+	for i, err := range errs {
+		joined[i] = err
+	}
+	return errors.Join(joined...)
+}
+
+// destinations returns every destination of entry.
+func destinations(entry MappingEntry) []string {
+	dests := []string{entry.URL, entry.Backup}
+	for _, v := range entry.Variants {
+		dests = append(dests, v.URL)
+	}
+	for _, rule := range entry.Schedule {
+		dests = append(dests, rule.URL)
+	}
+	return dests
+}
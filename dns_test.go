@@ -0,0 +1,87 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubResolver resolves the hosts it knows, records every lookup, and
+// blocks lookups of "slow.example.com" until their context is done.
+type stubResolver struct {
+	known map[string]bool
+
+	mu      sync.Mutex
+	lookups []string
+}
+
+func (s *stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	s.mu.Lock()
+	s.lookups = append(s.lookups, host)
+	s.mu.Unlock()
+
+	if host == "slow.example.com" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if !s.known[host] {
+		return nil, errors.New("no such host")
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func TestCheckDNS(t *testing.T) {
+	resolver := &stubResolver{known: map[string]bool{"example.com": true, "b.example.com": true}}
+	err := CheckDNS(context.Background(), []MappingEntry{
+		{Path: "/a", URL: "https://example.com/a"},
+		{Path: "/b", URL: "https://example.com/b", Backup: "https://b.example.com"},
+		{Path: "/typo", URL: "https://exmaple.com"},
+		{Path: "/relative", URL: "/elsewhere"},
+		{Path: "/ip", URL: "http://192.0.2.7/"},
+		{Path: "/v", Variants: []Variant{{URL: "https://example.com", Weight: 1}, {URL: "https://missing.example.com", Weight: 1}}},
+		{Path: "/s", URL: "https://example.com", Schedule: []ScheduleRule{{From: "09:00", To: "17:00", URL: "https://exmaple.com/day"}}},
+	}, resolver, 0)
+
+	var got []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var entryErr *EntryError
+		if !errors.As(err, &entryErr) {
+			t.Fatalf("got error %v, want an *EntryError", err)
+		}
+		got = append(got, entryErr.Path)
+	}
+	if want := []string{"/s", "/typo", "/v"}; !slices.Equal(got, want) {
+		t.Errorf("got errors for %v, want %v: %v", got, want, err)
+	}
+
+	slices.Sort(resolver.lookups)
+	if want := []string{"b.example.com", "example.com", "exmaple.com", "missing.example.com"}; !slices.Equal(resolver.lookups, want) {
+		t.Errorf("looked up %v, want each of %v once", resolver.lookups, want)
+	}
+}
+
+func TestCheckDNSResolved(t *testing.T) {
+	resolver := &stubResolver{known: map[string]bool{"example.com": true}}
+	err := CheckDNS(context.Background(), []MappingEntry{{Path: "/a", URL: "https://example.com"}}, resolver, 0)
+	if err != nil {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestCheckDNSTimeout(t *testing.T) {
+	resolver := &stubResolver{}
+	err := CheckDNS(context.Background(), []MappingEntry{{Path: "/slow", URL: "https://slow.example.com"}}, resolver, 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("got no error for a lookup timing out")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = CheckDNS(ctx, []MappingEntry{{Path: "/slow", URL: "https://slow.example.com"}}, resolver, 0)
+	if err == nil {
+		t.Error("got no error with a canceled context")
+	}
+}
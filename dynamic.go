@@ -0,0 +1,338 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DynamicHandler is an http.Handler that maps paths to URLs like
+// the handler returned by MapHandler, except that its mapping can be
+// changed while it is serving requests. It is safe for concurrent
+// use.
+type DynamicHandler struct {
+	handler *handler
+
+	mu       sync.RWMutex
+	paths    map[string]string
+	notes    map[string]map[string]string
+	expires  map[string]time.Time
+	disabled map[string]bool
+	persist  *persister
+
+	sweep sweeper
+}
+
+// NewDynamicHandler returns a DynamicHandler that initially maps
+// the paths in pathsToUrls, which is copied. See MapHandler for the
+// meaning of fallback and opts.
+func NewDynamicHandler(pathsToUrls map[string]string, fallback http.Handler, opts ...Option) *DynamicHandler {
+	d := &DynamicHandler{
+		paths:    maps.Clone(pathsToUrls),
+		notes:    make(map[string]map[string]string),
+		expires:  make(map[string]time.Time),
+		disabled: make(map[string]bool),
+	}
+	if d.paths == nil {
+		d.paths = make(map[string]string)
+	}
+	d.handler = newHandler(staticLookup(d.Lookup), fallback, newConfig(opts))
+	return d
+}
+
+// Resolve returns the destination r is sent to, the status code of
+// the response and whether r matched a mapping key or the default
+// destination of its host, as d would answer r, but without writing a
+// response or calling the fallback. ServeHTTP writes the response
+// Resolve describes, so the matching and the composition of the
+// destination can be checked through Resolve alone.
+//
+// The destination is empty for the responses that do not send the
+// client anywhere, such as those passing r to the fallback, and the
+// status is 0 when it is not up to the handler. These are the main
+// fields of the Decision returned by the package-level Resolve, which
+// also works for the handlers returned as an http.HandlerFunc.
+func (d *DynamicHandler) Resolve(r *http.Request) (dest string, status int, matched bool) {
+	return d.handler.Resolve(r)
+}
+
+func (d *DynamicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.handler.ServeHTTP(w, r)
+}
+
+// Lookup returns the URL path is currently mapped to. Expired and
+// disabled paths are not mapped.
+func (d *DynamicHandler) Lookup(path string) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	url, ok := d.paths[path]
+	if !ok || d.expired(path) || d.disabled[path] {
+		return "", false
+	}
+	return url, true
+}
+
+// Entry returns the entry of path, with its notes, expiry and whether
+// it is disabled.
+func (d *DynamicHandler) Entry(path string) (MappingEntry, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	url, ok := d.paths[path]
+	if !ok || d.expired(path) {
+		return MappingEntry{}, false
+	}
+	return d.entry(path, url), true
+}
+
+// Entries returns every redirect currently mapped, sorted by path,
+// with their notes, expiry and whether they are disabled. Expired
+// paths are left out.
+func (d *DynamicHandler) Entries() []MappingEntry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	entries := make([]MappingEntry, 0, len(d.paths))
+	for _, entry := range sortedEntries(d.paths) {
+		if !d.expired(entry.Path) {
+			entries = append(entries, d.entry(entry.Path, entry.URL))
+		}
+	}
+	return entries
+}
+
+// entry returns the entry mapping path to url, with the notes, expiry
+// and enabled state of path. It must be called with d.mu held.
+func (d *DynamicHandler) entry(path, url string) MappingEntry {
+	entry := MappingEntry{Path: path, URL: url, Notes: maps.Clone(d.notes[path])}
+	if expires, ok := d.expires[path]; ok {
+		entry.Expires = &expires
+	}
+	if d.disabled[path] {
+		entry.Enabled = new(bool)
+	}
+	return entry
+}
+
+// expired reports whether path has expired. It must be called with
+// d.mu held.
+func (d *DynamicHandler) expired(path string) bool {
+	expires, ok := d.expires[path]
+	return ok && !d.handler.cfg.now().Before(expires)
+}
+
+// Add maps path to url, replacing any URL, notes and expiry path was
+// mapped to, and enabling it.
+func (d *DynamicHandler) Add(path, url string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paths[path] = url
+	delete(d.notes, path)
+	delete(d.expires, path)
+	delete(d.disabled, path)
+	d.changed()
+}
+
+// Remove removes the mapping of path, if any.
+func (d *DynamicHandler) Remove(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.paths, path)
+	delete(d.notes, path)
+	delete(d.expires, path)
+	delete(d.disabled, path)
+	d.changed()
+}
+
+// Upsert maps the path of every entry of entries to its URL,
+// replacing any URL the path was mapped to and leaving the other paths
+// untouched. The notes, expiry and enabled state of the path are
+// replaced by those of the entry. Later entries win over earlier ones
+// for the same path. Entries listing Paths map each of them.
+//
+// The batch is applied atomically: if any entry is invalid, as
+// reported by MappingEntry.Validate, has no path or no URL, or uses
+// fields other than Path, URL, Notes, Expires and Enabled, which the
+// handler does not support, none is applied and the returned error joins one
+// *EntryError per invalid entry.
+func (d *DynamicHandler) Upsert(entries []MappingEntry) error {
+	entries = expandPaths(entries)
+	var errs []error
+	for _, entry := range entries {
+		if err := checkDynamicEntry(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, entry := range entries {
+		d.paths[entry.Path] = entry.URL
+		d.setNotes(entry.Path, entry.Notes)
+		d.setExpires(entry.Path, entry.Expires)
+		d.setDisabled(entry.Path, entry.disabled())
+	}
+	d.changed()
+	return nil
+}
+
+// checkDynamicEntry reports whether entry can be mapped by a
+// DynamicHandler.
+func checkDynamicEntry(entry MappingEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	switch {
+	case entry.Path == "":
+		return &EntryError{Path: entry.Path, Problem: "missing path"}
+	case entry.Gone || len(entry.Schedule) > 0 || len(entry.Variants) > 0 || entry.Backup != "" || entry.Temporary || entry.Status != 0 || entry.MaxHits != 0:
+		return &EntryError{Path: entry.Path, Problem: "only path, url, notes, expires and enabled are supported"}
+	case entry.URL == "":
+		return &EntryError{Path: entry.Path, Problem: "missing url"}
+	}
+	return nil
+}
+
+// DeletePaths removes the mapping of every path of paths, if any, at
+// once, leaving the other paths untouched.
+func (d *DynamicHandler) DeletePaths(paths []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, path := range paths {
+		delete(d.paths, path)
+		delete(d.notes, path)
+		delete(d.expires, path)
+		delete(d.disabled, path)
+	}
+	d.changed()
+}
+
+// setNotes sets the notes of path to a copy of notes.
+func (d *DynamicHandler) setNotes(path string, notes map[string]string) {
+	if len(notes) == 0 {
+		delete(d.notes, path)
+		return
+	}
+	d.notes[path] = maps.Clone(notes)
+}
+
+// setDisabled sets whether path is disabled.
+func (d *DynamicHandler) setDisabled(path string, disabled bool) {
+	if disabled {
+		d.disabled[path] = true
+	} else {
+		delete(d.disabled, path)
+	}
+}
+
+// setExpires sets the expiry of path to expires, if not nil.
+func (d *DynamicHandler) setExpires(path string, expires *time.Time) {
+	if expires == nil {
+		delete(d.expires, path)
+		return
+	}
+	d.expires[path] = *expires
+}
+
+// Replace replaces the whole mapping with pathsToUrls, which is
+// copied, dropping every note and expiry and enabling every path.
+func (d *DynamicHandler) Replace(pathsToUrls map[string]string) {
+	d.replace(pathsToUrls, nil, nil, nil)
+}
+
+// replace replaces the whole mapping with a copy of pathsToUrls and
+// the notes, expiry and disabled state of its paths in notes, expires
+// and disabled.
+func (d *DynamicHandler) replace(pathsToUrls map[string]string, notes map[string]map[string]string, expires map[string]time.Time, disabled map[string]bool) {
+	paths := maps.Clone(pathsToUrls)
+	if paths == nil {
+		paths = make(map[string]string)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paths = paths
+	d.notes = make(map[string]map[string]string)
+	for path, n := range notes {
+		if _, ok := paths[path]; ok {
+			d.setNotes(path, n)
+		}
+	}
+	d.expires = make(map[string]time.Time)
+	for path, t := range expires {
+		if _, ok := paths[path]; ok {
+			d.expires[path] = t
+		}
+	}
+	d.disabled = make(map[string]bool)
+	for path, off := range disabled {
+		if _, ok := paths[path]; ok && off {
+			d.disabled[path] = true
+		}
+	}
+	d.changed()
+}
+
+// snapshotVersion is the version of the snapshot format written by
+// Snapshot.
+const snapshotVersion = 1
+
+// snapshot is the JSON encoded form of a DynamicHandler mapping.
+type snapshot struct {
+	Version  int                          `json:"version"`
+	Paths    map[string]string            `json:"paths"`
+	Notes    map[string]map[string]string `json:"notes,omitempty"`
+	Expires  map[string]time.Time         `json:"expires,omitempty"`
+	Disabled map[string]bool              `json:"disabled,omitempty"`
+}
+
+// Snapshot returns the current mapping encoded as versioned JSON,
+// suitable for RestoreSnapshot. It can be used to start a new
+// instance, e.g. during a blue/green deploy, with the exact state of
+// a running one. It fails if an expiry cannot be encoded, as for
+// years outside of 0 to 9999.
+func (d *DynamicHandler) Snapshot() ([]byte, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	data, err := json.Marshal(snapshot{
+		Version:  snapshotVersion,
+		Paths:    d.paths,
+		Notes:    d.notes,
+		Expires:  d.expires,
+		Disabled: d.disabled,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// RestoreSnapshot replaces the whole mapping with the one encoded
+// in data by Snapshot. The mapping is left unchanged if data is not
+// a valid snapshot or was written by an unsupported version.
+func (d *DynamicHandler) RestoreSnapshot(data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	d.replace(snap.Paths, snap.Notes, snap.Expires, snap.Disabled)
+	return nil
+}
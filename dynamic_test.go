@@ -0,0 +1,187 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDynamicHandler(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+	wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+
+	d.Add("/b", "https://b.example.com")
+	wantRedirect(t, serve(d, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+
+	d.Remove("/a")
+	wantStatus(t, serve(d, "/a"), http.StatusNotFound)
+
+	d.Replace(map[string]string{"/c": "https://c.example.com"})
+	wantStatus(t, serve(d, "/b"), http.StatusNotFound)
+	wantRedirect(t, serve(d, "/c"), http.StatusMovedPermanently, "https://c.example.com")
+}
+
+func TestDynamicHandlerSnapshot(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+	restored := NewDynamicHandler(nil, notFound)
+	data, err := d.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.RestoreSnapshot(data); err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(restored, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+
+	if err := restored.RestoreSnapshot([]byte(`{"version": 99, "paths": {}}`)); err == nil {
+		t.Error("restored a snapshot of an unsupported version")
+	}
+	wantRedirect(t, serve(restored, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+}
+
+func TestDynamicHandlerSnapshotExpires(t *testing.T) {
+	d := NewDynamicHandler(nil, notFound)
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := d.Upsert([]MappingEntry{{Path: "/a", URL: "https://a.example.com", Expires: &expires}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := d.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewDynamicHandler(nil, notFound)
+	if err := restored.RestoreSnapshot(data); err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := restored.Entry("/a"); !ok || entry.Expires == nil || !entry.Expires.Equal(expires) {
+		t.Errorf("got entry %+v, want one expiring at %v", entry, expires)
+	}
+
+	// JSON cannot encode years past 9999.
+	far := time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := d.Upsert([]MappingEntry{{Path: "/far", URL: "https://far.example.com", Expires: &far}}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := d.Snapshot(); err == nil {
+		t.Errorf("got snapshot %s, want an error for an expiry in year 10000", data)
+	}
+}
+
+// TestDynamicHandlerConcurrentChanges serves requests while the
+// mapping is replaced and added to over and over, for the race
+// detector to check, and checks that every response comes from a
+// whole version of the mapping.
+func TestDynamicHandlerConcurrentChanges(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://example.com/a/0", "/b": "https://example.com/b/0"}, notFound)
+
+	done := make(chan struct{})
+	var writers, readers sync.WaitGroup
+	writers.Add(2)
+	go func() {
+		defer writers.Done()
+		for i := 1; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			d.Replace(map[string]string{
+				"/a": fmt.Sprintf("https://example.com/a/%d", i),
+				"/b": fmt.Sprintf("https://example.com/b/%d", i),
+			})
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for i := 1; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			d.Add("/b", fmt.Sprintf("https://example.com/b/%d", -i))
+		}
+	}()
+	for range 8 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for range 200 {
+				for _, path := range []string{"/a", "/b"} {
+					w := serve(d, path)
+					var v int
+					if _, err := fmt.Sscanf(w.Header().Get("Location"), "https://example.com"+path+"/%d", &v); err != nil || w.Code != http.StatusMovedPermanently {
+						t.Errorf("%s: got %d to %q", path, w.Code, w.Header().Get("Location"))
+						return
+					}
+				}
+				d.Entries()
+			}
+		}()
+	}
+	readers.Wait()
+	close(done)
+	writers.Wait()
+}
+
+func TestDynamicHandlerUpsert(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{
+		"/a": "https://a.example.com",
+		"/b": "https://b.example.com",
+	}, notFound)
+
+	err := d.Upsert([]MappingEntry{
+		{Path: "/a", URL: "https://a1.example.com", Notes: map[string]string{"owner": "web"}},
+		{Paths: []string{"/c", "/d"}, URL: "https://cd.example.com"},
+		{Path: "/a", URL: "https://a2.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a2.example.com")
+	wantRedirect(t, serve(d, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+	wantRedirect(t, serve(d, "/c"), http.StatusMovedPermanently, "https://cd.example.com")
+	wantRedirect(t, serve(d, "/d"), http.StatusMovedPermanently, "https://cd.example.com")
+	if entry, _ := d.Entry("/a"); entry.Notes != nil {
+		t.Errorf("got notes %v for /a, want the notes of the last entry", entry.Notes)
+	}
+}
+
+func TestDynamicHandlerUpsertAtomic(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+
+	err := d.Upsert([]MappingEntry{
+		{Path: "/a", URL: "https://a1.example.com"},
+		{Path: "/b", URL: "https://b.example.com", Temporary: true},
+		{URL: "https://c.example.com"},
+		{Path: "/d"},
+	})
+	var entryErr *EntryError
+	if !errors.As(err, &entryErr) {
+		t.Fatalf("got error %v, want an *EntryError", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 3 {
+		t.Errorf("got %d errors, want 3: %v", n, err)
+	}
+	wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	wantStatus(t, serve(d, "/b"), http.StatusNotFound)
+}
+
+func TestDynamicHandlerDeletePaths(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{
+		"/a": "https://a.example.com",
+		"/b": "https://b.example.com",
+		"/c": "https://c.example.com",
+	}, notFound)
+
+	d.DeletePaths([]string{"/a", "/c", "/missing"})
+	wantStatus(t, serve(d, "/a"), http.StatusNotFound)
+	wantRedirect(t, serve(d, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+	wantStatus(t, serve(d, "/c"), http.StatusNotFound)
+	if n := len(d.Entries()); n != 1 {
+		t.Errorf("got %d entries, want 1", n)
+	}
+}
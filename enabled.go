@@ -0,0 +1,25 @@
+package urlshort
+
+// disabled reports whether entry is disabled.
+func (entry MappingEntry) disabled() bool {
+	return entry.Enabled != nil && !*entry.Enabled
+}
+
+// SetEnabled enables or disables path, if it is mapped, without
+// changing its entry otherwise. A disabled path is passed to the
+// fallback, as if it was not mapped, until it is enabled again or
+// mapped anew by Add, Upsert or Replace; see MappingEntry.Enabled.
+func (d *DynamicHandler) SetEnabled(path string, on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.paths[path]; !ok || d.disabled[path] == !on {
+		return
+	}
+	if on {
+		delete(d.disabled, path)
+	} else {
+		d.disabled[path] = true
+	}
+	d.changed()
+}
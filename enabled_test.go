@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSetEnabled(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+
+	d.SetEnabled("/a", false)
+	wantStatus(t, serve(d, "/a"), http.StatusNotFound)
+	if _, ok := d.Lookup("/a"); ok {
+		t.Error("disabled path found by Lookup")
+	}
+	if entry, ok := d.Entry("/a"); !ok || !entry.disabled() {
+		t.Errorf("got entry %+v, %v, want a disabled entry", entry, ok)
+	}
+
+	d.SetEnabled("/a", true)
+	wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+
+	d.SetEnabled("/b", false)
+	if _, ok := d.Entry("/b"); ok {
+		t.Error("SetEnabled mapped an unmapped path")
+	}
+}
+
+func TestDisabledEntry(t *testing.T) {
+	yml := "- path: /a\n  url: https://a.example.com\n  enabled: false\n- path: /b\n  url: https://b.example.com\n  enabled: true\n"
+	h, err := YAMLHandler([]byte(yml), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus(t, serve(h, "/a"), http.StatusNotFound)
+	wantRedirect(t, serve(h, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+}
+
+func TestPurgeExpiredDropsDisabled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDynamicHandler(nil, notFound, WithClock(func() time.Time { return now }))
+	expires, off := now.Add(time.Hour), false
+	if err := d.Upsert([]MappingEntry{{Path: "/a", URL: "https://a.example.com", Expires: &expires, Enabled: &off}}); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if n := d.PurgeExpired(); n != 1 {
+		t.Fatalf("purged %d paths, want 1", n)
+	}
+	data, err := d.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Paths) != 0 || len(snap.Disabled) != 0 {
+		t.Errorf("got snapshot %+v after purge, want an empty one", snap)
+	}
+}
@@ -0,0 +1,121 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Event describes a request redirected by a handler of this package,
+// for analytics pipelines outside the process. See WithEvents.
+type Event struct {
+	// Path is the path of the request, before any prefix was removed
+	// by MountAt.
+	Path string
+	// Key is the mapping key that matched, as for HitCounter.
+	Key string
+	// Destination is the URL the request was sent to, or empty for
+	// requests answered with a 410 because the key is gone.
+	Destination string
+	// Status is the status code of the response, or 0 for proxied
+	// requests, whose status is the one of the destination.
+	Status int
+	// Time is when the response was written.
+	Time time.Time
+	// ClientIP is the host part of the RemoteAddr of the request.
+	ClientIP string
+}
+
+// EventStream sends the Events of the handlers given it with
+// WithEvents to a channel. It is safe for concurrent use.
+//
+// Sending is the only work left to the handler, so a slow consumer
+// of the channel would slow down the responses if the handler waited
+// for room in it for long. When the channel is full, an event is
+// instead dropped at once, or after waiting up to the time given to
+// NewEventStream for room, and counted by Dropped; the handler never
+// waits longer. A buffered channel sized for the bursts of requests
+// leaves room for the consumer to catch up.
+type EventStream struct {
+	ch      chan<- Event
+	wait    time.Duration
+	dropped atomic.Uint64
+}
+
+// NewEventStream returns an EventStream sending events to ch. An
+// event that finds ch full is dropped at once if wait is not
+// positive, and otherwise once it has waited for wait without room
+// being made in ch.
+func NewEventStream(ch chan<- Event, wait time.Duration) *EventStream {
+	return &EventStream{ch: ch, wait: wait}
+}
+
+// Dropped returns the number of events dropped because the channel
+// was full.
+func (s *EventStream) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// send sends e, or drops it if the channel has no room in time.
+func (s *EventStream) send(e Event) {
+	select {
+	case s.ch <- e:
+		return
+	default:
+	}
+	if s.wait <= 0 {
+		s.dropped.Add(1)
+		return
+	}
+
+	timer := time.NewTimer(s.wait)
+	defer timer.Stop()
+	select {
+	case s.ch <- e:
+	case <-timer.C:
+		s.dropped.Add(1)
+	}
+}
+
+// WithEvents makes the handler send an Event to s for every request
+// it redirects or answers with a 410 because its key is gone, once
+// the response is written. Several handlers may share s. Requests
+// passed to the fallback, answered with an error, or redirected to
+// another path of the handler, as by WithDirectoryKeys, have no
+// event. See EventStream for what happens when the channel is full.
+func WithEvents(s *EventStream) Option {
+	return func(c *config) {
+		c.events = s
+	}
+}
+
+// emitEvent sends the event of r, decided by res, to the event
+// stream of c, if any.
+func (c *config) emitEvent(r *http.Request, res resolution) {
+	if c.events == nil {
+		return
+	}
+	path, ok := OriginalPath(r)
+	if !ok {
+		path = r.URL.Path
+	}
+	c.events.send(Event{
+		Path:        path,
+		Key:         res.Key,
+		Destination: res.Destination,
+		Status:      res.Status,
+		Time:        c.now(),
+		ClientIP:    clientIP(r),
+	})
+}
+
+// clientIP returns the host part of the RemoteAddr of r, or the whole
+// RemoteAddr if it has no port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,121 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var eventsYAML = []byte(`
+- path: /a
+  url: https://a.example.com
+- path: /old
+  gone: true
+- path: /blog/
+  url: https://blog.example.com
+`)
+
+func TestEvents(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ch := make(chan Event, 10)
+	stream := NewEventStream(ch, 0)
+	h, err := YAMLHandler(eventsYAML, notFound, WithEvents(stream),
+		WithClock(func() time.Time { return now }),
+		WithDirectoryKeys(DirectoryKeysRedirect))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/a?q=1", nil)
+	r.RemoteAddr = "203.0.113.7:52100"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	wantStatus(t, serve(h, "/old"), http.StatusGone)
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+	wantStatus(t, serve(h, "/blog"), http.StatusMovedPermanently)
+	wantStatus(t, serve(MountAt("/go", h), "/go/a"), http.StatusMovedPermanently)
+
+	want := []Event{
+		{Path: "/a", Key: "/a", Destination: "https://a.example.com", Status: http.StatusMovedPermanently, Time: now, ClientIP: "203.0.113.7"},
+		{Path: "/old", Key: "/old", Status: http.StatusGone, Time: now, ClientIP: "192.0.2.1"},
+		{Path: "/go/a", Key: "/a", Destination: "https://a.example.com", Status: http.StatusMovedPermanently, Time: now, ClientIP: "192.0.2.1"},
+	}
+	if n := len(ch); n != len(want) {
+		t.Fatalf("got %d events, want %d", n, len(want))
+	}
+	for i, w := range want {
+		if got := <-ch; got != w {
+			t.Errorf("event %d: got %+v, want %+v", i, got, w)
+		}
+	}
+	if n := stream.Dropped(); n != 0 {
+		t.Errorf("got %d dropped events, want 0", n)
+	}
+}
+
+func TestEventsDropped(t *testing.T) {
+	ch := make(chan Event, 2)
+	stream := NewEventStream(ch, 0)
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithEvents(stream))
+
+	for range 5 {
+		wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	}
+	if n := len(ch); n != 2 {
+		t.Errorf("got %d events sent, want 2", n)
+	}
+	if n := stream.Dropped(); n != 3 {
+		t.Errorf("got %d dropped events, want 3", n)
+	}
+}
+
+func TestEventsWait(t *testing.T) {
+	ch := make(chan Event)
+	stream := NewEventStream(ch, time.Minute)
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithEvents(stream))
+
+	// A consumer making room in time receives the event.
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(h, "/a") }()
+	if e := <-ch; e.Key != "/a" {
+		t.Errorf("got event for %q, want /a", e.Key)
+	}
+	wantRedirect(t, <-done, http.StatusMovedPermanently, "https://a.example.com")
+
+	// Without a consumer, the event is dropped after the wait.
+	stream = NewEventStream(ch, 10*time.Millisecond)
+	h = MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithEvents(stream))
+	start := time.Now()
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Errorf("handler returned after %v, want it to wait for room", d)
+	}
+	if n := stream.Dropped(); n != 1 {
+		t.Errorf("got %d dropped events, want 1", n)
+	}
+}
+
+func TestEventsShared(t *testing.T) {
+	ch := make(chan Event, 100)
+	stream := NewEventStream(ch, 0)
+	a := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithEvents(stream))
+	b := MapHandler(map[string]string{"/b": "https://b.example.com"}, notFound, WithEvents(stream))
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(2)
+		go func() { defer wg.Done(); serve(a, "/a") }()
+		go func() { defer wg.Done(); serve(b, "/b") }()
+	}
+	wg.Wait()
+	close(ch)
+
+	keys := make(map[string]int)
+	for e := range ch {
+		keys[e.Key]++
+	}
+	if keys["/a"] != 50 || keys["/b"] != 50 {
+		t.Errorf("got events %v, want 50 for each handler", keys)
+	}
+}
@@ -0,0 +1,109 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// expired reports whether entry has expired at now.
+func (entry MappingEntry) expired(now time.Time) bool {
+	return entry.Expires != nil && !now.Before(*entry.Expires)
+}
+
+// skipInactive returns lookup with the expired and disabled entries
+// it finds reported as not found.
+func (c *config) skipInactive(lookup lookupFunc) lookupFunc {
+	return func(r *http.Request, path string) (MappingEntry, bool, error) {
+		entry, ok, err := lookup(r, path)
+		if ok && (entry.disabled() || entry.expired(c.now())) {
+			return MappingEntry{}, false, err
+		}
+		return entry, ok, err
+	}
+}
+
+// PurgeExpired removes the paths whose entries have expired, returning
+// how many were removed. Expired paths are never redirected, but are
+// only removed by PurgeExpired, on demand or run by SweepExpired, so
+// that they do not use memory forever.
+func (d *DynamicHandler) PurgeExpired() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.handler.cfg.now()
+	n := 0
+	for path, expires := range d.expires {
+		if now.Before(expires) {
+			continue
+		}
+		delete(d.paths, path)
+		delete(d.notes, path)
+		delete(d.expires, path)
+		delete(d.disabled, path)
+		n++
+	}
+	if n > 0 {
+		d.changed()
+	}
+	return n
+}
+
+// SweepExpired starts a goroutine calling PurgeExpired every interval
+// until Close is called, replacing the one started by a previous call,
+// if any. SweepExpired panics if interval is not positive.
+func (d *DynamicHandler) SweepExpired(interval time.Duration) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("urlshort: invalid sweep interval %v", interval))
+	}
+	d.sweep.mu.Lock()
+	defer d.sweep.mu.Unlock()
+
+	d.sweep.stopLocked()
+	stop, done := make(chan struct{}), make(chan struct{})
+	d.sweep.stop, d.sweep.done = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				d.PurgeExpired()
+			}
+		}
+	}()
+}
+
+// Close stops the goroutine started by SweepExpired, if any, and
+// waits for it to return. It does not write pending changes to the
+// file of BindFile; see Flush.
+func (d *DynamicHandler) Close() error {
+	d.sweep.mu.Lock()
+	defer d.sweep.mu.Unlock()
+
+	d.sweep.stopLocked()
+	return nil
+}
+
+// sweeper controls the goroutine of SweepExpired.
+type sweeper struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// stopLocked stops the goroutine, if any, and waits for it to return.
+// It must be called with s.mu held.
+func (s *sweeper) stopLocked() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
@@ -0,0 +1,141 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is a clock for WithClock that tests can move forward
+// while handlers read it.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestExpiredEntry(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h, err := YAMLHandler([]byte(`
+- path: /promo
+  url: https://shop.example.com/promo
+  expires: 2024-01-31T00:00:00Z
+`), notFound, WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus(t, serve(h, "/promo"), http.StatusMovedPermanently)
+	clock.Add(30 * 24 * time.Hour)
+	wantStatus(t, serve(h, "/promo"), http.StatusNotFound)
+}
+
+// expiringHandler returns a DynamicHandler mapping /keep forever and
+// /a and /b until an hour after the time of clock.
+func expiringHandler(t *testing.T, clock *testClock) *DynamicHandler {
+	t.Helper()
+	expires := clock.Now().Add(time.Hour)
+	d := NewDynamicHandler(map[string]string{"/keep": "https://keep.example.com"}, notFound, WithClock(clock.Now))
+	err := d.Upsert([]MappingEntry{
+		{Path: "/a", URL: "https://a.example.com", Expires: &expires},
+		{Path: "/b", URL: "https://b.example.com", Expires: &expires},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// storedPaths returns the number of paths d holds, expired or not.
+func storedPaths(d *DynamicHandler) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.paths)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	d := expiringHandler(t, clock)
+
+	if n := d.PurgeExpired(); n != 0 {
+		t.Errorf("PurgeExpired() = %d before expiry, want 0", n)
+	}
+	clock.Add(time.Hour)
+	wantStatus(t, serve(d, "/a"), http.StatusNotFound)
+	if n := storedPaths(d); n != 3 {
+		t.Errorf("got %d paths before the purge, want 3", n)
+	}
+	if n := d.PurgeExpired(); n != 2 {
+		t.Errorf("PurgeExpired() = %d, want 2", n)
+	}
+	if entries := d.Entries(); len(entries) != 1 || entries[0].Path != "/keep" {
+		t.Errorf("got entries %+v after the purge, want /keep only", entries)
+	}
+	if n := d.PurgeExpired(); n != 0 {
+		t.Errorf("PurgeExpired() = %d again, want 0", n)
+	}
+}
+
+func TestSweepExpired(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	d := expiringHandler(t, clock)
+	d.SweepExpired(time.Hour)
+	// Replaces the sweeper above.
+	d.SweepExpired(time.Millisecond)
+	defer d.Close()
+
+	clock.Add(time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for storedPaths(d) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d paths, want expired paths swept", storedPaths(d))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestSweepExpiredClose checks that Close stops the sweeper, which
+// then leaves expired entries alone.
+func TestSweepExpiredClose(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	d := expiringHandler(t, clock)
+	d.SweepExpired(time.Millisecond)
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Add(time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	if n := storedPaths(d); n != 3 {
+		t.Errorf("got %d paths after Close, want the 3 left unswept", n)
+	}
+}
+
+func TestSweepExpiredInvalidInterval(t *testing.T) {
+	d := NewDynamicHandler(nil, notFound)
+	defer d.Close()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SweepExpired(%v) did not panic", interval)
+				}
+			}()
+			d.SweepExpired(interval)
+		}()
+	}
+}
@@ -0,0 +1,50 @@
+package urlshort
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportYAML encodes entries in the format accepted by YAMLHandler.
+// Entries are written sorted by path, so exporting the same set of
+// redirects always gives the same bytes and the output diffs cleanly
+// under version control.
+func ExportYAML(entries []MappingEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(sortByPath(entries)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ExportJSON encodes entries in the format accepted by JSONHandler,
+// sorted by path like ExportYAML.
+func ExportJSON(entries []MappingEntry) ([]byte, error) {
+	data, err := json.MarshalIndent(sortByPath(entries), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// sortByPath returns a copy of entries sorted by path, or by first
+// path for entries listing Paths. Entries with the same path keep
+// their relative order.
+func sortByPath(entries []MappingEntry) []MappingEntry {
+	sorted := make([]MappingEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].firstPath() < sorted[j].firstPath()
+	})
+	return sorted
+}
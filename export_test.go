@@ -0,0 +1,83 @@
+package urlshort
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestExportDeterministic(t *testing.T) {
+	m := Map{}
+	for _, path := range []string{"/c", "/a", "/e", "/b", "/d", "/f", "/g", "/h"} {
+		m[path] = "https://example.com" + path
+	}
+	exports := []struct {
+		name   string
+		export func([]MappingEntry) ([]byte, error)
+	}{
+		{"yaml", ExportYAML},
+		{"json", ExportJSON},
+	}
+	for _, ex := range exports {
+		t.Run(ex.name, func(t *testing.T) {
+			first, err := ex.export(sortedEntries(m))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 10 {
+				// Build the entries from map iteration, in random order.
+				var entries []MappingEntry
+				for path, url := range m {
+					entries = append(entries, MappingEntry{Path: path, URL: url})
+				}
+				data, err := ex.export(entries)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, first) {
+					t.Fatalf("exports differ:\n%s\n%s", first, data)
+				}
+			}
+		})
+	}
+}
+
+func TestExportYAML(t *testing.T) {
+	data, err := ExportYAML([]MappingEntry{
+		{Path: "/b", URL: "https://b.example.com"},
+		{Path: "/a", URL: "https://a.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- path: /a\n  url: https://a.example.com\n- path: /b\n  url: https://b.example.com\n"
+	if string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	entries := []MappingEntry{
+		{Path: "/a", URL: "https://a.example.com"},
+		{Path: "/b", URL: "https://b.example.com", Notes: map[string]string{"owner": "qa"}},
+	}
+	yml, err := ExportYAML(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := ParseYAML(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsn, err := ExportJSON(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ParseJSON(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, entries) || !reflect.DeepEqual(fromJSON, entries) {
+		t.Errorf("got %+v and %+v, want %+v", fromYAML, fromJSON, entries)
+	}
+}
@@ -0,0 +1,69 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HandleAddForm returns an http.HandlerFunc mapping a path with
+// dyn.Add from a POST request whose application/x-www-form-urlencoded
+// body has path and url fields, for admin endpoints and plain HTML
+// forms. It is meant to be mounted next to, not in front of, the
+// redirect handler, behind whatever authentication the admin
+// endpoints need.
+//
+// The path must start with a slash, and the url must be either an
+// absolute URL with a host or a path starting with a single slash:
+// browsers take paths starting with two slashes, or a slash and a
+// backslash, for URLs of another host. Invalid input gets a 400
+// response and other methods than POST a 405, both with an
+// {"error": ...} JSON body, while a mapping added gets a 201 response
+// with the JSON entry it added.
+func HandleAddForm(dyn *DynamicHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid form body")
+			return
+		}
+		entry := MappingEntry{Path: r.PostForm.Get("path"), URL: r.PostForm.Get("url")}
+		if err := checkFormEntry(entry); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		dyn.Add(entry.Path, entry.URL)
+		writeJSON(w, http.StatusCreated, entry)
+	}
+}
+
+// checkFormEntry reports whether entry, read from a form by
+// HandleAddForm, can be added.
+func checkFormEntry(entry MappingEntry) error {
+	switch {
+	case entry.Path == "":
+		return fmt.Errorf("missing path")
+	case !strings.HasPrefix(entry.Path, "/"):
+		return fmt.Errorf("path must start with a slash")
+	case entry.URL == "":
+		return fmt.Errorf("missing url")
+	}
+	u, err := url.Parse(entry.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url")
+	}
+	if u.IsAbs() && u.Host == "" || !u.IsAbs() && !strings.HasPrefix(u.Path, "/") {
+		return fmt.Errorf("url must be absolute or start with a slash")
+	}
+	if isSchemeRelative(entry.URL) {
+		// Browsers read "/\evil.com" as "//evil.com", another host.
+		return fmt.Errorf("url must not be scheme-relative")
+	}
+	return nil
+}
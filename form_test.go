@@ -0,0 +1,95 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// postForm posts form to h as an application/x-www-form-urlencoded
+// body.
+func postForm(h http.Handler, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/admin/add", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandleAddForm(t *testing.T) {
+	for _, dest := range []string{"https://example.com/docs", "/local"} {
+		dyn := NewDynamicHandler(nil, notFound)
+		w := postForm(HandleAddForm(dyn), url.Values{"path": {"/docs"}, "url": {dest}})
+		wantStatus(t, w, http.StatusCreated)
+		var entry MappingEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Path != "/docs" || entry.URL != dest {
+			t.Errorf("got entry %+v, want /docs to %s", entry, dest)
+		}
+		wantRedirect(t, serve(dyn, "/docs"), http.StatusMovedPermanently, dest)
+	}
+}
+
+func TestHandleAddFormInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		form url.Values
+		msg  string
+	}{
+		{"missing path", url.Values{"url": {"https://example.com"}}, "missing path"},
+		{"relative path", url.Values{"path": {"docs"}, "url": {"https://example.com"}}, "path must start with a slash"},
+		{"missing url", url.Values{"path": {"/docs"}}, "missing url"},
+		{"unparsable url", url.Values{"path": {"/docs"}, "url": {"https://exa mple.com/%zz"}}, "invalid url"},
+		{"absolute without host", url.Values{"path": {"/docs"}, "url": {"mailto:a@example.com"}}, "url must be absolute or start with a slash"},
+		{"relative url", url.Values{"path": {"/docs"}, "url": {"docs.html"}}, "url must be absolute or start with a slash"},
+		{"scheme-relative", url.Values{"path": {"/docs"}, "url": {"//evil.com"}}, "url must be absolute or start with a slash"},
+		{"slash backslash", url.Values{"path": {"/docs"}, "url": {`/\evil.com`}}, "url must not be scheme-relative"},
+		{"backslashes", url.Values{"path": {"/docs"}, "url": {`\\evil.com`}}, "url must be absolute or start with a slash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dyn := NewDynamicHandler(nil, notFound)
+			w := postForm(HandleAddForm(dyn), tt.form)
+			wantStatus(t, w, http.StatusBadRequest)
+			wantJSONError(t, w, tt.msg)
+			if n := len(dyn.Entries()); n != 0 {
+				t.Errorf("got %d entries added, want none", n)
+			}
+		})
+	}
+}
+
+func TestHandleAddFormMethod(t *testing.T) {
+	w := serve(HandleAddForm(NewDynamicHandler(nil, notFound)), "/admin/add?path=/docs&url=/x")
+	wantStatus(t, w, http.StatusMethodNotAllowed)
+	if allow := w.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("got Allow %q, want POST", allow)
+	}
+	wantJSONError(t, w, "method not allowed")
+}
+
+func TestHandleAddFormBadBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/admin/add", strings.NewReader("path=%zz"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	HandleAddForm(NewDynamicHandler(nil, notFound)).ServeHTTP(w, r)
+	wantStatus(t, w, http.StatusBadRequest)
+	wantJSONError(t, w, "invalid form body")
+}
+
+// wantJSONError checks that w has the JSON error body msg.
+func wantJSONError(t *testing.T, w *httptest.ResponseRecorder, msg string) {
+	t.Helper()
+	var body struct{ Error string }
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("got body %q, want a JSON error: %v", w.Body, err)
+	}
+	if body.Error != msg {
+		t.Errorf("got error %q, want %q", body.Error, msg)
+	}
+}
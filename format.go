@@ -0,0 +1,35 @@
+package urlshort
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// extFormats maps the file extensions understood by the file based
+// handlers to the format of their content.
+var extFormats = map[string]string{
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".json": "json",
+}
+
+// formatFromName reports the mapping format implied by the extension
+// of the file name.
+func formatFromName(name string) (string, bool) {
+	format, ok := extFormats[strings.ToLower(filepath.Ext(name))]
+	return format, ok
+}
+
+// parseMapping parses raw mapping data in the given format ("yaml" or
+// "json") to a MappingEntry slice.
+func parseMapping(format string, data []byte, opts ...DecodeOption) ([]MappingEntry, error) {
+	switch format {
+	case "yaml":
+		return ParseYAML(data, opts...)
+	case "json":
+		return ParseJSON(data, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported mapping format %q", format)
+	}
+}
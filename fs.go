@@ -0,0 +1,37 @@
+package urlshort
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+)
+
+// FSHandler will read the mapping file name from fsys, such as an
+// embed.FS holding configuration compiled into the binary, parse it
+// and then return an http.HandlerFunc (which also implements
+// http.Handler) that will attempt to map any paths to their
+// corresponding URL. If the path is not provided in the file, then
+// the fallback http.Handler will be called instead.
+//
+// The format of the file is detected from its extension as for
+// DirHandler. If the file does not exist, the returned error wraps
+// fs.ErrNotExist.
+//
+// See MapHandler for the meaning of opts.
+func FSHandler(fsys fs.FS, name string, fallback http.Handler, opts ...Option) (http.HandlerFunc, error) {
+	format, ok := formatFromName(name)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported mapping file extension", name)
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newConfig(opts)
+	entries, err := parseMapping(format, data, cfg.decodeOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return entriesHandler(entries, fallback, cfg)
+}
@@ -0,0 +1,42 @@
+package urlshort
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/redirects.yaml": {Data: []byte("- path: /a\n  url: https://a.example.com\n")},
+		"redirects.JSON":      {Data: []byte(`[{"path": "/b", "url": "https://b.example.com"}]`)},
+		"bad.yml":             {Data: []byte("- path: [")},
+		"redirects.toml":      {Data: []byte("path = '/c'")},
+	}
+
+	h, err := FSHandler(fsys, "conf/redirects.yaml", notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	wantStatus(t, serve(h, "/b"), http.StatusNotFound)
+
+	h, err = FSHandler(fsys, "redirects.JSON", notFound, WithStatus(http.StatusFound))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/b"), http.StatusFound, "https://b.example.com")
+
+	if _, err := FSHandler(fsys, "missing.yaml", notFound); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v for a missing file, want fs.ErrNotExist", err)
+	}
+	if _, err := FSHandler(fsys, "bad.yml", notFound); err == nil || !strings.HasPrefix(err.Error(), "bad.yml: ") {
+		t.Errorf("got error %v for an invalid file, want one naming it", err)
+	}
+	if _, err := FSHandler(fsys, "redirects.toml", notFound); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("got error %v for an unknown extension, want it unsupported", err)
+	}
+}
@@ -0,0 +1,54 @@
+package urlshort
+
+import "net/http"
+
+// ErrorHandler responds to a request whose destination could not be
+// determined because of err.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// WithErrorHandler makes the handler respond with eh to the requests
+// whose destination could not be determined, such as those for which
+// the function of a FuncHandler or the Store of a StoreHandler
+// returned an error. See the handler constructors for what they do by
+// default.
+func WithErrorHandler(eh ErrorHandler) Option {
+	return func(c *config) {
+		c.errorHandler = eh
+	}
+}
+
+// ErrorStatus returns an ErrorHandler that responds with the plain
+// text status code and status text of code, without revealing the
+// error to the client.
+func ErrorStatus(code int) ErrorHandler {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, http.StatusText(code), code)
+	}
+}
+
+// FuncHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map any paths
+// (keys in the map) to the URL returned by their function (values
+// in the map), which is called for every request to the path. If
+// the path is not provided in the map, then the fallback
+// http.Handler will be called instead.
+//
+// If the function returns an error, the fallback is called too,
+// unless an ErrorHandler is given with WithErrorHandler, for example
+// ErrorStatus(http.StatusInternalServerError).
+//
+// See MapHandler for the meaning of opts.
+func FuncHandler(pathsToFuncs map[string]func(r *http.Request) (string, error), fallback http.Handler, opts ...Option) http.HandlerFunc {
+	lookup := func(r *http.Request, path string) (MappingEntry, bool, error) {
+		f, ok := pathsToFuncs[path]
+		if !ok {
+			return MappingEntry{}, false, nil
+		}
+		url, err := f(r)
+		if err != nil {
+			return MappingEntry{}, false, err
+		}
+		return MappingEntry{Path: path, URL: url}, true, nil
+	}
+	return newHandler(lookup, fallback, newConfig(opts)).ServeHTTP
+}
@@ -2,4 +2,9 @@ module github.com/salehzaidan/gophercises-urlshort
 
 go 1.22.1
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/net v0.30.0
+	golang.org/x/sync v0.8.0
+	golang.org/x/text v0.19.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,17 +1,314 @@
 package urlshort
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // redirectTo writes the Location response header to url and
-// set the status code to 301 to trigger a redirect.
-func redirectTo(w http.ResponseWriter, url string) {
+// set the status code to code to trigger a redirect.
+func redirectTo(w http.ResponseWriter, url string, code int) {
 	w.Header().Add("Location", url)
-	w.WriteHeader(http.StatusMovedPermanently)
+	w.WriteHeader(code)
+}
+
+// goneTo answers a request for a path marked as gone with a 410.
+func goneTo(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+}
+
+// lookupFunc returns the entry requests like r for path are mapped
+// to. A non-nil error means the entry could not be determined, which
+// is distinct from path not being mapped.
+type lookupFunc func(r *http.Request, path string) (entry MappingEntry, ok bool, err error)
+
+// staticLookup returns a lookupFunc for a lookup of destinations that
+// does not depend on the request and cannot fail.
+func staticLookup(lookup func(path string) (string, bool)) lookupFunc {
+	return func(_ *http.Request, path string) (MappingEntry, bool, error) {
+		url, ok := lookup(path)
+		return MappingEntry{Path: path, URL: url}, ok, nil
+	}
+}
+
+// entryLookup returns a lookupFunc for the entries of m, keyed by
+// path.
+func entryLookup(m map[string]MappingEntry) lookupFunc {
+	return func(_ *http.Request, path string) (MappingEntry, bool, error) {
+		entry, ok := m[path]
+		return entry, ok, nil
+	}
+}
+
+// handler redirects the requests whose path lookup maps to a
+// destination and passes the others to fallback.
+type handler struct {
+	lookup   lookupFunc
+	fallback http.Handler
+	cfg      *config
+
+	maintenance atomic.Pointer[maintenance]
+
+	// canonical maps the lower-cased mapping keys to the keys as
+	// written, for WithCanonicalCase.
+	canonical map[string]string
+}
+
+// newHandler returns a handler configured by cfg.
+func newHandler(lookup lookupFunc, fallback http.Handler, cfg *config) *handler {
+	return &handler{
+		lookup:   cfg.skipInactive(lookup),
+		fallback: fallback,
+		cfg:      cfg,
+	}
+}
+
+// find returns the entry r is mapped to, along with the mapping key
+// that matched its path. The URL of the entry is that of the
+// redirect: the one its schedule or variants select, with the rest
+// of the path appended for prefix matches.
+func (h *handler) find(r *http.Request) (key string, entry MappingEntry, ok bool, err error) {
+	path := h.cfg.normalize(h.cfg.requestPath(r))
+	if h.cfg.rootRedirect != "" && isRoot(path) {
+		return "/", MappingEntry{Path: "/", URL: h.cfg.rootRedirect}, true, nil
+	}
+	if entry, ok, err := h.lookup(r, path); ok || err != nil {
+		return path, h.cfg.selectURL(entry), ok, err
+	}
+	if entry, ok, err := h.lookupDirectory(r, path); ok || err != nil {
+		return path + "/", h.cfg.selectURL(entry), ok, err
+	}
+	if !h.cfg.prefixMatch {
+		return path, MappingEntry{}, false, nil
+	}
+
+	entry, rest, ok, err := lookupPrefix(r, h.lookup, path)
+	key = path[:len(path)-len(rest)]
+	entry = h.cfg.selectURL(entry)
+	if !ok || err != nil || entry.Gone || h.cfg.stripOriginPath {
+		return key, entry, ok, err
+	}
+	entry.URL = appendPath(entry.URL, rest)
+	if entry.Backup != "" {
+		entry.Backup = appendPath(entry.Backup, rest)
+	}
+	return key, entry, true, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res := h.resolve(r)
+	if res.release != nil {
+		defer res.release()
+	}
+	if rec, ok := r.Context().Value(resolveKey{}).(*resolveRecord); ok {
+		rec.decision, rec.err, rec.resolved = res.Decision, res.err, true
+		if res.Action == ActionFallback {
+			h.fallback.ServeHTTP(w, r)
+		}
+		return
+	}
+	if res.err != nil && h.cfg.errorHook != nil {
+		h.cfg.errorHook(r, res.err)
+	}
+	if res.maxHits > 0 && res.Destination != "" && !h.cfg.hits.take(res.Key, res.maxHits, h.cfg.now()) {
+		res = h.cfg.hits.limit(res)
+	}
+
+	h.cfg.addServerTiming(w, res.matchTime)
+	h.write(w, r, res)
+}
+
+// Resolve returns the main fields of the decision of the handler about
+// r, without writing a response or calling the fallback.
+func (h *handler) Resolve(r *http.Request) (dest string, status int, matched bool) {
+	res := h.resolve(r)
+	if res.release != nil {
+		res.release()
+	}
+	return res.Destination, res.Status, res.Matched
+}
+
+// resolve decides what to do with r, without writing anything.
+func (h *handler) resolve(r *http.Request) resolution {
+	start := time.Now()
+	path := h.cfg.requestPath(r)
+	if h.cfg.denied(path) {
+		return resolution{Decision: Decision{Path: path}}.act(ActionDenied, h.cfg.denyStatus)
+	}
+	key, entry, ok, err := h.find(r)
+	res := resolution{
+		Decision:  Decision{Path: path},
+		matchTime: time.Since(start),
+	}
+	if err == errAddSlash {
+		return res.redirectPath(slashLocation(r), h.cfg.redirectStatus())
+	}
+	if err != nil {
+		return res.fail(err)
+	}
+	if loc, ok := h.canonicalLocation(r, key); ok {
+		return res.redirectPath(loc, h.cfg.redirectStatus())
+	}
+	if ok && !h.cfg.conditionsHold(r, key) {
+		ok = false
+	}
+	if !ok {
+		key = ""
+		entry.URL, ok = h.cfg.hostDefault(r)
+	}
+	if m := h.maintenance.Load(); m != nil && (ok || h.cfg.maintenanceMisses) {
+		res.Matched = ok
+		res.maintenance = m
+		return res.act(ActionUnavailable, http.StatusServiceUnavailable)
+	}
+	if !ok {
+		return res.act(ActionFallback, 0)
+	}
+
+	res.Matched = true
+	res.Key = key
+	res.cors = h.cfg.cors != nil
+	if res.cors && r.Method == http.MethodOptions {
+		return res.act(ActionCORSPreflight, http.StatusNoContent)
+	}
+	if entry.Gone {
+		return res.act(ActionGone, http.StatusGone)
+	}
+	if entry.MaxHits > 0 && h.cfg.hits.spent(key, entry.MaxHits, h.cfg.now()) {
+		return h.cfg.hits.limit(res)
+	}
+	res.maxHits = entry.MaxHits
+	if h.cfg.limiter != nil {
+		release, ok := h.cfg.limiter.acquire(r, key)
+		if !ok {
+			return res.act(ActionUnavailable, http.StatusServiceUnavailable)
+		}
+		res.release = release
+	}
+
+	res.Mapped = entry.URL
+	url, err := h.cfg.destination(r, entry.URL)
+	if err != nil {
+		return res.fail(err)
+	}
+	if h.cfg.preflight != nil && !h.cfg.preflight.reachable(r.Context(), entry.URL) {
+		if entry.Backup == "" {
+			return res.act(ActionFallback, 0)
+		}
+		res.Mapped = entry.Backup
+		url, err = h.cfg.destination(r, entry.Backup)
+		if err != nil {
+			return res.fail(err)
+		}
+	}
+	if err := checkDestination(url); err != nil {
+		res.err = err
+		if h.cfg.destinationPolicy == DestinationStrict {
+			return res.act(ActionFallback, 0)
+		}
+	}
+	if !h.cfg.allowedScheme(url) {
+		if h.cfg.forbidSchemes {
+			return res.act(ActionForbidden, http.StatusForbidden)
+		}
+		return res.act(ActionFallback, 0)
+	}
+	tooLong := h.cfg.tooLong(url)
+	if tooLong && !h.cfg.longInterstitial {
+		return res.act(ActionFallback, 0)
+	}
+
+	res.Destination = url
+	switch {
+	case h.cfg.jsonRequested(r):
+		return res.act(ActionJSON, http.StatusOK)
+	case h.cfg.canonicalLink:
+		return res.act(ActionCanonicalLink, http.StatusOK)
+	case tooLong || h.cfg.interstitial:
+		return res.act(ActionInterstitial, http.StatusOK)
+	case h.cfg.proxy && isAbs(url):
+		return res.act(ActionProxy, 0)
+	case h.cfg.refresh:
+		return res.act(ActionRefresh, http.StatusOK)
+	}
+	return res.act(ActionRedirect, h.cfg.entryStatus(key, entry))
+}
+
+// write carries out the decision res about r.
+func (h *handler) write(w http.ResponseWriter, r *http.Request, res resolution) {
+	if res.pathRedirect {
+		redirectTo(w, res.Destination, res.Status)
+		return
+	}
+	if res.cors && h.cfg.cors.handle(w, r) {
+		return
+	}
+
+	switch res.Action {
+	case ActionError:
+		h.fail(w, r, res.err)
+		return
+	case ActionFallback:
+		h.fallback.ServeHTTP(w, r)
+		return
+	case ActionUnavailable:
+		if res.maintenance != nil {
+			res.maintenance.unavailable(w)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	case ActionForbidden:
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	case ActionDenied:
+		http.Error(w, http.StatusText(res.Status), res.Status)
+		return
+	case ActionGone:
+		recordRedirect(r, res.Key, "")
+		defer h.cfg.emitEvent(r, res)
+		goneTo(h.cfg.decorate(w))
+		return
+	}
+
+	recordRedirect(r, res.Key, res.Destination)
+	defer h.cfg.emitEvent(r, res)
+	w = h.cfg.decorate(w)
+	if h.cfg.jsonResponse {
+		w.Header().Add("Vary", "Accept")
+	}
+	switch res.Action {
+	case ActionJSON:
+		jsonTo(w, res.Path, res.Destination)
+	case ActionCanonicalLink:
+		canonicalTo(w, res.Destination)
+	case ActionInterstitial:
+		interstitialTo(w, res.Destination)
+	case ActionProxy:
+		h.cfg.proxyTo(w, r, res.Destination)
+	case ActionRefresh:
+		refreshTo(w, res.Destination, h.cfg.refreshDelay)
+	default:
+		h.cfg.addPreloadHints(w, r, res.Mapped, res.Destination)
+		redirectTo(w, res.Destination, res.Status)
+	}
+}
+
+// fail responds to r, whose destination could not be determined
+// because of err.
+func (h *handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if h.cfg.errorHandler == nil {
+		h.fallback.ServeHTTP(w, r)
+		return
+	}
+	h.cfg.errorHandler(w, r, err)
 }
 
 // MapHandler will return an http.HandlerFunc (which also
@@ -20,28 +317,138 @@ func redirectTo(w http.ResponseWriter, url string) {
 // that each key in the map points to, in string format).
 // If the path is not provided in the map, then the fallback
 // http.Handler will be called instead.
-func MapHandler(pathsToUrls map[string]string, fallback http.Handler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		url, ok := pathsToUrls[r.URL.Path]
-		if !ok {
-			fallback.ServeHTTP(w, r)
-			return
-		}
+//
+// The behaviour of the handler can be customised with opts.
+func MapHandler(pathsToUrls map[string]string, fallback http.Handler, opts ...Option) http.HandlerFunc {
+	cfg := newConfig(opts)
+	lookup := Map(pathsToUrls).Lookup
+	if cfg.caseInsensitive {
+		lookup = Map(foldKeys(pathsToUrls)).Lookup
+	}
+	h := newHandler(staticLookup(lookup), fallback, cfg)
+	h.canonical = cfg.canonicalPaths(sortedEntries(pathsToUrls))
+	return h.ServeHTTP
+}
 
-		redirectTo(w, url)
+// NewMapHandler is like MapHandler, except that it checks the
+// mapping first, returning an error instead of a handler if it has
+// any of the problems reported by Validate, such as an empty URL or a
+// redirect cycle, or exceeds the limit of WithMaxEntries. The error
+// joins one *EntryError per problem.
+//
+// Like the constructors of the handlers parsing mapping data, it
+// returns an error for invalid input, so that every source of
+// redirects can be handled the same way.
+func NewMapHandler(pathsToUrls map[string]string, fallback http.Handler, opts ...Option) (http.Handler, error) {
+	entries := Map(pathsToUrls).Entries()
+	if err := lint(entries); err != nil {
+		return nil, err
+	}
+
+	h, err := entriesHandler(entries, fallback, newConfig(opts))
+	if err != nil {
+		return nil, err
 	}
+	return h, nil
 }
 
-// mappingEntry maps a redirect from request containing Path to URL.
-type mappingEntry struct {
-	Path string
-	URL  string
+// MappingEntry maps a redirect from request containing Path to URL.
+//
+// An entry can list several Paths instead of a single Path, such as
+// "/docs", "/documentation" and "/help", to map them all to the same
+// URL with the same settings, as if it was repeated for each of them.
+// It must not set both; see Validate. Parsing and exporting keep the
+// grouped form, and the handlers expand it.
+//
+// An entry with Gone set instead marks Path as intentionally removed:
+// requests for it are answered with a 410 Gone rather than passed to
+// the fallback. Such an entry must not have a URL; see Validate.
+//
+// An entry with Variants redirects to one of them, chosen at random
+// in proportion to their weights by default (see
+// WithWeightedRoundRobin), instead of to URL, which must then be
+// empty. An entry with a Schedule redirects to the URL of the first
+// of its rules whose window is active, and to URL or one of its
+// Variants outside of them.
+//
+// An entry with a Backup redirects to it instead when the destination
+// is found unreachable by the preflight check of WithPreflight. The
+// backup itself is not checked. Without WithPreflight, Backup is
+// ignored.
+//
+// Notes annotate the entry for operators, for example with the ticket
+// that asked for it or its owner. They play no part in matching, and
+// are reported along with the entry by the Entries method of the
+// Mappings that keep them, such as CompiledMap and DynamicHandler,
+// and by APIHandler.
+//
+// An entry with Temporary set is redirected with the temporary
+// counterpart of the status of the handler (see WithStatus): 302
+// instead of the default 301, and 307 instead of 308. A handler whose
+// status is already temporary uses it for every entry. An entry with
+// a Status is redirected with it instead, whatever the status of the
+// handler; it must be one of the codes accepted by WithStatus.
+//
+// An entry with a positive MaxHits is disabled once it has redirected
+// that many requests, and then answered with a 410 Gone or passed to
+// the fallback; see WithHitBudget.
+//
+// An entry with Expires set is no longer matched from that time on,
+// as if it was not in the mapping, which suits promotional links. A
+// DynamicHandler also removes it then, on demand or periodically; see
+// DynamicHandler.PurgeExpired.
+//
+// An entry with Enabled set to false is disabled: its path is passed
+// to the fallback, as if it was not in the mapping, which makes
+// Enabled a kill-switch for new redirects, flipped at run time by
+// DynamicHandler.SetEnabled. Entries are enabled when Enabled is nil.
+// Unlike a gone entry, answered with a 410 telling clients the path
+// was intentionally removed, a disabled entry leaves no trace in the
+// responses, so that it can be enabled again unnoticed.
+type MappingEntry struct {
+	Path      string            `yaml:"path,omitempty" json:"path,omitempty"`
+	Paths     []string          `yaml:"paths,omitempty" json:"paths,omitempty"`
+	URL       string            `yaml:"url" json:"url"`
+	Gone      bool              `yaml:"gone,omitempty" json:"gone,omitempty"`
+	Temporary bool              `yaml:"temporary,omitempty" json:"temporary,omitempty"`
+	Status    int               `yaml:"status,omitempty" json:"status,omitempty"`
+	Schedule  []ScheduleRule    `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Variants  []Variant         `yaml:"variants,omitempty" json:"variants,omitempty"`
+	Backup    string            `yaml:"backup,omitempty" json:"backup,omitempty"`
+	MaxHits   int               `yaml:"maxHits,omitempty" json:"maxHits,omitempty"`
+	Expires   *time.Time        `yaml:"expires,omitempty" json:"expires,omitempty"`
+	Enabled   *bool             `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	Notes     map[string]string `yaml:"notes,omitempty" json:"notes,omitempty"`
 }
 
-// parseYAMLMapping parses raw YAML mapping to a mappingEntry slice.
-func parseYAMLMapping(yml []byte) ([]mappingEntry, error) {
-	var entries []mappingEntry
-	err := yaml.Unmarshal(yml, &entries)
+// ParseYAML parses raw YAML mapping to a MappingEntry slice.
+// See YAMLHandler for the expected format, and DecodeOption for
+// the meaning of opts. The *EntryError of an invalid entry has the
+// line of the entry in yml.
+func ParseYAML(yml []byte, opts ...DecodeOption) ([]MappingEntry, error) {
+	cfg := newDecodeConfig(opts)
+	var node yaml.Node
+	err := yaml.Unmarshal(yml, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	locate := yamlLocator(&node)
+	var entries []MappingEntry
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+		var doc mappingDocument
+		err = decodeYAML(yml, cfg, &doc)
+		if err == nil {
+			entries, err = doc.expand(locate)
+		}
+	} else {
+		err = decodeYAML(yml, cfg, &entries)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateEntries(entries, locate)
 	if err != nil {
 		return nil, err
 	}
@@ -49,15 +456,54 @@ func parseYAMLMapping(yml []byte) ([]mappingEntry, error) {
 	return entries, nil
 }
 
-// buildMap constructs a map from path to URL given a mappingEntry slice.
-func buildMap(entries []mappingEntry) map[string]string {
-	m := make(map[string]string)
-	for _, entry := range entries {
-		m[entry.Path] = entry.URL
+// decodeYAML decodes yml into v as configured by cfg.
+func decodeYAML(yml []byte, cfg *decodeConfig, v any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(yml))
+	dec.KnownFields(cfg.disallowUnknownFields)
+
+	err := dec.Decode(v)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// buildMap constructs a map from path to entry given a MappingEntry
+// slice.
+func buildMap(entries []MappingEntry) map[string]MappingEntry {
+	m := make(map[string]MappingEntry)
+	for _, entry := range expandPaths(entries) {
+		m[entry.Path] = entry
 	}
 	return m
 }
 
+// entriesHandler builds the handler of the parsed entries, checking
+// them against the limits set by cfg.
+func entriesHandler(entries []MappingEntry, fallback http.Handler, cfg *config) (http.HandlerFunc, error) {
+	h, err := newEntriesHandler(entries, fallback, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return h.ServeHTTP, nil
+}
+
+// newEntriesHandler is like entriesHandler, but returns the handler
+// itself.
+func newEntriesHandler(entries []MappingEntry, fallback http.Handler, cfg *config) (*handler, error) {
+	if err := cfg.checkEntries(entries); err != nil {
+		return nil, err
+	}
+	if err := cfg.checkSchemes(entries); err != nil {
+		return nil, err
+	}
+
+	pathMap := buildMap(cfg.resolveEntries(cfg.foldEntries(entries)))
+	h := newHandler(entryLookup(pathMap), fallback, cfg)
+	h.canonical = cfg.canonicalPaths(entries)
+	return h, nil
+}
+
 // YAMLHandler will parse the provided YAML and then return
 // an http.HandlerFunc (which also implements http.Handler)
 // that will attempt to map any paths to their corresponding
@@ -69,25 +515,54 @@ func buildMap(entries []mappingEntry) map[string]string {
 //   - path: /some-path
 //     url: https://www.some-url.com/demo
 //
+// or, to define aliases for the destinations, in the format:
+//
+//	aliases:
+//	  shop: https://shop.some-url.com
+//	redirects:
+//	  - path: /some-product
+//	    url: shop:/product/1
+//
+// In the latter format, a URL made of the name of an alias (letters,
+// digits, "-" and "_"), a colon and an optional path starting with a
+// single slash refers to the alias, and is expanded to the URL of the
+// alias followed by the path, here
+// "https://shop.some-url.com/product/1". Referring to an undefined
+// alias is an error. Other URLs, including "https://..." ones, are
+// used as they are.
+//
 // The only errors that can be returned all related to having
-// invalid YAML data.
+// invalid YAML data, invalid entries (see MappingEntry.Validate), or
+// references to undefined aliases.
 //
 // See MapHandler to create a similar http.HandlerFunc via
-// a mapping of paths to urls.
-func YAMLHandler(yml []byte, fallback http.Handler) (http.HandlerFunc, error) {
-	entries, err := parseYAMLMapping(yml)
+// a mapping of paths to urls, and for the meaning of opts.
+func YAMLHandler(yml []byte, fallback http.Handler, opts ...Option) (http.HandlerFunc, error) {
+	return build(fallback, newConfig(append([]Option{WithYAML(yml)}, opts...)))
+}
+
+// ParseJSON parses raw JSON mapping to a MappingEntry slice.
+// See JSONHandler for the expected format, and DecodeOption for
+// the meaning of opts. The *EntryError of an invalid entry has the
+// index of the entry in jsn.
+func ParseJSON(jsn []byte, opts ...DecodeOption) ([]MappingEntry, error) {
+	cfg := newDecodeConfig(opts)
+	var entries []MappingEntry
+	var err error
+	if bytes.HasPrefix(bytes.TrimLeft(jsn, " \t\r\n"), []byte("{")) {
+		var doc mappingDocument
+		err = decodeJSON(jsn, cfg, &doc)
+		if err == nil {
+			entries, err = doc.expand(jsonLocator)
+		}
+	} else {
+		err = decodeJSON(jsn, cfg, &entries)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	pathMap := buildMap(entries)
-	return MapHandler(pathMap, fallback), nil
-}
-
-// parseJSONMapping parses raw JSON mapping to a mappingEntry slice.
-func parseJSONMapping(jsn []byte) ([]mappingEntry, error) {
-	var entries []mappingEntry
-	err := json.Unmarshal(jsn, &entries)
+	err = validateEntries(entries, jsonLocator)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +570,26 @@ func parseJSONMapping(jsn []byte) ([]mappingEntry, error) {
 	return entries, nil
 }
 
+// decodeJSON decodes jsn into v as configured by cfg.
+func decodeJSON(jsn []byte, cfg *decodeConfig, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(jsn))
+	if cfg.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	err := dec.Decode(v)
+	if err == io.EOF {
+		return errors.New("unexpected end of JSON input")
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
 // JSONHandler will parse the provided JSON and then return
 // an http.HandlerFunc (which also implements http.Handler)
 // that will attempt to map any paths to their corresponding
@@ -112,17 +607,21 @@ func parseJSONMapping(jsn []byte) ([]mappingEntry, error) {
 //
 // ]
 //
+// or, to define aliases for the destinations, in the format:
+//
+//	{
+//	  "aliases": {"shop": "https://shop.some-url.com"},
+//	  "redirects": [{"path": "/some-product", "url": "shop:/product/1"}]
+//	}
+//
+// See YAMLHandler for how aliases are expanded.
+//
 // The only errors that can be returned all related to having
-// invalid JSON data.
+// invalid JSON data, invalid entries (see MappingEntry.Validate), or
+// references to undefined aliases.
 //
 // See MapHandler to create a similar http.HandlerFunc via
-// a mapping of paths to urls.
-func JSONHandler(json []byte, fallback http.Handler) (http.HandlerFunc, error) {
-	entries, err := parseJSONMapping(json)
-	if err != nil {
-		return nil, err
-	}
-
-	pathMap := buildMap(entries)
-	return MapHandler(pathMap, fallback), nil
+// a mapping of paths to urls, and for the meaning of opts.
+func JSONHandler(json []byte, fallback http.Handler, opts ...Option) (http.HandlerFunc, error) {
+	return build(fallback, newConfig(append([]Option{WithJSON(json)}, opts...)))
 }
@@ -0,0 +1,34 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serve serves a GET request for target with h and returns the
+// response.
+func serve(h http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+// notFound is the fallback of the handlers under test.
+var notFound = http.NotFoundHandler()
+
+// wantRedirect fails t unless w redirects to loc with status.
+func wantRedirect(t *testing.T, w *httptest.ResponseRecorder, status int, loc string) {
+	t.Helper()
+	if w.Code != status || w.Header().Get("Location") != loc {
+		t.Errorf("got %d to %q, want %d to %q", w.Code, w.Header().Get("Location"), status, loc)
+	}
+}
+
+// wantStatus fails t unless w has status.
+func wantStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Errorf("got status %d, want %d", w.Code, status)
+	}
+}
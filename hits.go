@@ -0,0 +1,129 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithHitBudget sets how the handler enforces the MaxHits of entries,
+// the number of requests they redirect before being disabled, such as
+// the fixed budget of clicks of a promotional link.
+//
+// With a zero window, MaxHits is a lifetime budget: once spent, the
+// path stays disabled. With a positive window, it is the number of
+// requests redirected in any window of that length, and the path is
+// enabled again as the oldest of them leave the window. Without this
+// option, budgets are lifetime ones and disabled paths are answered
+// with a 410 Gone, as gone entries are; with fallback set, they are
+// passed to the fallback instead.
+//
+// Only requests that are redirected, or sent to the destination in
+// any other way, are counted, not those answered otherwise, such as
+// CORS preflights, nor those given to Resolve, which reports whether
+// the budget is spent without spending it. Concurrent requests never
+// spend more than the budget. Counts are kept in memory by the
+// handler, per mapping key, and are lost when the program restarts;
+// a LayeredHandler keeps them across reloads.
+func WithHitBudget(window time.Duration, fallback bool) Option {
+	return func(c *config) {
+		c.hits.window = window
+		c.hits.fallback = fallback
+	}
+}
+
+// hitCounter counts the requests redirected by each mapping key with
+// a MaxHits.
+type hitCounter struct {
+	window   time.Duration
+	fallback bool
+
+	mu   sync.Mutex
+	keys map[string]*hits
+}
+
+// hits holds the count of requests redirected by one key.
+type hits struct {
+	// total counts every request of a lifetime budget.
+	total atomic.Int64
+
+	// mu guards times, the times of the requests of a rolling budget
+	// in the current window, oldest first.
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// of returns the hits of key.
+func (hc *hitCounter) of(key string) *hits {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	h, ok := hc.keys[key]
+	if !ok {
+		if hc.keys == nil {
+			hc.keys = make(map[string]*hits)
+		}
+		h = &hits{}
+		hc.keys[key] = h
+	}
+	return h
+}
+
+// spent reports whether the budget of max hits of key is spent.
+func (hc *hitCounter) spent(key string, max int, now time.Time) bool {
+	h := hc.of(key)
+	if hc.window <= 0 {
+		return h.total.Load() >= int64(max)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expire(now.Add(-hc.window))
+	return len(h.times) >= max
+}
+
+// take counts a hit of key, reporting whether the budget of max hits
+// allowed it.
+func (hc *hitCounter) take(key string, max int, now time.Time) bool {
+	h := hc.of(key)
+	if hc.window <= 0 {
+		for {
+			n := h.total.Load()
+			if n >= int64(max) {
+				return false
+			}
+			if h.total.CompareAndSwap(n, n+1) {
+				return true
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expire(now.Add(-hc.window))
+	if len(h.times) >= max {
+		return false
+	}
+	h.times = append(h.times, now)
+	return true
+}
+
+// expire forgets the hits that happened before since.
+func (h *hits) expire(since time.Time) {
+	i := 0
+	for i < len(h.times) && !h.times[i].After(since) {
+		i++
+	}
+	h.times = append(h.times[:0], h.times[i:]...)
+}
+
+// limit returns res turned into the response to a request for a path
+// whose budget is spent.
+func (hc *hitCounter) limit(res resolution) resolution {
+	res.Mapped, res.Destination = "", ""
+	if hc.fallback {
+		return res.act(ActionFallback, 0)
+	}
+	return res.act(ActionGone, http.StatusGone)
+}
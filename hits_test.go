@@ -0,0 +1,131 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// promoYAML maps /promo with a budget of three redirects, and /other
+// without one.
+var promoYAML = []byte(`
+- path: /promo
+  url: https://shop.example.com/promo
+  maxHits: 3
+- path: /other
+  url: https://example.com
+`)
+
+func TestHitBudgetLifetime(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  []Option
+		spent int
+	}{
+		{"gone", nil, http.StatusGone},
+		{"fallback", []Option{WithHitBudget(0, true)}, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := YAMLHandler(promoYAML, notFound, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 3 {
+				wantRedirect(t, serve(h, "/promo"), http.StatusMovedPermanently, "https://shop.example.com/promo")
+			}
+			for range 2 {
+				wantStatus(t, serve(h, "/promo"), tt.spent)
+			}
+			for range 5 {
+				wantStatus(t, serve(h, "/other"), http.StatusMovedPermanently)
+			}
+		})
+	}
+}
+
+func TestHitBudgetRolling(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	h, err := YAMLHandler(promoYAML, notFound, WithHitBudget(time.Minute, false),
+		WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		wantStatus(t, serve(h, "/promo"), http.StatusMovedPermanently)
+	}
+	now = now.Add(30 * time.Second)
+	wantStatus(t, serve(h, "/promo"), http.StatusMovedPermanently)
+	wantStatus(t, serve(h, "/promo"), http.StatusGone)
+
+	// The first two hits leave the window, the third stays in it.
+	now = now.Add(31 * time.Second)
+	wantStatus(t, serve(h, "/promo"), http.StatusMovedPermanently)
+	wantStatus(t, serve(h, "/promo"), http.StatusMovedPermanently)
+	wantStatus(t, serve(h, "/promo"), http.StatusGone)
+}
+
+// TestHitBudgetConcurrent crosses the budget from many goroutines at
+// once and checks that exactly the budget is redirected.
+func TestHitBudgetConcurrent(t *testing.T) {
+	const budget, requests = 50, 400
+	yml := []byte("- path: /promo\n  url: https://shop.example.com/promo\n  maxHits: 50\n")
+	for _, window := range []time.Duration{0, time.Hour} {
+		h, err := YAMLHandler(yml, notFound, WithHitBudget(window, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var redirected, gone atomic.Int64
+		var wg sync.WaitGroup
+		for range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				switch serve(h, "/promo").Code {
+				case http.StatusMovedPermanently:
+					redirected.Add(1)
+				case http.StatusGone:
+					gone.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if redirected.Load() != budget || gone.Load() != requests-budget {
+			t.Errorf("window %v: got %d redirected and %d gone, want %d and %d",
+				window, redirected.Load(), gone.Load(), budget, requests-budget)
+		}
+	}
+}
+
+// TestHitBudgetResolve checks that Resolve reports a spent budget
+// without spending it.
+func TestHitBudgetResolve(t *testing.T) {
+	h, err := YAMLHandler(promoYAML, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		d, err := Resolve(h, httptest.NewRequest(http.MethodGet, "/promo", nil))
+		if err != nil || d.Action != ActionRedirect {
+			t.Fatalf("Resolve = %+v, %v, want a redirect", d, err)
+		}
+	}
+	for range 3 {
+		wantStatus(t, serve(h, "/promo"), http.StatusMovedPermanently)
+	}
+	d, err := Resolve(h, httptest.NewRequest(http.MethodGet, "/promo", nil))
+	if err != nil || d.Action != ActionGone {
+		t.Errorf("Resolve = %+v, %v, want gone once the budget is spent", d, err)
+	}
+}
+
+func TestHitBudgetInvalid(t *testing.T) {
+	if _, err := ParseYAML([]byte("- path: /a\n  url: https://a.example.com\n  maxHits: -1\n")); err == nil {
+		t.Error("accepted a negative maxHits")
+	}
+}
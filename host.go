@@ -0,0 +1,44 @@
+package urlshort
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WithHostDefaults gives the handler a default destination per host
+// for requests whose path is not matched, such as a tenant specific
+// "not found" page. Hosts are the keys of hostsToUrls, which is
+// copied; they are compared case-insensitively and without the port.
+//
+// A request is looked up in the following order:
+//
+//  1. its path, as configured by the other options;
+//  2. the default destination of its host;
+//  3. the fallback of the handler.
+func WithHostDefaults(hostsToUrls map[string]string) Option {
+	defaults := make(map[string]string, len(hostsToUrls))
+	for host, url := range hostsToUrls {
+		defaults[strings.ToLower(host)] = url
+	}
+	return func(c *config) {
+		c.hostDefaults = defaults
+	}
+}
+
+// hostDefault returns the default destination of the host r was
+// sent to.
+func (c *config) hostDefault(r *http.Request) (string, bool) {
+	url, ok := c.hostDefaults[requestHost(r)]
+	return url, ok
+}
+
+// requestHost returns the lower-cased host r was sent to, without
+// the port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,41 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithHTTPSUpgrade makes the handler upgrade http destinations to
+// https for requests that arrived over plain HTTP, so that a client
+// which reached the handler insecurely is sent on securely.
+// ForceHTTPS can be used instead to upgrade every destination.
+//
+// A request arrived over HTTPS if the connection used TLS. Behind a
+// TLS terminating proxy every request arrives over plain HTTP, so if
+// trustForwardedProto is true a request whose X-Forwarded-Proto
+// header is "https" counts as having arrived over HTTPS too. Only
+// set it if the handler is exclusively reachable through a proxy that
+// sets or overwrites the header, as clients can send it themselves.
+func WithHTTPSUpgrade(trustForwardedProto bool) Option {
+	return func(c *config) {
+		c.httpsUpgrade = true
+		c.trustForwardedProto = trustForwardedProto
+	}
+}
+
+// upgradeScheme returns dest with an https scheme if it is an http
+// destination and r arrived over plain HTTP.
+func (c *config) upgradeScheme(r *http.Request, dest string) string {
+	if !c.httpsUpgrade || c.arrivedOverHTTPS(r) {
+		return dest
+	}
+	return ForceHTTPS(dest)
+}
+
+// arrivedOverHTTPS reports whether r arrived over HTTPS.
+func (c *config) arrivedOverHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return c.trustForwardedProto && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
@@ -0,0 +1,49 @@
+package urlshort
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSUpgrade(t *testing.T) {
+	urls := map[string]string{"/a": "http://example.com/a", "/s": "https://example.com/s", "/r": "/relative"}
+	tests := []struct {
+		name      string
+		trust     bool
+		tls       bool
+		forwarded string
+		target    string
+		loc       string
+	}{
+		{"plain", false, false, "", "/a", "https://example.com/a"},
+		{"direct TLS", false, true, "", "/a", "http://example.com/a"},
+		{"forwarded trusted", true, false, "https", "/a", "http://example.com/a"},
+		{"forwarded trusted case", true, false, "HTTPS", "/a", "http://example.com/a"},
+		{"forwarded http", true, false, "http", "/a", "https://example.com/a"},
+		{"forwarded untrusted", false, false, "https", "/a", "https://example.com/a"},
+		{"already https", false, false, "", "/s", "https://example.com/s"},
+		{"relative", false, false, "", "/r", "/relative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, WithHTTPSUpgrade(tt.trust))
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			wantRedirect(t, w, http.StatusMovedPermanently, tt.loc)
+		})
+	}
+}
+
+func TestHTTPSUpgradeOff(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "http://example.com/a"}, notFound)
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "http://example.com/a")
+}
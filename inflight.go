@@ -0,0 +1,96 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithConcurrencyLimit caps the number of matched requests the
+// handler serves at the same time for each mapping key to limit,
+// protecting slow destinations, such as those checked by
+// WithPreflight or served by WithProxy, from bursts of requests. A
+// request over the limit waits up to wait for another one to finish,
+// and is answered with a plain text 503 Service Unavailable if none
+// does in time or if the request is canceled meanwhile. If wait is
+// not positive, requests over the limit are answered with a 503 at
+// once. Limiting is off if limit is not positive.
+//
+// A request counts against the limit from the moment it is matched
+// until its response is written, including the time spent on the
+// preflight check, proxying or any other work for the destination.
+func WithConcurrencyLimit(limit int, wait time.Duration) Option {
+	return func(c *config) {
+		if limit <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = &limiter{
+			limit: limit,
+			wait:  wait,
+			sems:  make(map[string]*semaphore),
+		}
+	}
+}
+
+// limiter limits the number of requests in flight per mapping key.
+type limiter struct {
+	limit int
+	wait  time.Duration
+
+	mu   sync.Mutex
+	sems map[string]*semaphore
+}
+
+// semaphore counts the requests in flight for a mapping key.
+type semaphore struct {
+	slots chan struct{}
+	// users is the number of requests holding or waiting for a slot,
+	// so that the semaphore can be dropped when it is zero.
+	users int
+}
+
+// acquire waits for a slot for a request for key, as configured by
+// l, and returns the function releasing it, or reports that none was
+// free in time.
+func (l *limiter) acquire(r *http.Request, key string) (release func(), ok bool) {
+	l.mu.Lock()
+	sem, found := l.sems[key]
+	if !found {
+		sem = &semaphore{slots: make(chan struct{}, l.limit)}
+		l.sems[key] = sem
+	}
+	sem.users++
+	l.mu.Unlock()
+
+	done := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		sem.users--
+		if sem.users == 0 {
+			delete(l.sems, key)
+		}
+	}
+
+	select {
+	case sem.slots <- struct{}{}:
+		return func() { <-sem.slots; done() }, true
+	default:
+	}
+	if l.wait <= 0 {
+		done()
+		return nil, false
+	}
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case sem.slots <- struct{}{}:
+		return func() { <-sem.slots; done() }, true
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+	done()
+	return nil, false
+}
@@ -0,0 +1,97 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// blockingWriter is an http.ResponseWriter whose WriteHeader reports
+// it was called on entered and then waits for release, holding the
+// slot of the request meanwhile.
+type blockingWriter struct {
+	http.ResponseWriter
+	entered chan<- struct{}
+	release <-chan struct{}
+}
+
+func (w blockingWriter) WriteHeader(code int) {
+	w.entered <- struct{}{}
+	<-w.release
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// limitedHandler returns a handler limited by WithConcurrencyLimit to
+// one request at a time for /a, whose first request blocks until
+// release is closed.
+func limitedHandler(wait time.Duration) (h http.Handler, entered <-chan struct{}, release chan<- struct{}) {
+	enteredc, releasec := make(chan struct{}), make(chan struct{})
+	first := true
+	h = MapHandler(map[string]string{"/a": "https://a.example.com", "/b": "https://b.example.com"}, notFound,
+		WithConcurrencyLimit(1, wait),
+		WithResponseWriter(func(w http.ResponseWriter) http.ResponseWriter {
+			if !first {
+				return w
+			}
+			first = false
+			return blockingWriter{ResponseWriter: w, entered: enteredc, release: releasec}
+		}))
+	return h, enteredc, releasec
+}
+
+func TestConcurrencyLimitRejects(t *testing.T) {
+	h, entered, release := limitedHandler(0)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(h, "/a") }()
+	<-entered
+
+	wantStatus(t, serve(h, "/a"), http.StatusServiceUnavailable)
+	wantRedirect(t, serve(h, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+
+	close(release)
+	wantRedirect(t, <-done, http.StatusMovedPermanently, "https://a.example.com")
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+}
+
+func TestConcurrencyLimitWaits(t *testing.T) {
+	h, entered, release := limitedHandler(time.Minute)
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- serve(h, "/a") }()
+	<-entered
+
+	second := make(chan *httptest.ResponseRecorder)
+	go func() { second <- serve(h, "/a") }()
+	select {
+	case w := <-second:
+		t.Fatalf("got %d while the first request holds the slot", w.Code)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	wantRedirect(t, <-first, http.StatusMovedPermanently, "https://a.example.com")
+	wantRedirect(t, <-second, http.StatusMovedPermanently, "https://a.example.com")
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	urls := map[string]string{"/a": "https://a.example.com"}
+	benchmarks := []struct {
+		name string
+		opts []Option
+	}{
+		{"no limit", nil},
+		{"limit", []Option{WithConcurrencyLimit(100, 0)}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			h := MapHandler(urls, notFound, bm.opts...)
+			r := httptest.NewRequest(http.MethodGet, "/a", nil)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					h.ServeHTTP(httptest.NewRecorder(), r)
+				}
+			})
+		})
+	}
+}
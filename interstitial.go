@@ -0,0 +1,71 @@
+package urlshort
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+)
+
+// WithInterstitial makes the handler answer matched requests with a
+// 200 and an HTML page linking to the destination instead of a
+// redirect, so that users see where a link leads before following
+// it. The destination only appears in the body of the page, never in
+// a header.
+//
+// The page is sent with a strict Content-Security-Policy header, so
+// that it renders under the same rules as sites enforcing one: its
+// only inline content, a style sheet, is allowed by a nonce drawn
+// from crypto/rand for every response.
+func WithInterstitial() Option {
+	return func(c *config) {
+		c.interstitial = true
+	}
+}
+
+// interstitialPage is the body of the responses written by
+// interstitialTo, executed with an interstitialData.
+var interstitialPage = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>You are leaving this site</title>
+<style nonce="{{.Nonce}}">
+body { font-family: sans-serif; margin: 3em auto; max-width: 40em; padding: 0 1em; }
+a { overflow-wrap: anywhere; }
+</style>
+</head>
+<body>
+<h1>You are leaving this site</h1>
+<p>This link leads to:</p>
+<p><a href="{{.URL}}" rel="noreferrer">{{.URL}}</a></p>
+</body>
+</html>
+`))
+
+// interstitialData is the data of interstitialPage: the destination
+// and the nonce allowing its inline content.
+type interstitialData struct {
+	URL   string
+	Nonce string
+}
+
+// interstitialTo writes an interstitial page linking to url.
+func interstitialTo(w http.ResponseWriter, url string) {
+	nonce := newNonce()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'nonce-"+nonce+"'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'")
+	w.WriteHeader(http.StatusOK)
+	interstitialPage.Execute(w, interstitialData{URL: url, Nonce: nonce})
+}
+
+// newNonce returns a random nonce for a Content-Security-Policy.
+func newNonce() string {
+	b := make([]byte, 16)
+	// Read from crypto/rand only fails on systems without a usable
+	// random source.
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
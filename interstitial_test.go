@@ -0,0 +1,66 @@
+package urlshort
+
+import (
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	headerNonce = regexp.MustCompile(`style-src 'nonce-([^']+)'`)
+	pageNonce   = regexp.MustCompile(`<style nonce="([^"]+)">`)
+)
+
+// interstitialNonces serves /a with h and returns the nonce of the
+// Content-Security-Policy header and that of the page in the body.
+func interstitialNonces(t *testing.T, h http.Handler) (header, page string) {
+	t.Helper()
+	w := serve(h, "/a")
+	wantStatus(t, w, http.StatusOK)
+	m := headerNonce.FindStringSubmatch(w.Header().Get("Content-Security-Policy"))
+	if m == nil {
+		t.Fatalf("no nonce in Content-Security-Policy %q", w.Header().Get("Content-Security-Policy"))
+	}
+	header = m[1]
+	m = pageNonce.FindStringSubmatch(w.Body.String())
+	if m == nil {
+		t.Fatalf("no nonce in page\n%s", w.Body)
+	}
+	// The nonce is base64, whose "+" is escaped in HTML attributes.
+	return header, html.UnescapeString(m[1])
+}
+
+func TestInterstitialNonce(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithInterstitial())
+	seen := make(map[string]bool)
+	for range 50 {
+		header, page := interstitialNonces(t, h)
+		if header != page {
+			t.Fatalf("header nonce %q does not match page nonce %q", header, page)
+		}
+		if seen[header] {
+			t.Fatalf("nonce %q was reused", header)
+		}
+		seen[header] = true
+	}
+}
+
+func TestInterstitial(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": `https://a.example.com/?q="x"&y=<1>`}, notFound, WithInterstitial())
+	w := serve(h, "/a")
+	wantStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Location"); got != "" {
+		t.Errorf("got Location %q, want none", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("got Content-Type %q", got)
+	}
+	if body := w.Body.String(); strings.Contains(body, `"x"&y=<1>`) {
+		t.Errorf("destination not escaped in page\n%s", body)
+	}
+	if !strings.Contains(w.Body.String(), `href="https://a.example.com/?q=%22x%22&amp;y=%3c1%3e"`) {
+		t.Errorf("page does not link to the destination\n%s", w.Body)
+	}
+}
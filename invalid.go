@@ -0,0 +1,66 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrInvalidDestination is wrapped by the errors reporting a
+// destination that does not make a valid Location, once composed from
+// the mapping, the request and the options of the handler.
+var ErrInvalidDestination = errors.New("invalid destination")
+
+// DestinationPolicy selects what the handler does with a destination
+// that does not make a valid Location. See WithDestinationPolicy.
+type DestinationPolicy int
+
+const (
+	// DestinationLenient sends invalid destinations as they are,
+	// leaving it to the client to make sense of them. It is the
+	// default.
+	DestinationLenient DestinationPolicy = iota
+	// DestinationStrict passes the requests for invalid destinations
+	// to the fallback.
+	DestinationStrict
+)
+
+// WithDestinationPolicy sets what the handler does with destinations
+// that are invalid once composed, such as a relative URL given a
+// query default or a prefix suffix that url.Parse rejects, or an
+// http or https URL left without a host. The check runs on the final
+// destination, after every option on destinations, and whatever the
+// policy the invalid destination is reported to the hook of
+// WithErrorHook with an error wrapping ErrInvalidDestination.
+func WithDestinationPolicy(policy DestinationPolicy) Option {
+	return func(c *config) {
+		c.destinationPolicy = policy
+	}
+}
+
+// WithErrorHook makes the handler call hook with the errors met while
+// resolving a request, such as those of a Store or a FuncHandler, and
+// the invalid destinations of WithDestinationPolicy, for logging or
+// metrics. The hook is called before the response is written, and
+// does not change it: see WithErrorHandler for that. It is not called
+// for the requests passed to Resolve, which reports the error itself.
+// A DynamicHandler bound to a file also reports its write errors to
+// the hook, with a nil request; see DynamicHandler.BindFile.
+func WithErrorHook(hook func(r *http.Request, err error)) Option {
+	return func(c *config) {
+		c.errorHook = hook
+	}
+}
+
+// checkDestination reports whether dest makes a valid Location.
+func checkDestination(dest string) error {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDestination, err)
+	}
+	if (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+		return fmt.Errorf("%w: %q has no host", ErrInvalidDestination, dest)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// invalidHandlers returns handlers whose destinations are valid on
+// their own, but not once composed with the request or the options:
+// "/a" is relative until a rewriter prefixes it with a scheme and no
+// host, and the host of "/host" comes from the query.
+func invalidHandlers(opts ...Option) map[string]http.Handler {
+	prefixScheme := func(dest string) string { return "https://" + dest }
+	return map[string]http.Handler{
+		"/a": MapHandler(map[string]string{"/a": "/a"}, notFound,
+			append([]Option{WithDestinationRewriter(prefixScheme)}, opts...)...),
+		"/host?h=exa+mple.com": FuncHandler(map[string]func(r *http.Request) (string, error){
+			"/host": func(r *http.Request) (string, error) { return "https://" + r.URL.Query().Get("h"), nil },
+		}, notFound, opts...),
+	}
+}
+
+func TestDestinationPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		policy DestinationPolicy
+		status int
+	}{
+		{"lenient", DestinationLenient, http.StatusMovedPermanently},
+		{"strict", DestinationStrict, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for target, h := range invalidHandlers(WithDestinationPolicy(tt.policy)) {
+				wantStatus(t, serve(h, target), tt.status)
+
+				_, err := Resolve(h, httptest.NewRequest(http.MethodGet, target, nil))
+				if !errors.Is(err, ErrInvalidDestination) {
+					t.Errorf("%s: Resolve got error %v, want ErrInvalidDestination", target, err)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorHook(t *testing.T) {
+	var errs []error
+	hook := WithErrorHook(func(r *http.Request, err error) { errs = append(errs, err) })
+	for target, h := range invalidHandlers(hook) {
+		errs = nil
+		serve(h, target)
+		if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidDestination) {
+			t.Errorf("%s: hook got %v, want one ErrInvalidDestination", target, errs)
+		}
+	}
+
+	errs = nil
+	h := MapHandler(map[string]string{"/ok": "https://ok.example.com"}, notFound, hook)
+	wantRedirect(t, serve(h, "/ok"), http.StatusMovedPermanently, "https://ok.example.com")
+	if len(errs) != 0 {
+		t.Errorf("hook got %v for a valid destination", errs)
+	}
+}
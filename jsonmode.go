@@ -0,0 +1,89 @@
+package urlshort
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WithJSONResponse makes the handler answer matched requests from API
+// consumers with a 200 and the destination as data, instead of a
+// redirect:
+//
+//	{"path": "/some-path", "url": "https://www.some-url.com/demo"}
+//
+// where path is the path of the request and url the destination it
+// would have been redirected to. The response is negotiated per
+// request: JSON is sent if the Accept header of the request names
+// application/json, and accepts it at least as much as text/html, or,
+// if queryParam is not empty, if the query of the request sets
+// queryParam to "json", as in "?format=json". Other requests,
+// including those from browsers, whose Accept header prefers HTML,
+// and from clients accepting anything with "*/*", such as curl, are
+// answered as without WithJSONResponse, and every response to a
+// matched request carries a "Vary: Accept" header for caches. Gone
+// paths are still answered with a 410.
+func WithJSONResponse(queryParam string) Option {
+	return func(c *config) {
+		c.jsonResponse = true
+		c.jsonQueryParam = queryParam
+	}
+}
+
+// jsonRequested reports whether r should be answered with JSON.
+func (c *config) jsonRequested(r *http.Request) bool {
+	if !c.jsonResponse {
+		return false
+	}
+	if c.jsonQueryParam != "" && r.URL.Query().Get(c.jsonQueryParam) == "json" {
+		return true
+	}
+	accept := r.Header.Values("Accept")
+	jsonQ, exact := acceptQuality(accept, "application/json")
+	htmlQ, _ := acceptQuality(accept, "text/html")
+	return exact && jsonQ > 0 && jsonQ >= htmlQ
+}
+
+// acceptQuality returns the quality with which the values of an
+// Accept header accept mediaType, with exact media ranges taking
+// precedence over "type/*" and "*/*" ones, and whether an exact range
+// was given.
+func acceptQuality(accept []string, mediaType string) (q float64, exact bool) {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	best, specificity := 0.0, -1
+	for _, value := range accept {
+		for _, item := range strings.Split(value, ",") {
+			rng, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err != nil {
+				continue
+			}
+			s := -1
+			switch rng {
+			case mediaType:
+				s = 2
+			case typ + "/*":
+				s = 1
+			case "*/*":
+				s = 0
+			}
+			if s <= specificity {
+				continue
+			}
+			quality := 1.0
+			if v, ok := params["q"]; ok {
+				if quality, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			best, specificity = quality, s
+		}
+	}
+	return best, specificity == 2
+}
+
+// jsonTo writes a JSON response giving url as the destination of
+// path.
+func jsonTo(w http.ResponseWriter, path, url string) {
+	writeJSON(w, http.StatusOK, MappingEntry{Path: path, URL: url})
+}
@@ -0,0 +1,73 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONResponse(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithJSONResponse("format"))
+	for _, tt := range []struct {
+		name   string
+		target string
+		accept string
+		json   bool
+	}{
+		{"json", "/a", "application/json", true},
+		{"json preferred", "/a", "text/html;q=0.5, application/json", true},
+		{"json as much as html", "/a", "text/html, application/json", true},
+		{"query", "/a?format=json", "", true},
+		{"query overrides accept", "/a?format=json", "text/html", true},
+		{"browser", "/a", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"html preferred", "/a", "application/json;q=0.5, text/html", false},
+		{"json refused", "/a", "application/json;q=0", false},
+		{"anything", "/a", "*/*", false},
+		{"application wildcard", "/a", "application/*", false},
+		{"no accept", "/a", "", false},
+		{"other format", "/a?format=xml", "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			h.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("got Vary %q, want %q", got, "Accept")
+			}
+			if !tt.json {
+				wantRedirect(t, w, http.StatusMovedPermanently, "https://a.example.com")
+				return
+			}
+			wantStatus(t, w, http.StatusOK)
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("got Content-Type %q, want application/json", got)
+			}
+			var got MappingEntry
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Path != "/a" || got.URL != "https://a.example.com" {
+				t.Errorf("got %+v, want /a to https://a.example.com", got)
+			}
+		})
+	}
+}
+
+func TestJSONResponseNoQueryParam(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithJSONResponse(""))
+	wantRedirect(t, serve(h, "/a?format=json"), http.StatusMovedPermanently, "https://a.example.com")
+}
+
+func TestJSONResponseGoneAndMisses(t *testing.T) {
+	h, err := YAMLHandler([]byte("- path: /old\n  gone: true\n"), notFound, WithJSONResponse("format"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus(t, serve(h, "/old?format=json"), http.StatusGone)
+	wantStatus(t, serve(h, "/missing?format=json"), http.StatusNotFound)
+}
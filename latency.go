@@ -0,0 +1,150 @@
+package urlshort
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the bucket upper bounds used by
+// NewLatencyRecorder when none are given. Redirects are served in
+// microseconds unless they involve a preflight check or a store, so
+// the buckets span both.
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram is a snapshot of the latencies recorded for a mapping
+// key. Counts[i] is the number of latencies no greater than
+// Buckets[i] and greater than Buckets[i-1], and the extra last count
+// is the number of latencies greater than every bucket.
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// LatencyRecorder is an http.Handler that records, per mapping key as
+// for HitCounter, a histogram of the time taken by the handler of
+// this package it wraps to answer the requests it redirects, from the
+// moment the request reaches the recorder until the response header
+// is written. It is safe for concurrent use.
+type LatencyRecorder struct {
+	next    http.Handler
+	buckets []time.Duration
+
+	mu         sync.Mutex
+	histograms map[string]*Histogram
+}
+
+// NewLatencyRecorder returns a LatencyRecorder serving requests with
+// next and recording latencies in buckets with the given upper
+// bounds, or in DefaultLatencyBuckets if there are none.
+func NewLatencyRecorder(next http.Handler, buckets ...time.Duration) *LatencyRecorder {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &LatencyRecorder{
+		next:       next,
+		buckets:    buckets,
+		histograms: make(map[string]*Histogram),
+	}
+}
+
+func (l *LatencyRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, rec := withRedirect(r)
+	tw := &writeTimer{ResponseWriter: w, start: time.Now()}
+	l.next.ServeHTTP(tw, r)
+	if !rec.matched {
+		return
+	}
+	if tw.elapsed == 0 {
+		tw.elapsed = time.Since(tw.start)
+	}
+
+	l.record(rec.key, tw.elapsed)
+}
+
+// record adds latency d to the histogram of key.
+func (l *LatencyRecorder) record(key string, d time.Duration) {
+	i := sort.Search(len(l.buckets), func(i int) bool {
+		return d <= l.buckets[i]
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.histograms[key]
+	if !ok {
+		h = &Histogram{
+			Buckets: l.buckets,
+			Counts:  make([]uint64, len(l.buckets)+1),
+		}
+		l.histograms[key] = h
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// Snapshot returns a copy of the histogram of every mapping key
+// redirected to so far.
+func (l *LatencyRecorder) Snapshot() map[string]Histogram {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snap := make(map[string]Histogram, len(l.histograms))
+	for key, h := range l.histograms {
+		snap[key] = Histogram{
+			Buckets: slices.Clone(h.Buckets),
+			Counts:  slices.Clone(h.Counts),
+			Count:   h.Count,
+			Sum:     h.Sum,
+		}
+	}
+	return snap
+}
+
+// writeTimer is an http.ResponseWriter that measures the time until
+// the response header is written.
+type writeTimer struct {
+	http.ResponseWriter
+	start   time.Time
+	elapsed time.Duration
+}
+
+func (w *writeTimer) WriteHeader(code int) {
+	if w.elapsed == 0 {
+		w.elapsed = time.Since(w.start)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writeTimer) Write(b []byte) (int, error) {
+	if w.elapsed == 0 {
+		w.elapsed = time.Since(w.start)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for
+// http.ResponseController.
+func (w *writeTimer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
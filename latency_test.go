@@ -0,0 +1,66 @@
+package urlshort
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLatencyRecorder(t *testing.T) {
+	l := NewLatencyRecorder(MapHandler(map[string]string{
+		"/a": "https://a.example.com",
+		"/b": "https://b.example.com",
+	}, notFound))
+	for _, target := range []string{"/a", "/a", "/b", "/missing"} {
+		serve(l, target)
+	}
+
+	snap := l.Snapshot()
+	if len(snap) != 2 || snap["/a"].Count != 2 || snap["/b"].Count != 1 {
+		t.Fatalf("got snapshot %+v, want 2 latencies for /a and 1 for /b", snap)
+	}
+	h := snap["/a"]
+	if !slices.Equal(h.Buckets, DefaultLatencyBuckets) || len(h.Counts) != len(DefaultLatencyBuckets)+1 {
+		t.Errorf("got buckets %v and %d counts, want the default buckets and one more count", h.Buckets, len(h.Counts))
+	}
+	var total uint64
+	for _, n := range h.Counts {
+		total += n
+	}
+	if total != h.Count || h.Sum <= 0 {
+		t.Errorf("got counts %v summing to %d and sum %v, want %d latencies", h.Counts, total, h.Sum, h.Count)
+	}
+
+	// The snapshot is a copy.
+	h.Counts[0] = 100
+	if l.Snapshot()["/a"].Counts[0] == 100 {
+		t.Error("changing a snapshot changed the recorder")
+	}
+}
+
+func TestLatencyRecorderBuckets(t *testing.T) {
+	buckets := []time.Duration{10 * time.Millisecond, time.Millisecond, 5 * time.Millisecond}
+	l := NewLatencyRecorder(notFound, buckets...)
+	if !slices.Equal(buckets, []time.Duration{10 * time.Millisecond, time.Millisecond, 5 * time.Millisecond}) {
+		t.Errorf("got buckets %v changed by NewLatencyRecorder", buckets)
+	}
+
+	for _, d := range []time.Duration{
+		0, time.Millisecond, // first bucket, bounds included
+		2 * time.Millisecond, 5 * time.Millisecond,
+		6 * time.Millisecond,
+		11 * time.Millisecond, time.Second, // past every bucket
+	} {
+		l.record("/a", d)
+	}
+	want := Histogram{
+		Buckets: []time.Duration{time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond},
+		Counts:  []uint64{2, 2, 1, 2},
+		Count:   7,
+		Sum:     1025 * time.Millisecond,
+	}
+	got := l.Snapshot()["/a"]
+	if !slices.Equal(got.Buckets, want.Buckets) || !slices.Equal(got.Counts, want.Counts) || got.Count != want.Count || got.Sum != want.Sum {
+		t.Errorf("got histogram %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package urlshort
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Layer loads the raw mapping data of one layer of a LayeredHandler,
+// along with its format, "yaml" or "json".
+type Layer func(ctx context.Context) (data []byte, format string, err error)
+
+// FileLayer returns a Layer reading the mapping file name, whose
+// format is detected from its extension as for DirHandler.
+func FileLayer(name string) Layer {
+	return func(ctx context.Context) ([]byte, string, error) {
+		format, ok := formatFromName(name)
+		if !ok {
+			return nil, "", fmt.Errorf("%s: unsupported mapping file extension", name)
+		}
+		data, err := os.ReadFile(name)
+		return data, format, err
+	}
+}
+
+// RemoteLayer returns a Layer fetching mapping data from url with a
+// GET request made with client (http.DefaultClient if nil). Any
+// status other than 200 is an error. The format is detected from the
+// extension of the path of url as for DirHandler, or else from the
+// Content-Type of the response, where media types containing "yaml"
+// are YAML and "application/json" is JSON.
+func RemoteLayer(client *http.Client, url string) Layer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) ([]byte, string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+		}
+		format, ok := remoteFormat(req.URL, resp.Header.Get("Content-Type"))
+		if !ok {
+			return nil, "", fmt.Errorf("%s: unknown mapping format", url)
+		}
+		data, err := io.ReadAll(resp.Body)
+		return data, format, err
+	}
+}
+
+// remoteFormat returns the mapping format of data fetched from u with
+// the given Content-Type.
+func remoteFormat(u *url.URL, contentType string) (string, bool) {
+	if format, ok := formatFromName(u.Path); ok {
+		return format, true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json":
+		return "json", true
+	case strings.Contains(mediaType, "yaml"):
+		return "yaml", true
+	}
+	return "", false
+}
+
+// LayeredHandler is an http.Handler that maps paths to URLs from two
+// layers of mapping data, such as a base configuration fetched from a
+// remote server and a local file overriding some of its entries for
+// testing. It is safe for concurrent use.
+//
+// Entries of the local layer win over those of the base layer for the
+// same path, as the later files of DirHandler do. The handler serving
+// requests is rebuilt from both layers whenever Reload finds that
+// either has changed, and is swapped in atomically.
+type LayeredHandler struct {
+	base, local Layer
+	fallback    http.Handler
+	cfg         *config
+
+	handler atomic.Pointer[handler]
+
+	mu        sync.Mutex
+	baseData  []byte
+	baseFmt   string
+	localData []byte
+	localFmt  string
+}
+
+// NewLayeredHandler returns a LayeredHandler loading its base and
+// local layers with base and local, which both must load without
+// error for the handler to be built. See MapHandler for the meaning
+// of fallback and opts.
+func NewLayeredHandler(ctx context.Context, base, local Layer, fallback http.Handler, opts ...Option) (*LayeredHandler, error) {
+	l := &LayeredHandler{
+		base:     base,
+		local:    local,
+		fallback: fallback,
+		cfg:      newConfig(opts),
+	}
+	if err := l.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LayeredHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.handler.Load().ServeHTTP(w, r)
+}
+
+// Resolve returns what l would do with r, as its current handler
+// would, without writing a response; see DynamicHandler.Resolve.
+func (l *LayeredHandler) Resolve(r *http.Request) (dest string, status int, matched bool) {
+	return l.handler.Load().Resolve(r)
+}
+
+// Reload loads both layers again and, if the data of either has
+// changed, rebuilds the handler from them.
+//
+// A layer that fails to load keeps its last loaded data, so when only
+// one layer fails the handler is still rebuilt if the other has
+// changed. The handler is left unchanged if the data of the layers
+// cannot be parsed or built into a handler, in which case the next
+// call tries again. In every case, the returned error joins the
+// errors met, each prefixed with the name of its layer.
+//
+// Concurrent calls, such as those of Run and of an explicit reload,
+// are serialized, loading included, so that the data loaded by a call
+// never replaces the data loaded by a later one. Requests are served
+// by the current handler meanwhile.
+func (l *LayeredHandler) Reload(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	baseData, baseFmt, baseErr := l.base(ctx)
+	localData, localFmt, localErr := l.local(ctx)
+
+	var errs []error
+	if baseErr != nil {
+		errs = append(errs, fmt.Errorf("base layer: %w", baseErr))
+		baseData, baseFmt = l.baseData, l.baseFmt
+	}
+	if localErr != nil {
+		errs = append(errs, fmt.Errorf("local layer: %w", localErr))
+		localData, localFmt = l.localData, l.localFmt
+	}
+	changed := l.handler.Load() == nil ||
+		baseFmt != l.baseFmt || !bytes.Equal(baseData, l.baseData) ||
+		localFmt != l.localFmt || !bytes.Equal(localData, l.localData)
+	if !changed || baseFmt == "" || localFmt == "" {
+		return errors.Join(errs...)
+	}
+
+	baseEntries, baseErr := parseMapping(baseFmt, baseData, l.cfg.decodeOptions...)
+	if baseErr != nil {
+		errs = append(errs, fmt.Errorf("base layer: %w", baseErr))
+	}
+	localEntries, localErr := parseMapping(localFmt, localData, l.cfg.decodeOptions...)
+	if localErr != nil {
+		errs = append(errs, fmt.Errorf("local layer: %w", localErr))
+	}
+	if baseErr != nil || localErr != nil {
+		return errors.Join(errs...)
+	}
+
+	h, err := newEntriesHandler(append(baseEntries, localEntries...), l.fallback, l.cfg)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	l.handler.Store(h)
+	l.baseData, l.baseFmt = baseData, baseFmt
+	l.localData, l.localFmt = localData, localFmt
+	return errors.Join(errs...)
+}
+
+// Run calls Reload every interval until ctx is done, passing the
+// errors it returns to onError if it is not nil. Run panics if
+// interval is not positive.
+func (l *LayeredHandler) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("urlshort: invalid reload interval %v", interval))
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Reload(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
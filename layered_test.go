@@ -0,0 +1,291 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// staticLayer returns a Layer loading the YAML data yml.
+func staticLayer(yml string) Layer {
+	return func(ctx context.Context) ([]byte, string, error) {
+		return []byte(yml), "yaml", nil
+	}
+}
+
+func TestLayeredHandler(t *testing.T) {
+	base := staticLayer("- path: /a\n  url: https://base.example.com/a\n- path: /b\n  url: https://base.example.com/b\n")
+	local := staticLayer("- path: /b\n  url: https://local.example.com/b\n")
+	l, err := NewLayeredHandler(context.Background(), base, local, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(l, "/a"), http.StatusMovedPermanently, "https://base.example.com/a")
+	wantRedirect(t, serve(l, "/b"), http.StatusMovedPermanently, "https://local.example.com/b")
+	wantStatus(t, serve(l, "/c"), http.StatusNotFound)
+}
+
+func TestLayeredHandlerKeepsLastGoodData(t *testing.T) {
+	var fail atomic.Bool
+	base := func(ctx context.Context) ([]byte, string, error) {
+		if fail.Load() {
+			return nil, "", errors.New("unreachable")
+		}
+		return []byte("- path: /a\n  url: https://a.example.com\n"), "yaml", nil
+	}
+	l, err := NewLayeredHandler(context.Background(), base, staticLayer("[]"), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fail.Store(true)
+	if err := l.Reload(context.Background()); err == nil {
+		t.Error("Reload of a failing layer returned no error")
+	}
+	wantRedirect(t, serve(l, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+}
+
+// TestLayeredHandlerConcurrentReload serves requests while the layers
+// change and are reloaded over and over, for the race detector to
+// check, and checks that every response comes from a whole version
+// of the mapping.
+func TestLayeredHandlerConcurrentReload(t *testing.T) {
+	var version atomic.Int64
+	layer := func(ctx context.Context) ([]byte, string, error) {
+		v := version.Load()
+		return []byte(fmt.Sprintf("- path: /a\n  url: https://example.com/%d\n", v)), "yaml", nil
+	}
+	l, err := NewLayeredHandler(context.Background(), layer, staticLayer("[]"), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var reloaders, readers sync.WaitGroup
+	for range 4 {
+		reloaders.Add(1)
+		go func() {
+			defer reloaders.Done()
+			for ctx.Err() == nil {
+				version.Add(1)
+				if err := l.Reload(ctx); err != nil && ctx.Err() == nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for range 8 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for range 200 {
+				w := serve(l, "/a")
+				var v int64
+				if _, err := fmt.Sscanf(w.Header().Get("Location"), "https://example.com/%d", &v); err != nil || w.Code != http.StatusMovedPermanently {
+					t.Errorf("got %d to %q", w.Code, w.Header().Get("Location"))
+					return
+				}
+			}
+		}()
+	}
+	readers.Wait()
+	cancel()
+	reloaders.Wait()
+
+	last := version.Add(1)
+	if err := l.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(l, "/a"), http.StatusMovedPermanently, fmt.Sprintf("https://example.com/%d", last))
+}
+
+// mutableLayer is a Layer loading YAML data, or failing with err,
+// that can be changed between reloads.
+type mutableLayer struct {
+	mu   sync.Mutex
+	data string
+	err  error
+}
+
+func (m *mutableLayer) set(data string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data, m.err = data, err
+}
+
+func (m *mutableLayer) load(ctx context.Context) ([]byte, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, "", m.err
+	}
+	return []byte(m.data), "yaml", nil
+}
+
+func TestLayeredHandlerReloadOneLayer(t *testing.T) {
+	base := &mutableLayer{data: "- path: /a\n  url: https://base.example.com/a\n"}
+	local := &mutableLayer{data: "- path: /b\n  url: https://local.example.com/b\n"}
+	l, err := NewLayeredHandler(context.Background(), base.load, local.load, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errUnreachable := errors.New("unreachable")
+
+	steps := []struct {
+		name        string
+		base, local func()
+		errs        []string
+		a, b        string
+	}{
+		{
+			name: "unchanged",
+			a:    "https://base.example.com/a", b: "https://local.example.com/b",
+		},
+		{
+			name: "base changed",
+			base: func() { base.set("- path: /a\n  url: https://base.example.com/a2\n", nil) },
+			a:    "https://base.example.com/a2", b: "https://local.example.com/b",
+		},
+		{
+			name:  "local changed",
+			local: func() { local.set("- path: /b\n  url: https://local.example.com/b2\n", nil) },
+			a:     "https://base.example.com/a2", b: "https://local.example.com/b2",
+		},
+		{
+			name:  "local overrides base",
+			local: func() { local.set("- path: /a\n  url: https://local.example.com/a\n", nil) },
+			a:     "https://local.example.com/a", b: "",
+		},
+		{
+			name:  "base fails while local changes",
+			base:  func() { base.set("", errUnreachable) },
+			local: func() { local.set("- path: /b\n  url: https://local.example.com/b3\n", nil) },
+			errs:  []string{"base layer: unreachable"},
+			a:     "https://base.example.com/a2", b: "https://local.example.com/b3",
+		},
+		{
+			name:  "local unparsable",
+			base:  func() { base.set("- path: /a\n  url: https://base.example.com/a4\n", nil) },
+			local: func() { local.set("- path: [", nil) },
+			errs:  []string{"local layer: "},
+			a:     "https://base.example.com/a2", b: "https://local.example.com/b3",
+		},
+		{
+			name:  "both fail",
+			base:  func() { base.set("", errUnreachable) },
+			local: func() { local.set("", errUnreachable) },
+			errs:  []string{"base layer: unreachable", "local layer: unreachable"},
+			a:     "https://base.example.com/a2", b: "https://local.example.com/b3",
+		},
+		{
+			name:  "both recover",
+			base:  func() { base.set("- path: /a\n  url: https://base.example.com/a5\n", nil) },
+			local: func() { local.set("[]", nil) },
+			a:     "https://base.example.com/a5", b: "",
+		},
+	}
+	for _, step := range steps {
+		for _, change := range []func(){step.base, step.local} {
+			if change != nil {
+				change()
+			}
+		}
+		err := l.Reload(context.Background())
+		switch {
+		case len(step.errs) == 0 && err != nil:
+			t.Fatalf("%s: got error %v, want none", step.name, err)
+		case len(step.errs) > 0 && err == nil:
+			t.Fatalf("%s: got no error, want %q", step.name, step.errs)
+		}
+		for _, want := range step.errs {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: got error %v, want one containing %q", step.name, err, want)
+			}
+		}
+		for path, want := range map[string]string{"/a": step.a, "/b": step.b} {
+			w := serve(l, path)
+			if want == "" {
+				wantStatus(t, w, http.StatusNotFound)
+				continue
+			}
+			wantRedirect(t, w, http.StatusMovedPermanently, want)
+		}
+	}
+}
+
+func TestLayeredHandlerReloadUnchanged(t *testing.T) {
+	l, err := NewLayeredHandler(context.Background(), staticLayer("- path: /a\n  url: https://a.example.com\n"), staticLayer("[]"), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := l.handler.Load()
+	if err := l.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if l.handler.Load() != h {
+		t.Error("handler was rebuilt from unchanged layers")
+	}
+}
+
+func TestLayeredHandlerRun(t *testing.T) {
+	base := &mutableLayer{data: "- path: /a\n  url: https://a.example.com/1\n"}
+	l, err := NewLayeredHandler(context.Background(), base.load, staticLayer("[]"), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Run(ctx, time.Millisecond, func(err error) { errs <- err })
+	}()
+
+	base.set("- path: /a\n  url: https://a.example.com/2\n", nil)
+	for deadline := time.Now().Add(5 * time.Second); serve(l, "/a").Header().Get("Location") != "https://a.example.com/2"; {
+		if time.Now().After(deadline) {
+			t.Fatal("Run did not reload the changed layer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	base.set("", errors.New("unreachable"))
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "base layer: unreachable") {
+			t.Errorf("onError got %v, want the error of the base layer", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("onError was not called")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return once ctx was done")
+	}
+}
+
+func TestLayeredHandlerRunInvalidInterval(t *testing.T) {
+	l, err := NewLayeredHandler(context.Background(), staticLayer("[]"), staticLayer("[]"), notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Run with interval %v did not panic", interval)
+				}
+			}()
+			l.Run(context.Background(), interval, nil)
+		}()
+	}
+}
@@ -0,0 +1,71 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ParseLegacyStatusMap converts a legacy mapping of paths to URLs
+// whose values may start with a status code, as in
+//
+//	"/old": "302 https://www.some-url.com/demo"
+//
+// to entries with the status in their Status field, for migrating to
+// the entry formats of YAMLHandler and JSONHandler. Values without a
+// status, a URL alone, give entries without a Status, redirected with
+// the status of the handler, 301 by default. A value made of the
+// status 410 alone gives a gone entry.
+//
+// Entries are returned sorted by path. Values whose leading field is
+// not a redirect status code accepted by WithStatus, or that have
+// more than a status and a URL, are an error, which joins one
+// *EntryError per such value.
+func ParseLegacyStatusMap(m map[string]string) ([]MappingEntry, error) {
+	var entries []MappingEntry
+	var errs []error
+	for _, entry := range sortedEntries(m) {
+		entry, err := parseLegacyValue(entry.Path, entry.URL)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseLegacyValue returns the entry of path given its legacy value.
+func parseLegacyValue(path, value string) (MappingEntry, error) {
+	fields := strings.Fields(value)
+	switch {
+	case len(fields) == 1 && !isDigits(fields[0]):
+		return MappingEntry{Path: path, URL: fields[0]}, nil
+	case len(fields) == 0 || len(fields) > 2:
+		return MappingEntry{}, &EntryError{Path: path, Problem: fmt.Sprintf("malformed value %q", value)}
+	}
+
+	code, err := strconv.Atoi(fields[0])
+	if err != nil || !isDigits(fields[0]) {
+		return MappingEntry{}, &EntryError{Path: path, Problem: fmt.Sprintf("malformed status %q", fields[0])}
+	}
+	switch {
+	case code == http.StatusGone && len(fields) == 1:
+		return MappingEntry{Path: path, Gone: true}, nil
+	case !isRedirectStatus(code):
+		return MappingEntry{}, &EntryError{Path: path, Problem: fmt.Sprintf("invalid redirect status %d", code)}
+	case len(fields) == 1:
+		return MappingEntry{}, &EntryError{Path: path, Problem: "missing url"}
+	}
+	return MappingEntry{Path: path, URL: fields[1], Status: code}, nil
+}
+
+// isDigits reports whether s is made of ASCII digits only.
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,79 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLegacyStatusMap(t *testing.T) {
+	entries, err := ParseLegacyStatusMap(map[string]string{
+		"/plain":  "https://example.com/plain",
+		"/moved":  "302 https://example.com/moved",
+		"/api":    "  308   https://api.example.com  ",
+		"/old":    "410",
+		"/digits": "https://example.com/301",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MappingEntry{
+		{Path: "/api", URL: "https://api.example.com", Status: http.StatusPermanentRedirect},
+		{Path: "/digits", URL: "https://example.com/301"},
+		{Path: "/moved", URL: "https://example.com/moved", Status: http.StatusFound},
+		{Path: "/old", Gone: true},
+		{Path: "/plain", URL: "https://example.com/plain"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("got %+v, want %+v", entries, want)
+	}
+
+	// The entries serve with their status once migrated.
+	yml, err := ExportYAML(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := YAMLHandler(yml, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/moved"), http.StatusFound, "https://example.com/moved")
+	wantRedirect(t, serve(h, "/plain"), http.StatusMovedPermanently, "https://example.com/plain")
+	wantStatus(t, serve(h, "/old"), http.StatusGone)
+}
+
+func TestParseLegacyStatusMapInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  string
+	}{
+		{"", "malformed value"},
+		{"301 https://a.example.com extra", "malformed value"},
+		{"3o1 https://a.example.com", "malformed status"},
+		{"+301 https://a.example.com", "malformed status"},
+		{"200 https://a.example.com", "invalid redirect status 200"},
+		{"410 https://a.example.com", "invalid redirect status 410"},
+		{"301", "missing url"},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseLegacyStatusMap(map[string]string{"/a": tt.value})
+			var entryErr *EntryError
+			if !errors.As(err, &entryErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want an *EntryError about %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLegacyStatusMapJoinsErrors(t *testing.T) {
+	_, err := ParseLegacyStatusMap(map[string]string{
+		"/a": "200 https://a.example.com",
+		"/b": "https://b.example.com",
+		"/c": "301",
+	})
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("got %d errors, want 2: %v", n, err)
+	}
+}
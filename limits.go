@@ -0,0 +1,63 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyEntries is returned when building a handler from more
+// entries than allowed by WithMaxEntries.
+var ErrTooManyEntries = errors.New("too many entries")
+
+// WithMaxEntries limits the number of entries the handlers built from
+// parsed mappings, such as YAMLHandler and DirHandler, will load to n,
+// guarding against runaway configuration. Building a handler from
+// more entries fails with an error wrapping ErrTooManyEntries. Every
+// entry counts, including those overridden by a later entry for the
+// same path. By default the number of entries is unlimited, and it is
+// also unlimited if n is not positive.
+func WithMaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// checkEntries checks that entries are within the configured limits.
+func (c *config) checkEntries(entries []MappingEntry) error {
+	if c.maxEntries > 0 && len(entries) > c.maxEntries {
+		return fmt.Errorf("%w: %d entries exceed the limit of %d", ErrTooManyEntries, len(entries), c.maxEntries)
+	}
+	return nil
+}
+
+// DefaultMaxDestinationLength is the destination length limit set by
+// WithMaxDestinationLength when it is given a non-positive limit. It
+// stays below the 8 KiB header size limit common to proxies and
+// servers, leaving room for the other headers of a redirect.
+const DefaultMaxDestinationLength = 4096
+
+// WithMaxDestinationLength limits the length in bytes of the
+// destinations the handler sends in the Location header to n, or to
+// DefaultMaxDestinationLength if n is not positive. Destinations
+// longer than that, such as those with long signed query strings, can
+// be silently truncated by intermediaries. Requests for them are
+// answered with the page of WithInterstitial, which carries the
+// destination in its body, if interstitial is true, and are passed to
+// the fallback otherwise.
+//
+// Without this option the length of destinations is not checked.
+func WithMaxDestinationLength(n int, interstitial bool) Option {
+	if n <= 0 {
+		n = DefaultMaxDestinationLength
+	}
+	return func(c *config) {
+		c.maxDestinationLength = n
+		c.longInterstitial = interstitial
+	}
+}
+
+// tooLong reports whether dest exceeds the configured destination
+// length limit.
+func (c *config) tooLong(dest string) bool {
+	return c.maxDestinationLength > 0 && len(dest) > c.maxDestinationLength
+}
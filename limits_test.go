@@ -0,0 +1,114 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// yamlEntries returns a YAML mapping of n entries.
+func yamlEntries(n int) []byte {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "- path: /%d\n  url: https://example.com/%d\n", i, i)
+	}
+	return []byte(b.String())
+}
+
+func TestMaxEntries(t *testing.T) {
+	const limit = 3
+	builds := []struct {
+		name  string
+		build func(n int, opts ...Option) error
+	}{
+		{"YAMLHandler", func(n int, opts ...Option) error {
+			_, err := YAMLHandler(yamlEntries(n), notFound, opts...)
+			return err
+		}},
+		{"JSONHandler", func(n int, opts ...Option) error {
+			entries, err := ParseYAML(yamlEntries(n))
+			if err != nil {
+				return err
+			}
+			jsn, err := ExportJSON(entries)
+			if err != nil {
+				return err
+			}
+			_, err = JSONHandler(jsn, notFound, opts...)
+			return err
+		}},
+		{"NewMapHandler", func(n int, opts ...Option) error {
+			m := make(map[string]string)
+			for i := range n {
+				m[fmt.Sprintf("/%d", i)] = "https://example.com"
+			}
+			_, err := NewMapHandler(m, notFound, opts...)
+			return err
+		}},
+		{"DirHandler", func(n int, opts ...Option) error {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "a.yaml"), yamlEntries(n), 0o644); err != nil {
+				return err
+			}
+			_, err := DirHandler(dir, notFound, opts...)
+			return err
+		}},
+	}
+	for _, b := range builds {
+		t.Run(b.name, func(t *testing.T) {
+			if err := b.build(limit, WithMaxEntries(limit)); err != nil {
+				t.Errorf("%d entries with a limit of %d: %v", limit, limit, err)
+			}
+			if err := b.build(limit+1, WithMaxEntries(limit)); !errors.Is(err, ErrTooManyEntries) {
+				t.Errorf("%d entries with a limit of %d: got %v, want ErrTooManyEntries", limit+1, limit, err)
+			}
+			if err := b.build(limit+1, WithMaxEntries(0)); err != nil {
+				t.Errorf("%d entries without a limit: %v", limit+1, err)
+			}
+			if err := b.build(limit + 1); err != nil {
+				t.Errorf("%d entries by default: %v", limit+1, err)
+			}
+		})
+	}
+}
+
+func TestMaxEntriesCountsOverridden(t *testing.T) {
+	yml := "- path: /a\n  url: https://a.example.com\n- path: /a\n  url: https://b.example.com\n"
+	if _, err := YAMLHandler([]byte(yml), notFound, WithMaxEntries(1)); !errors.Is(err, ErrTooManyEntries) {
+		t.Errorf("got %v, want ErrTooManyEntries", err)
+	}
+}
+
+func TestMaxDestinationLength(t *testing.T) {
+	long := "https://example.com/" + strings.Repeat("a", DefaultMaxDestinationLength-len("https://example.com/"))
+	urls := map[string]string{
+		"/short": "https://example.com/ok",
+		"/edge":  long,
+		"/long":  long + "a",
+	}
+
+	h := MapHandler(urls, notFound, WithMaxDestinationLength(0, false))
+	wantRedirect(t, serve(h, "/short"), http.StatusMovedPermanently, "https://example.com/ok")
+	wantRedirect(t, serve(h, "/edge"), http.StatusMovedPermanently, long)
+	wantStatus(t, serve(h, "/long"), http.StatusNotFound)
+
+	// The limit applies to the destination once composed.
+	h = MapHandler(urls, notFound, WithMaxDestinationLength(len("https://example.com/ok?a=1"), false), WithQueryForwarding())
+	wantRedirect(t, serve(h, "/short?a=1"), http.StatusMovedPermanently, "https://example.com/ok?a=1")
+	wantStatus(t, serve(h, "/short?a=10"), http.StatusNotFound)
+
+	h = MapHandler(urls, notFound, WithMaxDestinationLength(0, true))
+	wantRedirect(t, serve(h, "/edge"), http.StatusMovedPermanently, long)
+	w := serve(h, "/long")
+	wantStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Location"); got != "" {
+		t.Errorf("got Location of %d bytes, want none", len(got))
+	}
+	if !strings.Contains(w.Body.String(), long+"a") {
+		t.Error("got an interstitial page without the destination")
+	}
+}
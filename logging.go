@@ -0,0 +1,119 @@
+package urlshort
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// redirectKey is the context key under which middleware stores the
+// *redirect record handlers fill in.
+type redirectKey struct{}
+
+// redirect records the decision a handler made about a request, for
+// the middleware wrapping the handler to read once it has returned.
+type redirect struct {
+	matched     bool
+	key         string
+	destination string
+}
+
+// withRedirect returns r with a redirect record in its context,
+// along with the record. If the context of r already holds one, it
+// is shared rather than replaced so that stacked middleware all see
+// the decision.
+func withRedirect(r *http.Request) (*http.Request, *redirect) {
+	if rec, ok := r.Context().Value(redirectKey{}).(*redirect); ok {
+		return r, rec
+	}
+	rec := &redirect{}
+	return r.WithContext(context.WithValue(r.Context(), redirectKey{}, rec)), rec
+}
+
+// recordRedirect records that r was matched by the mapping key and
+// redirected to dest, if the context of r holds a redirect record.
+// The key is empty for requests that matched no key, such as those
+// redirected to the default destination of their host, and dest is
+// empty for requests answered with a 410 because the key is gone.
+func recordRedirect(r *http.Request, key, dest string) {
+	if rec, ok := r.Context().Value(redirectKey{}).(*redirect); ok {
+		rec.matched = true
+		rec.key = key
+		rec.destination = dest
+	}
+}
+
+// statusRecorder is an http.ResponseWriter that remembers the status
+// code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for
+// http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LoggingHandler returns an http.Handler that serves requests with
+// next and then logs one record per request to logger
+// (slog.Default() if nil). Besides the record time, it has the
+// attributes:
+//
+//   - method: the request method
+//   - path: the request path, before any prefix was removed by MountAt
+//   - matched: whether a handler of this package redirected the request
+//   - destination: the URL it was redirected to, if matched
+//   - status: the status code of the response
+//   - client_ip: the host part of the request RemoteAddr
+//   - latency: the time taken by next
+//
+// For JSON access logs, give it a logger using slog.JSONHandler. The
+// redirect decision is read from the request context, so it is only
+// reported for handlers of this package, which may be wrapped in any
+// other middleware in between.
+func LoggingHandler(next http.Handler, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, rec := withRedirect(r)
+		sw := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		latency := time.Since(start)
+
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		path, ok := OriginalPath(r)
+		if !ok {
+			path = r.URL.Path
+		}
+		logger.LogAttrs(r.Context(), slog.LevelInfo, "redirect",
+			slog.String("method", r.Method),
+			slog.String("path", path),
+			slog.Bool("matched", rec.matched),
+			slog.String("destination", rec.destination),
+			slog.Int("status", sw.status),
+			slog.String("client_ip", clientIP(r)),
+			slog.Duration("latency", latency),
+		)
+	})
+}
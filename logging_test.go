@@ -0,0 +1,118 @@
+package urlshort
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// logRecord is the JSON form of a record of LoggingHandler.
+type logRecord struct {
+	Msg         string `json:"msg"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Matched     bool   `json:"matched"`
+	Destination string `json:"destination"`
+	Status      int    `json:"status"`
+	ClientIP    string `json:"client_ip"`
+	Latency     *int64 `json:"latency"`
+}
+
+func TestLoggingHandler(t *testing.T) {
+	urls := map[string]string{"/a": "https://a.example.com"}
+	tests := []struct {
+		name   string
+		wrap   func(logged func(http.Handler) http.Handler) http.Handler
+		method string
+		target string
+		want   logRecord
+	}{
+		{
+			name: "matched",
+			wrap: func(logged func(http.Handler) http.Handler) http.Handler {
+				return logged(MapHandler(urls, notFound))
+			},
+			method: http.MethodGet, target: "/a",
+			want: logRecord{Method: "GET", Path: "/a", Matched: true, Destination: "https://a.example.com", Status: http.StatusMovedPermanently},
+		},
+		{
+			name: "not matched",
+			wrap: func(logged func(http.Handler) http.Handler) http.Handler {
+				return logged(MapHandler(urls, notFound))
+			},
+			method: http.MethodHead, target: "/missing",
+			want: logRecord{Method: "HEAD", Path: "/missing", Status: http.StatusNotFound},
+		},
+		{
+			name: "other middleware in between",
+			wrap: func(logged func(http.Handler) http.Handler) http.Handler {
+				h := MapHandler(urls, notFound)
+				return logged(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					type key struct{}
+					r = r.WithContext(context.WithValue(r.Context(), key{}, "wrapped"))
+					h.ServeHTTP(w, r)
+				}))
+			},
+			method: http.MethodGet, target: "/a",
+			want: logRecord{Method: "GET", Path: "/a", Matched: true, Destination: "https://a.example.com", Status: http.StatusMovedPermanently},
+		},
+		{
+			name: "mounted",
+			wrap: func(logged func(http.Handler) http.Handler) http.Handler {
+				return MountAt("/s", logged(MapHandler(urls, notFound)))
+			},
+			method: http.MethodGet, target: "/s/a",
+			want: logRecord{Method: "GET", Path: "/s/a", Matched: true, Destination: "https://a.example.com", Status: http.StatusMovedPermanently},
+		},
+		{
+			name: "implicit status",
+			wrap: func(logged func(http.Handler) http.Handler) http.Handler {
+				return logged(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			},
+			method: http.MethodGet, target: "/a",
+			want: logRecord{Method: "GET", Path: "/a", Status: http.StatusOK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+			h := tt.wrap(func(next http.Handler) http.Handler {
+				return LoggingHandler(next, logger)
+			})
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			var got logRecord
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("got log %q: %v", buf.String(), err)
+			}
+			if got.Latency == nil || *got.Latency < 0 {
+				t.Errorf("got latency %v, want a duration", got.Latency)
+			}
+			got.Latency = nil
+			want := tt.want
+			want.Msg, want.ClientIP = "redirect", "192.0.2.1"
+			if got != want {
+				t.Errorf("got record %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoggingHandlerDefaultLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	serve(LoggingHandler(MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound), nil), "/a")
+	var got logRecord
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || !got.Matched {
+		t.Errorf("got log %q, want a matched record", buf.String())
+	}
+}
@@ -0,0 +1,45 @@
+package urlshort
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maintenance is the state of a handler in maintenance mode.
+type maintenance struct {
+	retryAfter time.Duration
+}
+
+// WithMaintenanceMisses makes a DynamicHandler in maintenance mode
+// (see DynamicHandler.SetMaintenance) answer the requests it does not
+// match with a 503 too, instead of passing them to the fallback.
+func WithMaintenanceMisses() Option {
+	return func(c *config) {
+		c.maintenanceMisses = true
+	}
+}
+
+// SetMaintenance turns maintenance mode on or off. While it is on,
+// matched requests are answered with a plain text 503 Service
+// Unavailable instead of being redirected, with a Retry-After header
+// of retryAfter rounded up to the second if it is positive. Requests
+// that are not matched are still passed to the fallback, unless the
+// handler was built with WithMaintenanceMisses. The mapping is left
+// untouched, and redirects resume as soon as the mode is turned off.
+func (d *DynamicHandler) SetMaintenance(on bool, retryAfter time.Duration) {
+	if !on {
+		d.handler.maintenance.Store(nil)
+		return
+	}
+	d.handler.maintenance.Store(&maintenance{retryAfter: retryAfter})
+}
+
+// unavailable answers a request with a 503, as configured by m.
+func (m *maintenance) unavailable(w http.ResponseWriter) {
+	if m.retryAfter > 0 {
+		secs := (m.retryAfter + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(secs), 10))
+	}
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,48 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSetMaintenance(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+
+	d.SetMaintenance(true, 1500*time.Millisecond)
+	w := serve(d, "/a")
+	wantStatus(t, w, http.StatusServiceUnavailable)
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("got Retry-After %q, want %q", got, "2")
+	}
+	if got := w.Header().Get("Location"); got != "" {
+		t.Errorf("got Location %q during maintenance", got)
+	}
+	wantStatus(t, serve(d, "/missing"), http.StatusNotFound)
+
+	d.SetMaintenance(true, 0)
+	w = serve(d, "/a")
+	wantStatus(t, w, http.StatusServiceUnavailable)
+	if got := w.Header().Get("Retry-After"); got != "" {
+		t.Errorf("got Retry-After %q, want none", got)
+	}
+
+	d.SetMaintenance(false, 0)
+	wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+}
+
+func TestMaintenanceMisses(t *testing.T) {
+	d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound, WithMaintenanceMisses())
+	wantStatus(t, serve(d, "/missing"), http.StatusNotFound)
+
+	d.SetMaintenance(true, time.Minute)
+	wantStatus(t, serve(d, "/a"), http.StatusServiceUnavailable)
+	w := serve(d, "/missing")
+	wantStatus(t, w, http.StatusServiceUnavailable)
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("got Retry-After %q, want %q", got, "60")
+	}
+
+	d.SetMaintenance(false, 0)
+	wantStatus(t, serve(d, "/missing"), http.StatusNotFound)
+}
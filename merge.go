@@ -0,0 +1,55 @@
+package urlshort
+
+// Source is a named set of entries to be merged, such as the entries
+// parsed from one file.
+type Source struct {
+	Name    string
+	Entries []MappingEntry
+}
+
+// Conflict describes a path mapped to different URLs while merging.
+// ValueA was mapped first, by SourceA, and was overridden by ValueB,
+// mapped by SourceB. Both sources are the same when a source maps the
+// same path twice.
+type Conflict struct {
+	Path    string
+	ValueA  string
+	SourceA string
+	ValueB  string
+	SourceB string
+}
+
+// Merge merges the entries of sources into a single mapping of paths
+// to URLs, suitable for MapHandler. Sources are merged in order, so
+// when several map the same path the last one wins, as it does for
+// the files of DirHandler.
+//
+// Every override of a path with a different URL is reported as a
+// Conflict, in the order they occurred, so that callers can fail on
+// unexpected ones instead of relying on the precedence silently.
+// Mapping a path to the URL it already has is not a conflict.
+func Merge(sources ...Source) (map[string]string, []Conflict) {
+	m := make(map[string]string)
+	from := make(map[string]string)
+	var conflicts []Conflict
+	for _, src := range sources {
+		for _, entry := range expandPaths(src.Entries) {
+			old, ok := m[entry.Path]
+			if ok && old == entry.URL {
+				continue
+			}
+			if ok {
+				conflicts = append(conflicts, Conflict{
+					Path:    entry.Path,
+					ValueA:  old,
+					SourceA: from[entry.Path],
+					ValueB:  entry.URL,
+					SourceB: src.Name,
+				})
+			}
+			m[entry.Path] = entry.URL
+			from[entry.Path] = src.Name
+		}
+	}
+	return m, conflicts
+}
@@ -0,0 +1,61 @@
+package urlshort
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestMergeNoConflict(t *testing.T) {
+	m, conflicts := Merge(
+		Source{Name: "a.yaml", Entries: []MappingEntry{{Path: "/a", URL: "https://a.example.com"}}},
+		Source{Name: "b.json", Entries: []MappingEntry{
+			{Path: "/b", URL: "https://b.example.com"},
+			{Path: "/a", URL: "https://a.example.com"},
+		}},
+	)
+	want := map[string]string{"/a": "https://a.example.com", "/b": "https://b.example.com"}
+	if !maps.Equal(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("got conflicts %+v, want none", conflicts)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	m, conflicts := Merge(
+		Source{Name: "base.yaml", Entries: []MappingEntry{
+			{Path: "/a", URL: "https://a1.example.com"},
+			{Path: "/b", URL: "https://b1.example.com"},
+		}},
+		Source{Name: "team.json", Entries: []MappingEntry{
+			{Path: "/a", URL: "https://a2.example.com"},
+			{Paths: []string{"/b", "/c"}, URL: "https://b2.example.com"},
+		}},
+		Source{Name: "local.yaml", Entries: []MappingEntry{
+			{Path: "/a", URL: "https://a3.example.com"},
+			{Path: "/a", URL: "https://a4.example.com"},
+		}},
+	)
+	want := map[string]string{"/a": "https://a4.example.com", "/b": "https://b2.example.com", "/c": "https://b2.example.com"}
+	if !maps.Equal(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+	wantConflicts := []Conflict{
+		{Path: "/a", ValueA: "https://a1.example.com", SourceA: "base.yaml", ValueB: "https://a2.example.com", SourceB: "team.json"},
+		{Path: "/b", ValueA: "https://b1.example.com", SourceA: "base.yaml", ValueB: "https://b2.example.com", SourceB: "team.json"},
+		{Path: "/a", ValueA: "https://a2.example.com", SourceA: "team.json", ValueB: "https://a3.example.com", SourceB: "local.yaml"},
+		{Path: "/a", ValueA: "https://a3.example.com", SourceA: "local.yaml", ValueB: "https://a4.example.com", SourceB: "local.yaml"},
+	}
+	if !slices.Equal(conflicts, wantConflicts) {
+		t.Errorf("got conflicts\n%+v\nwant\n%+v", conflicts, wantConflicts)
+	}
+}
+
+func TestMergeEmpty(t *testing.T) {
+	m, conflicts := Merge()
+	if len(m) != 0 || conflicts != nil {
+		t.Errorf("got %v, %v, want an empty mapping", m, conflicts)
+	}
+}
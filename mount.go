@@ -0,0 +1,46 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+)
+
+// originalPathKey is the context key under which MountAt stores the
+// path of requests before the prefix is stripped.
+type originalPathKey struct{}
+
+// MountAt returns a handler that serves requests whose path begins
+// with prefix by removing prefix from their path, as
+// http.StripPrefix does, and passing them to h. Unlike with
+// http.StripPrefix, the path the request was sent with remains
+// available to h, and to the handlers it calls, through OriginalPath.
+// Requests whose path does not begin with prefix are answered with a
+// 404.
+//
+// The handlers of this package only ever match the r.URL.Path they
+// are given, so when mounted with either MountAt or http.StripPrefix
+// their mapping keys are written without the prefix: with the prefix
+// "/go", the key "/docs" matches requests for "/go/docs".
+// Destinations, on the other hand, are sent to the client as they
+// are and resolved by it against the URL it requested, which still
+// has the prefix. A destination of "/docs" thus leads to "/docs" on
+// the same host, not to "/go/docs", and a relative destination such
+// as "intro" is resolved against the path with the prefix.
+func MountAt(prefix string, h http.Handler) http.Handler {
+	strip := http.StripPrefix(prefix, h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := OriginalPath(r); !ok {
+			r = r.WithContext(context.WithValue(r.Context(), originalPathKey{}, r.URL.Path))
+		}
+		strip.ServeHTTP(w, r)
+	})
+}
+
+// OriginalPath returns the path r was sent with, before any prefix
+// was removed by MountAt. If handlers returned by MountAt are nested,
+// it is the path seen by the outermost one. It reports false if r
+// was not passed through MountAt.
+func OriginalPath(r *http.Request) (string, bool) {
+	path, ok := r.Context().Value(originalPathKey{}).(string)
+	return path, ok
+}
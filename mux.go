@@ -0,0 +1,41 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RegisterMux registers every path in pathsToUrls on mux as an exact
+// match route redirecting to its URL, so the redirects can live on an
+// existing mux next to other routes.
+//
+// Unlike MapHandler, which is a single catch-all handler that falls
+// back for the paths it does not know, each path becomes its own
+// route, and requests for other paths are dispatched by mux as
+// usual. Paths are registered with the Go 1.22 pattern syntax for
+// any method and host: a path ending in a slash, such as "/" or
+// "/blog/", is registered with a trailing "{$}" so that it does not
+// match the paths below it, although mux still redirects "/blog" to
+// it as it does for any such pattern. Paths containing braces are
+// interpreted as wildcards by mux, and RegisterMux panics, like
+// http.ServeMux.Handle, if a path is not a valid pattern or conflicts
+// with one already registered.
+//
+// The routes share a handler built from pathsToUrls and opts; see
+// MapHandler for their meaning. Requests the handler declines, such
+// as those failing a preflight check, are answered with a 404.
+func RegisterMux(mux *http.ServeMux, pathsToUrls map[string]string, opts ...Option) {
+	h := MapHandler(pathsToUrls, http.NotFoundHandler(), opts...)
+	for path := range pathsToUrls {
+		mux.Handle(exactPattern(path), h)
+	}
+}
+
+// exactPattern returns the http.ServeMux pattern that matches path
+// and nothing else.
+func exactPattern(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path + "{$}"
+	}
+	return path
+}
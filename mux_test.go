@@ -0,0 +1,51 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegisterMux(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	RegisterMux(mux, map[string]string{
+		"/a":     "https://a.example.com",
+		"/blog/": "https://blog.example.com",
+		"/":      "https://example.com",
+	}, WithStatus(http.StatusFound))
+
+	wantRedirect(t, serve(mux, "/a"), http.StatusFound, "https://a.example.com")
+	wantRedirect(t, serve(mux, "/blog/"), http.StatusFound, "https://blog.example.com")
+	wantRedirect(t, serve(mux, "/"), http.StatusFound, "https://example.com")
+	wantStatus(t, serve(mux, "/health"), http.StatusOK)
+
+	// Trailing slash paths do not match the paths below them, but mux
+	// redirects to them from the path without the slash.
+	wantStatus(t, serve(mux, "/blog/post"), http.StatusNotFound)
+	if w := serve(mux, "/blog"); w.Code/100 != 3 || w.Header().Get("Location") != "/blog/" {
+		t.Errorf("got %d to %q for /blog, want a redirect to /blog/", w.Code, w.Header().Get("Location"))
+	}
+	wantStatus(t, serve(mux, "/a/b"), http.StatusNotFound)
+	wantStatus(t, serve(mux, "/other"), http.StatusNotFound)
+}
+
+func TestRegisterMuxDeclined(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+	mux := http.NewServeMux()
+	RegisterMux(mux, map[string]string{"/a": down.URL}, WithPreflight(down.Client(), time.Minute))
+	wantStatus(t, serve(mux, "/a"), http.StatusNotFound)
+}
+
+func TestRegisterMuxConflict(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {})
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for a path registered twice")
+		}
+	}()
+	RegisterMux(mux, map[string]string{"/a": "https://a.example.com"})
+}
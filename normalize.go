@@ -0,0 +1,46 @@
+package urlshort
+
+import "strings"
+
+// WithNormalizer makes the handler look up request paths normalized
+// by normalize, such as LowercaseAll or LowercaseFirstSegment, instead
+// of the paths as they were sent. Several normalizers run in the
+// order they were given.
+//
+// Only request paths are normalized: mapping keys are matched as
+// they are written, so they should be written in normalized form.
+func WithNormalizer(normalize func(path string) string) Option {
+	return func(c *config) {
+		c.normalizers = append(c.normalizers, normalize)
+	}
+}
+
+// normalize returns path normalized by the configured normalizers.
+func (c *config) normalize(path string) string {
+	for _, normalize := range c.normalizers {
+		path = normalize(path)
+	}
+	if c.caseInsensitive {
+		path = LowercaseAll(path)
+	}
+	return path
+}
+
+// LowercaseAll is a normalizer for WithNormalizer that lower-cases
+// the whole path, making matching case-insensitive.
+func LowercaseAll(path string) string {
+	return strings.ToLower(path)
+}
+
+// LowercaseFirstSegment is a normalizer for WithNormalizer that
+// lower-cases only the first segment of the path, so that
+// "/Docs/Intro" is matched as "/docs/Intro".
+func LowercaseFirstSegment(path string) string {
+	rest := strings.TrimPrefix(path, "/")
+	end := strings.IndexByte(rest, '/')
+	if end < 0 {
+		end = len(rest)
+	}
+	n := len(path) - len(rest) + end
+	return strings.ToLower(path[:n]) + path[n:]
+}
@@ -0,0 +1,74 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	for _, tt := range []struct {
+		normalize func(string) string
+		path      string
+		want      string
+	}{
+		{LowercaseAll, "/Docs/Intro", "/docs/intro"},
+		{LowercaseFirstSegment, "/Docs/Intro", "/docs/Intro"},
+		{LowercaseFirstSegment, "/Docs", "/docs"},
+		{LowercaseFirstSegment, "/", "/"},
+		{LowercaseFirstSegment, "", ""},
+	} {
+		if got := tt.normalize(tt.path); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWithNormalizer(t *testing.T) {
+	paths := map[string]string{
+		"/docs/Intro": "https://docs.example.com/intro",
+		"/blog":       "https://blog.example.com",
+	}
+	trimSlash := func(path string) string {
+		if len(path) > 1 {
+			return strings.TrimSuffix(path, "/")
+		}
+		return path
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		path string
+		want int
+	}{
+		{"none", nil, "/Docs/Intro", http.StatusNotFound},
+		{"first segment", []Option{WithNormalizer(LowercaseFirstSegment)}, "/DOCS/Intro", http.StatusMovedPermanently},
+		{"first segment only", []Option{WithNormalizer(LowercaseFirstSegment)}, "/docs/intro", http.StatusNotFound},
+		{"custom", []Option{WithNormalizer(trimSlash)}, "/blog/", http.StatusMovedPermanently},
+		{"custom unchanged", []Option{WithNormalizer(trimSlash)}, "/blog", http.StatusMovedPermanently},
+		{"chained", []Option{WithNormalizer(trimSlash), WithNormalizer(LowercaseAll)}, "/BLOG/", http.StatusMovedPermanently},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			wantStatus(t, serve(MapHandler(paths, notFound, tt.opts...), tt.path), tt.want)
+		})
+	}
+}
+
+// TestWithNormalizerOrder checks that normalizers run in the order
+// they were given.
+func TestWithNormalizerOrder(t *testing.T) {
+	var order []string
+	record := func(name string) func(string) string {
+		return func(path string) string {
+			order = append(order, name)
+			return path
+		}
+	}
+	h := MapHandler(map[string]string{}, notFound,
+		WithNormalizer(record("first")), WithNormalizer(record("second")))
+	serve(h, "/a")
+	if len(order) < 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("normalizers ran in order %v, want [first second]", order)
+	}
+}
@@ -0,0 +1,136 @@
+package urlshort
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Option customises the behaviour of the handlers built by this
+// package. Every handler constructor accepts a list of options;
+// without any, a handler simply redirects matched paths.
+type Option func(*config)
+
+// config holds the behaviour selected by a list of Options.
+type config struct {
+	preflight       *preflight
+	queryForwarding *queryForwarding
+	queryDefaults   []queryDefaults
+	rootRedirect    string
+	status          int
+
+	prefixMatch     bool
+	stripOriginPath bool
+	collapseSlashes bool
+	directoryKeys   DirectoryKeyMode
+
+	normalizers []func(path string) string
+	pathFunc    func(r *http.Request) string
+
+	rewriters []DestinationRewriter
+
+	allowedSchemes map[string]bool
+	forbidSchemes  bool
+
+	denyPatterns []*regexp.Regexp
+	denyStatus   int
+
+	punycode       bool
+	strictPunycode bool
+
+	hostDefaults map[string]string
+
+	httpsUpgrade        bool
+	trustForwardedProto bool
+
+	proxy          bool
+	proxyTransport http.RoundTripper
+
+	refresh      bool
+	refreshDelay time.Duration
+
+	interstitial bool
+
+	canonicalLink bool
+
+	jsonResponse   bool
+	jsonQueryParam string
+
+	maxDestinationLength int
+	longInterstitial     bool
+
+	maxEntries    int
+	decodeOptions []DecodeOption
+
+	sources         []source
+	caseInsensitive bool
+	canonicalCase   bool
+
+	conditions map[string][]func(r *http.Request) bool
+
+	decorators []func(w http.ResponseWriter) http.ResponseWriter
+
+	errorHandler ErrorHandler
+	errorHook    func(r *http.Request, err error)
+
+	destinationPolicy DestinationPolicy
+
+	schemeRelative       bool
+	schemeRelativeScheme string
+
+	serverTiming bool
+
+	maintenanceMisses bool
+
+	limiter *limiter
+
+	cors *cors
+
+	resolver *resolver
+
+	coalesceLookups bool
+
+	clock    func() time.Time
+	location *time.Location
+
+	roundRobin *roundRobin
+
+	hits *hitCounter
+
+	preservedBody map[string]bool
+
+	preloadHints map[string][]Preload
+
+	events *EventStream
+}
+
+// newConfig applies opts, in order, over the default config.
+func newConfig(opts []Option) *config {
+	cfg := &config{hits: &hitCounter{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// destination returns the URL r is redirected to, given the
+// destination dest its path is mapped to, which is the variant or
+// scheduled URL selected for r, if any. The slashes of its path are
+// collapsed first, then the query of r is forwarded, the query
+// defaults are added, the scheme is upgraded, the rewriters run, a
+// scheme-relative destination is made absolute and finally the host
+// is converted to punycode.
+func (c *config) destination(r *http.Request, dest string) (string, error) {
+	dest = c.collapsePathSlashes(dest)
+	dest = c.forwardQuery(r, dest)
+	dest = c.addQueryDefaults(c.requestPath(r), dest)
+	dest = c.upgradeScheme(r, dest)
+	for _, rewrite := range c.rewriters {
+		dest = rewrite(dest)
+	}
+	dest, err := c.resolveSchemeRelative(dest)
+	if err != nil {
+		return "", err
+	}
+	return c.toASCIIHost(dest)
+}
@@ -0,0 +1,23 @@
+package urlshort
+
+// Page returns at most limit entries of m, skipping the first offset,
+// in the order of m.Entries, which is sorted by path, along with the
+// total number of entries of m. A negative offset counts as 0, and a
+// limit that is not positive as no limit.
+//
+// Every call reads m afresh: pages are consistent with each other
+// only as long as m does not change between calls. If paths are added
+// to or removed from a DynamicHandler meanwhile, the following pages
+// shift accordingly, so that an entry may be listed twice or skipped.
+// To page through a fixed state, page through a snapshot instead, such
+// as the Map of the entries returned by m.Entries or a CompiledMap.
+func Page(m Mappings, offset, limit int) (entries []MappingEntry, total int) {
+	all := m.Entries()
+	total = len(all)
+	offset = min(max(offset, 0), total)
+	end := total
+	if limit > 0 && limit < total-offset {
+		end = offset + limit
+	}
+	return all[offset:end:end], total
+}
@@ -0,0 +1,108 @@
+package urlshort
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	m := Map{
+		"/a": "https://a.example.com",
+		"/b": "https://b.example.com",
+		"/c": "https://c.example.com",
+		"/d": "https://d.example.com",
+	}
+	tests := []struct {
+		name          string
+		offset, limit int
+		paths         []string
+	}{
+		{"first page", 0, 2, []string{"/a", "/b"}},
+		{"second page", 2, 2, []string{"/c", "/d"}},
+		{"partial page", 3, 2, []string{"/d"}},
+		{"past the end", 4, 2, nil},
+		{"far past the end", 10, 2, nil},
+		{"no limit", 1, 0, []string{"/b", "/c", "/d"}},
+		{"negative limit", 1, -1, []string{"/b", "/c", "/d"}},
+		{"negative offset", -5, 1, []string{"/a"}},
+		{"huge limit", 1, math.MaxInt, []string{"/b", "/c", "/d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, total := Page(m, tt.offset, tt.limit)
+			if total != 4 {
+				t.Errorf("got total %d, want 4", total)
+			}
+			var paths []string
+			for _, entry := range entries {
+				paths = append(paths, entry.Path)
+			}
+			if !slices.Equal(paths, tt.paths) {
+				t.Errorf("got paths %q, want %q", paths, tt.paths)
+			}
+		})
+	}
+}
+
+// TestPageCapacity checks that appending to a page cannot overwrite
+// the entries that follow it.
+func TestPageCapacity(t *testing.T) {
+	entries := []MappingEntry{{Path: "/a"}, {Path: "/b"}, {Path: "/c"}}
+	m := pageMappings(entries)
+	page, _ := Page(m, 0, 1)
+	_ = append(page, MappingEntry{Path: "/x"})
+	if entries[1].Path != "/b" {
+		t.Errorf("appending to a page changed the next entry to %s", entries[1].Path)
+	}
+}
+
+// pageMappings is Mappings whose Entries returns the same slice at
+// every call.
+type pageMappings []MappingEntry
+
+func (m pageMappings) Lookup(path string) (string, bool) { return "", false }
+func (m pageMappings) Entries() []MappingEntry           { return m }
+
+func TestAPIHandlerMappingsPage(t *testing.T) {
+	api := APIHandler(Map{
+		"/a": "https://a.example.com",
+		"/b": "https://b.example.com",
+		"/c": "https://c.example.com",
+	})
+
+	w := serve(api, "/api/mappings?offset=1&limit=1")
+	wantStatus(t, w, http.StatusOK)
+	var entries []MappingEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "/b" {
+		t.Errorf("got entries %+v, want /b only", entries)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("got X-Total-Count %q, want 3", got)
+	}
+
+	w = serve(api, "/api/mappings?offset=1&limit=9223372036854775807")
+	wantStatus(t, w, http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil || len(entries) != 2 {
+		t.Errorf("got entries %+v, %v for a huge limit, want the last 2", entries, err)
+	}
+
+	w = serve(api, "/api/mappings")
+	if got := w.Header().Get("X-Total-Count"); got != "" {
+		t.Errorf("got X-Total-Count %q without pagination, want none", got)
+	}
+	for query, msg := range map[string]string{
+		"offset=-1":         "invalid offset parameter",
+		"limit=x":           "invalid limit parameter",
+		"offset=1&limit=-2": "invalid limit parameter",
+	} {
+		w := serve(api, "/api/mappings?"+query)
+		wantStatus(t, w, http.StatusBadRequest)
+		wantJSONError(t, w, msg)
+	}
+}
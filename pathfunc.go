@@ -0,0 +1,79 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithPathFunc makes the handler match the path returned by path for
+// each request instead of r.URL.Path, for routers that hand the part
+// of the path to match to their handlers some other way, such as a
+// wildcard parameter. The path returned is also the one
+// WithCondition and WithQueryParams match against, and the one
+// normalized by WithNormalizer.
+//
+// For example, with http.ServeMux:
+//
+//	mux.Handle("/go/{path...}", urlshort.MapHandler(m, fallback,
+//		urlshort.WithPathFunc(urlshort.PathFromPathValue("path"))))
+//
+// or with chi, whose catch-all parameter is "*":
+//
+//	r.Handle("/go/*", urlshort.MapHandler(m, fallback,
+//		urlshort.WithPathFunc(func(r *http.Request) string {
+//			return "/" + chi.URLParam(r, "*")
+//		})))
+//
+// Either way, the key "/docs" matches requests for "/go/docs".
+func WithPathFunc(path func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.pathFunc = path
+	}
+}
+
+// PathFromPathValue returns a function for WithPathFunc reading the
+// path from the wildcard name of the http.ServeMux pattern that
+// matched the request, with a leading slash added if it has none,
+// since wildcards such as {path...} match without it.
+func PathFromPathValue(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		path := r.PathValue(name)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		return path
+	}
+}
+
+// WithEscapedPath makes the handler match the escaped form of the
+// path of each request, as returned by r.URL.EscapedPath, instead of
+// r.URL.Path, where percent-encoded characters are decoded. Keys are
+// then written escaped too, which tells apart paths that only differ
+// by an encoded slash: the key "/a%2Fb" matches requests for /a%2Fb
+// only, and the key "/a/b" requests for /a/b only, while without this
+// option both requests match "/a/b" and "/a%2Fb" matches nothing.
+//
+// The tradeoff is that paths are matched as the client encoded them,
+// except that EscapedPath encodes what the client left unencoded but
+// must be, such as spaces and non-ASCII characters. Equivalent
+// encodings of a path no longer match the same key: the key "/café"
+// matches nothing, its escaped form "/caf%C3%A9" must be used instead,
+// and matches neither /caf%c3%a9, with lowercase hexadecimal digits,
+// nor /%63af%C3%A9, with a needlessly encoded "c". Only use it when
+// keys need encoded slashes or other reserved characters.
+//
+// It replaces the path of WithPathFunc, and the other way round,
+// whichever comes last.
+func WithEscapedPath() Option {
+	return WithPathFunc(func(r *http.Request) string {
+		return r.URL.EscapedPath()
+	})
+}
+
+// requestPath returns the path of r to match.
+func (c *config) requestPath(r *http.Request) string {
+	if c.pathFunc != nil {
+		return c.pathFunc(r)
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,109 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPathFromPathValue(t *testing.T) {
+	m := map[string]string{
+		"/docs":       "https://docs.example.com",
+		"/docs/intro": "https://docs.example.com/intro",
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/go/{path...}", MapHandler(m, notFound, WithPathFunc(PathFromPathValue("path"))))
+
+	wantRedirect(t, serve(mux, "/go/docs"), http.StatusMovedPermanently, "https://docs.example.com")
+	wantRedirect(t, serve(mux, "/go/docs/intro"), http.StatusMovedPermanently, "https://docs.example.com/intro")
+	wantStatus(t, serve(mux, "/go/blog"), http.StatusNotFound)
+	wantStatus(t, serve(mux, "/docs"), http.StatusNotFound)
+}
+
+// routeParamKey is the context key under which the router of
+// TestWithPathFunc stores its catch-all parameter, as chi does.
+type routeParamKey struct{}
+
+// TestWithPathFunc mounts the handler under a simulated third-party
+// router, which strips its mount point and passes the rest of the
+// path in the request context.
+func TestWithPathFunc(t *testing.T) {
+	h := MapHandler(map[string]string{"/docs": "https://docs.example.com"}, notFound,
+		WithPathFunc(func(r *http.Request) string {
+			return "/" + r.Context().Value(routeParamKey{}).(string)
+		}),
+		WithCondition("/docs", func(r *http.Request) bool { return r.Header.Get("X-Deny") == "" }))
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/go/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeParamKey{}, rest)))
+	})
+
+	wantRedirect(t, serve(router, "/go/docs"), http.StatusMovedPermanently, "https://docs.example.com")
+	wantStatus(t, serve(router, "/go/blog"), http.StatusNotFound)
+
+	// The condition of /docs applies to the path of the router.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/go/docs", nil)
+	r.Header.Set("X-Deny", "1")
+	router.ServeHTTP(w, r)
+	wantStatus(t, w, http.StatusNotFound)
+}
+
+func TestWithPathFuncNormalized(t *testing.T) {
+	h := MapHandler(map[string]string{"/docs": "https://docs.example.com"}, notFound,
+		WithPathFunc(func(r *http.Request) string { return r.Header.Get("X-Path") }),
+		WithNormalizer(LowercaseAll))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/ignored", nil)
+	r.Header.Set("X-Path", "/DOCS")
+	h.ServeHTTP(w, r)
+	wantRedirect(t, w, http.StatusMovedPermanently, "https://docs.example.com")
+}
+
+func TestWithEscapedPath(t *testing.T) {
+	m := map[string]string{
+		"/a%2Fb":     "https://example.com/encoded",
+		"/a/b":       "https://example.com/plain",
+		"/caf%C3%A9": "https://example.com/cafe",
+	}
+	for _, tt := range []struct {
+		name   string
+		opts   []Option
+		target string
+		want   string
+	}{
+		{"decoded encoded slash", nil, "/a%2Fb", "https://example.com/plain"},
+		{"decoded slash", nil, "/a/b", "https://example.com/plain"},
+		{"escaped encoded slash", []Option{WithEscapedPath()}, "/a%2Fb", "https://example.com/encoded"},
+		{"escaped slash", []Option{WithEscapedPath()}, "/a/b", "https://example.com/plain"},
+		{"escaped non-ASCII", []Option{WithEscapedPath()}, "/caf%C3%A9", "https://example.com/cafe"},
+		{"escaped lowercase hex", []Option{WithEscapedPath()}, "/caf%c3%a9", ""},
+		{"escaped needless encoding", []Option{WithEscapedPath()}, "/%63af%C3%A9", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(MapHandler(m, notFound, tt.opts...), tt.target)
+			if tt.want == "" {
+				wantStatus(t, w, http.StatusNotFound)
+				return
+			}
+			wantRedirect(t, w, http.StatusMovedPermanently, tt.want)
+		})
+	}
+}
+
+// TestWithEscapedPathOrder checks that WithEscapedPath and WithPathFunc
+// replace each other, whichever comes last.
+func TestWithEscapedPathOrder(t *testing.T) {
+	m := map[string]string{"/a%2Fb": "https://example.com/encoded", "/other": "https://example.com/other"}
+	other := WithPathFunc(func(*http.Request) string { return "/other" })
+
+	wantRedirect(t, serve(MapHandler(m, notFound, other, WithEscapedPath()), "/a%2Fb"), http.StatusMovedPermanently, "https://example.com/encoded")
+	wantRedirect(t, serve(MapHandler(m, notFound, WithEscapedPath(), other), "/a%2Fb"), http.StatusMovedPermanently, "https://example.com/other")
+}
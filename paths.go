@@ -0,0 +1,50 @@
+package urlshort
+
+import "strings"
+
+// expandPaths returns entries with every entry listing Paths replaced
+// by one entry per path, in order, and the other entries unchanged.
+// entries is not modified.
+func expandPaths(entries []MappingEntry) []MappingEntry {
+	grouped := false
+	for _, entry := range entries {
+		if len(entry.Paths) > 0 {
+			grouped = true
+			break
+		}
+	}
+	if !grouped {
+		return entries
+	}
+
+	expanded := make([]MappingEntry, 0, len(entries))
+	for _, entry := range entries {
+		if len(entry.Paths) == 0 {
+			expanded = append(expanded, entry)
+			continue
+		}
+		for _, path := range entry.Paths {
+			e := entry
+			e.Path, e.Paths = path, nil
+			expanded = append(expanded, e)
+		}
+	}
+	return expanded
+}
+
+// name returns the path of e, or its paths separated by commas, to
+// report errors about e.
+func (e MappingEntry) name() string {
+	if e.Path != "" || len(e.Paths) == 0 {
+		return e.Path
+	}
+	return strings.Join(e.Paths, ", ")
+}
+
+// firstPath returns the path of e, or its first path.
+func (e MappingEntry) firstPath() string {
+	if e.Path != "" || len(e.Paths) == 0 {
+		return e.Path
+	}
+	return e.Paths[0]
+}
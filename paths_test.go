@@ -0,0 +1,99 @@
+package urlshort
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// groupedYAML maps three paths to the docs with one entry.
+var groupedYAML = []byte(`- paths:
+    - /docs
+    - /documentation
+    - /help
+  url: https://docs.example.com
+- path: /blog
+  url: https://blog.example.com
+`)
+
+func TestPaths(t *testing.T) {
+	h, err := YAMLHandler(groupedYAML, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/docs", "/documentation", "/help"} {
+		wantRedirect(t, serve(h, path), http.StatusMovedPermanently, "https://docs.example.com")
+	}
+	wantRedirect(t, serve(h, "/blog"), http.StatusMovedPermanently, "https://blog.example.com")
+}
+
+func TestPathsInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		yml  string
+		want string
+	}{
+		{"- path: /a\n  paths: [/b]\n  url: https://a.example.com\n", "cannot set both path and paths"},
+		{"- paths: [/a, '']\n  url: https://a.example.com\n", "empty path in paths"},
+	} {
+		_, err := ParseYAML([]byte(tt.yml))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got error %v, want %q", err, tt.want)
+		}
+	}
+}
+
+// TestPathsExportRoundTrip checks that exports keep entries listing
+// Paths grouped.
+func TestPathsExportRoundTrip(t *testing.T) {
+	entries, err := ParseYAML(groupedYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yml, err := ExportYAML(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted by path, the blog comes first.
+	want := `- path: /blog
+  url: https://blog.example.com
+- paths:
+    - /docs
+    - /documentation
+    - /help
+  url: https://docs.example.com
+`
+	if string(yml) != want {
+		t.Errorf("got\n%s\nwant\n%s", yml, want)
+	}
+
+	jsn, err := ExportJSON(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ParseJSON(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, sortByPath(entries)) {
+		t.Errorf("JSON round trip gave %+v, want %+v", fromJSON, sortByPath(entries))
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	entries := []MappingEntry{
+		{Path: "/a", URL: "https://a.example.com"},
+		{Paths: []string{"/b", "/c"}, URL: "https://bc.example.com", Temporary: true},
+	}
+	want := []MappingEntry{
+		{Path: "/a", URL: "https://a.example.com"},
+		{Path: "/b", URL: "https://bc.example.com", Temporary: true},
+		{Path: "/c", URL: "https://bc.example.com", Temporary: true},
+	}
+	if got := expandPaths(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if len(entries[1].Paths) != 2 {
+		t.Error("expandPaths modified its argument")
+	}
+}
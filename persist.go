@@ -0,0 +1,211 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// persister writes the mapping of a DynamicHandler to its file.
+type persister struct {
+	name   string
+	format string
+	delay  time.Duration
+	onErr  func(err error)
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	pending bool
+
+	// writeMu serializes writes, so that a slow write never replaces
+	// the file written by a later one.
+	writeMu sync.Mutex
+}
+
+// BindFile binds d to the mapping file name, whose format is detected
+// from its extension as for DirHandler, so that its mapping survives
+// restarts. The mapping of d is first replaced by the one of the file,
+// if it exists; a file that cannot be parsed, or that has entries a
+// DynamicHandler does not support, is an error leaving d unbound.
+//
+// Every change to the mapping is then written to the file once no
+// other change happened for delay, so that a burst of changes is
+// written once. The file is written to a temporary file in the same
+// directory which is then renamed over it, so it is never left
+// partially written. Write errors are passed to the hook of
+// WithErrorHook, with a nil request, and the next change tries again.
+// Flush writes pending changes at once, such as before exiting.
+//
+// BindFile must be called at most once, before d is changed
+// concurrently.
+func (d *DynamicHandler) BindFile(name string, delay time.Duration) error {
+	format, ok := formatFromName(name)
+	if !ok {
+		return fmt.Errorf("%s: unsupported mapping file extension", name)
+	}
+	if d.persist != nil {
+		return errors.New("dynamic handler already bound to a file")
+	}
+
+	data, err := os.ReadFile(name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := d.load(format, data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.persist = &persister{
+		name:   name,
+		format: format,
+		delay:  delay,
+		onErr: func(err error) {
+			if hook := d.handler.cfg.errorHook; hook != nil {
+				hook(nil, err)
+			}
+		},
+	}
+	return nil
+}
+
+// load replaces the mapping of d with the entries of data, in the
+// given format.
+func (d *DynamicHandler) load(format string, data []byte) error {
+	entries, err := parseMapping(format, data, d.handler.cfg.decodeOptions...)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	paths := make(map[string]string, len(entries))
+	notes := make(map[string]map[string]string)
+	expires := make(map[string]time.Time)
+	disabled := make(map[string]bool)
+	for _, entry := range expandPaths(entries) {
+		if err := checkDynamicEntry(entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		paths[entry.Path] = entry.URL
+		notes[entry.Path] = entry.Notes
+		if entry.Expires != nil {
+			expires[entry.Path] = *entry.Expires
+		}
+		disabled[entry.Path] = entry.disabled()
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	d.replace(paths, notes, expires, disabled)
+	return nil
+}
+
+// Flush writes the pending changes to the mapping of d to the file it
+// is bound to by BindFile, if any, without waiting for the delay.
+func (d *DynamicHandler) Flush() error {
+	d.mu.RLock()
+	p := d.persist
+	d.mu.RUnlock()
+
+	if p == nil || !p.stop() {
+		return nil
+	}
+	return p.write(d)
+}
+
+// changed schedules the write of the mapping of d, if it is bound to
+// a file. It must be called with d.mu held.
+func (d *DynamicHandler) changed() {
+	if d.persist != nil {
+		d.persist.schedule(d)
+	}
+}
+
+// schedule (re)starts the delay before the write of the mapping of d.
+func (p *persister) schedule(d *DynamicHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending = true
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.timer = time.AfterFunc(p.delay, func() {
+		if !p.stop() {
+			return
+		}
+		if err := p.write(d); err != nil {
+			p.onErr(err)
+		}
+	})
+}
+
+// stop cancels the pending write, if any, reporting whether there
+// was one.
+func (p *persister) stop() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	pending := p.pending
+	p.pending = false
+	return pending
+}
+
+// write writes the current mapping of d to the file.
+func (p *persister) write(d *DynamicHandler) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	var data []byte
+	var err error
+	switch entries := d.Entries(); p.format {
+	case "yaml":
+		data, err = ExportYAML(entries)
+	default:
+		data, err = ExportJSON(entries)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	return writeFileAtomic(p.name, data)
+}
+
+// writeFileAtomic writes data to the file name through a temporary
+// file renamed over it, so that name is either left as it was or
+// entirely written.
+func writeFileAtomic(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package urlshort
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestBindFileRestart changes a bound DynamicHandler, then binds a new
+// one to the same file as after a restart, and checks it serves the
+// changes.
+func TestBindFileRestart(t *testing.T) {
+	for _, name := range []string{"mapping.yaml", "mapping.json"} {
+		t.Run(name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), name)
+			d := NewDynamicHandler(nil, notFound)
+			if err := d.BindFile(name, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			d.Add("/a", "https://a.example.com")
+			err := d.Upsert([]MappingEntry{{Path: "/b", URL: "https://b.example.com", Notes: map[string]string{"owner": "web"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := d.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			restarted := NewDynamicHandler(map[string]string{"/c": "https://c.example.com"}, notFound)
+			if err := restarted.BindFile(name, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			wantRedirect(t, serve(restarted, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+			wantRedirect(t, serve(restarted, "/b"), http.StatusMovedPermanently, "https://b.example.com")
+			wantStatus(t, serve(restarted, "/c"), http.StatusNotFound)
+			if entry, _ := restarted.Entry("/b"); entry.Notes["owner"] != "web" {
+				t.Errorf("got notes %v for /b, want them restored", entry.Notes)
+			}
+			wantOnlyFile(t, name)
+		})
+	}
+}
+
+// TestBindFileDebounce checks that a burst of changes is written once
+// the delay has passed without further changes.
+func TestBindFileDebounce(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "mapping.json")
+	d := NewDynamicHandler(nil, notFound)
+	if err := d.BindFile(name, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	d.Add("/a", "https://a.example.com")
+	d.Add("/b", "https://b.example.com")
+	d.Remove("/a")
+	if _, err := os.Stat(name); err == nil {
+		t.Fatal("file written before the delay")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(name)
+		if err == nil {
+			want := "[\n  {\n    \"path\": \"/b\",\n    \"url\": \"https://b.example.com\"\n  }\n]\n"
+			if string(data) != want {
+				t.Errorf("got file\n%s\nwant\n%s", data, want)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file not written after the delay")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	wantOnlyFile(t, name)
+}
+
+func TestBindFileWriteError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sub")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	hooked := NewDynamicHandler(nil, notFound, WithErrorHook(func(r *http.Request, err error) {
+		if r != nil {
+			t.Errorf("hook got request %v, want nil", r)
+		}
+		errs <- err
+	}))
+	if err := hooked.BindFile(filepath.Join(dir, "hooked.yaml"), 0); err != nil {
+		t.Fatal(err)
+	}
+	flushed := NewDynamicHandler(nil, notFound)
+	if err := flushed.BindFile(filepath.Join(dir, "flushed.yaml"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	hooked.Add("/a", "https://a.example.com")
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("hook got a nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("write error not reported to the hook")
+	}
+
+	flushed.Add("/a", "https://a.example.com")
+	if err := flushed.Flush(); err == nil {
+		t.Error("Flush reported no error")
+	}
+}
+
+func TestBindFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		name = filepath.Join(dir, name)
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	for _, tt := range []struct {
+		name string
+		file string
+	}{
+		{"extension", filepath.Join(dir, "mapping.txt")},
+		{"invalid", write("invalid.json", "{")},
+		{"unsupported entry", write("temporary.yaml", "- path: /a\n  url: https://a.example.com\n  temporary: true\n")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDynamicHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+			if err := d.BindFile(tt.file, 0); err == nil {
+				t.Fatal("BindFile reported no error")
+			}
+			wantRedirect(t, serve(d, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+		})
+	}
+
+	d := NewDynamicHandler(nil, notFound)
+	if err := d.BindFile(filepath.Join(dir, "a.yaml"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.BindFile(filepath.Join(dir, "b.yaml"), time.Hour); err == nil {
+		t.Error("bound a handler twice")
+	}
+}
+
+// wantOnlyFile fails t unless name is the only file of its directory,
+// with no temporary file left behind.
+func wantOnlyFile(t *testing.T, name string) {
+	t.Helper()
+	files, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != filepath.Base(name) {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		t.Errorf("got files %v, want %s only", names, filepath.Base(name))
+	}
+}
@@ -0,0 +1,100 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithPrefixMatch makes mapping keys that end in a slash also match
+// every path below them, so that the key "/docs/" matches
+// "/docs/intro" and "/docs/api/v1". An exact match always takes
+// precedence over a prefix match, and the longest matching prefix
+// key takes precedence over shorter ones, with "/" matching every
+// path that nothing else does.
+//
+// By default the part of the path below the key is appended to the
+// destination; see WithPreserveOriginPath.
+func WithPrefixMatch() Option {
+	return func(c *config) {
+		c.prefixMatch = true
+	}
+}
+
+// WithPreserveOriginPath sets whether a path matched by a prefix key
+// (see WithPrefixMatch) keeps the part of the path below the key when
+// redirected. With the key "/" mapped to "https://example.com", a
+// request for "/foo" is redirected to "https://example.com/foo" if
+// preserve is true, which is the default, and to
+// "https://example.com" if it is false.
+func WithPreserveOriginPath(preserve bool) Option {
+	return func(c *config) {
+		c.stripOriginPath = !preserve
+	}
+}
+
+// lookupPrefix returns the entry of the longest prefix key of path
+// found by lookup for r, along with the part of path below the key.
+func lookupPrefix(r *http.Request, lookup lookupFunc, path string) (entry MappingEntry, rest string, ok bool, err error) {
+	for i := strings.LastIndex(path, "/"); i >= 0; i = strings.LastIndex(path[:i], "/") {
+		entry, ok, err := lookup(r, path[:i+1])
+		if ok || err != nil {
+			return entry, path[i+1:], ok, err
+		}
+	}
+	return MappingEntry{}, path, false, nil
+}
+
+// appendPath appends rest, an unescaped path relative to dest, to the
+// path of dest. The paths are joined with a single slash whatever the
+// slashes they start or end with, and the query and fragment of dest
+// are kept.
+func appendPath(dest, rest string) string {
+	if rest == "" {
+		return dest
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return strings.TrimSuffix(dest, "/") + "/" + rest
+	}
+	escaped := (&url.URL{Path: rest}).EscapedPath()
+	return u.JoinPath(escaped).String()
+}
+
+// WithCollapseSlashes makes the handler collapse runs of slashes in
+// the path of destinations into a single slash, so that
+// "https://example.com//docs///intro" is sent as
+// "https://example.com/docs/intro". The "//" after the scheme, the
+// query and the fragment are left untouched.
+func WithCollapseSlashes() Option {
+	return func(c *config) {
+		c.collapseSlashes = true
+	}
+}
+
+// collapsePathSlashes returns dest with runs of slashes in its path
+// collapsed, if configured.
+func (c *config) collapsePathSlashes(dest string) string {
+	if !c.collapseSlashes || !strings.Contains(dest, "//") {
+		return dest
+	}
+	u, err := url.Parse(dest)
+	if err != nil || !strings.Contains(u.Path, "//") {
+		return dest
+	}
+	u.Path = collapse(u.Path)
+	u.RawPath = collapse(u.RawPath)
+	return u.String()
+}
+
+// collapse replaces the runs of slashes in p with a single slash.
+func collapse(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && i > 0 && p[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(p[i])
+	}
+	return b.String()
+}
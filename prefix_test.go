@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPrefixMatch(t *testing.T) {
+	urls := map[string]string{
+		"/":           "https://example.com",
+		"/docs/":      "https://docs.example.com/v2/",
+		"/docs/api/":  "https://api.example.com",
+		"/docs/exact": "https://exact.example.com",
+	}
+	tests := []struct {
+		name     string
+		preserve bool
+		target   string
+		loc      string
+	}{
+		{"preserve bare host", true, "/foo", "https://example.com/foo"},
+		{"preserve nested", true, "/docs/intro/start", "https://docs.example.com/v2/intro/start"},
+		{"preserve longest prefix", true, "/docs/api/v1", "https://api.example.com/v1"},
+		{"exact wins", true, "/docs/exact", "https://exact.example.com"},
+		{"key itself", true, "/docs/", "https://docs.example.com/v2/"},
+		{"escaped rest", true, "/docs/a%20b", "https://docs.example.com/v2/a%20b"},
+		{"strip bare host", false, "/foo", "https://example.com"},
+		{"strip nested", false, "/docs/intro/start", "https://docs.example.com/v2/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, WithPrefixMatch(), WithPreserveOriginPath(tt.preserve))
+			wantRedirect(t, serve(h, tt.target), http.StatusMovedPermanently, tt.loc)
+		})
+	}
+}
+
+func TestPrefixMatchDefaultPreserves(t *testing.T) {
+	h := MapHandler(map[string]string{"/": "https://example.com"}, notFound, WithPrefixMatch())
+	wantRedirect(t, serve(h, "/foo"), http.StatusMovedPermanently, "https://example.com/foo")
+}
+
+func TestPrefixMatchOff(t *testing.T) {
+	h := MapHandler(map[string]string{"/docs/": "https://docs.example.com"}, notFound)
+	wantStatus(t, serve(h, "/docs/intro"), http.StatusNotFound)
+}
+
+func TestAppendPath(t *testing.T) {
+	tests := []struct {
+		dest, rest, want string
+	}{
+		{"https://example.com", "", "https://example.com"},
+		{"https://example.com", "foo", "https://example.com/foo"},
+		{"https://example.com/", "foo", "https://example.com/foo"},
+		{"https://example.com/a/", "foo/bar", "https://example.com/a/foo/bar"},
+		{"https://example.com/a?x=1#top", "foo", "https://example.com/a/foo?x=1#top"},
+	}
+	for _, tt := range tests {
+		if got := appendPath(tt.dest, tt.rest); got != tt.want {
+			t.Errorf("appendPath(%q, %q) = %q, want %q", tt.dest, tt.rest, got, tt.want)
+		}
+	}
+}
+
+func TestCollapseSlashes(t *testing.T) {
+	urls := map[string]string{
+		"/docs":     "https://example.com//docs///intro",
+		"/query":    "https://example.com//a?u=http://x.example.com//y#f//g",
+		"/escaped":  "https://example.com//a%2Fb",
+		"/relative": "/a//b/",
+		"/clean":    "https://example.com/a/b",
+	}
+	h := MapHandler(urls, notFound, WithCollapseSlashes())
+	for path, want := range map[string]string{
+		"/docs":     "https://example.com/docs/intro",
+		"/query":    "https://example.com/a?u=http://x.example.com//y#f//g",
+		"/escaped":  "https://example.com/a%2Fb",
+		"/relative": "/a/b/",
+		"/clean":    "https://example.com/a/b",
+	} {
+		wantRedirect(t, serve(h, path), http.StatusMovedPermanently, want)
+	}
+
+	wantRedirect(t, serve(MapHandler(urls, notFound), "/docs"), http.StatusMovedPermanently, "https://example.com//docs///intro")
+}
+
+func TestCollapseSlashesPrefix(t *testing.T) {
+	h := MapHandler(map[string]string{"/gh/": "https://github.com//org//"}, notFound,
+		WithPrefixMatch(), WithPreserveOriginPath(true), WithCollapseSlashes())
+	wantRedirect(t, serve(h, "/gh/repo"), http.StatusMovedPermanently, "https://github.com/org/repo")
+}
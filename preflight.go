@@ -0,0 +1,132 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultPreflightTTL is how long a preflight result is cached when
+// WithPreflight is given a non-positive TTL.
+const DefaultPreflightTTL = time.Minute
+
+// maxPreflightResults is the number of preflight results cached at
+// most, past which expired results are dropped, and then arbitrary
+// ones if none has expired.
+const maxPreflightResults = 1024
+
+// WithPreflight makes the handler check that a destination is
+// reachable before redirecting to it. The check is a HEAD request
+// made with client (http.DefaultClient if nil) under the context of
+// the incoming request, to the URL the path is mapped to, before any
+// other option, such as WithQueryForwarding, composes the destination
+// from it, so that the queries of clients cannot multiply the checks.
+// The destination is reachable if it answers with a 2xx status once
+// redirects have been followed. Requests for unreachable destinations
+// are redirected to the Backup of their entry, if it has one, and
+// passed to the fallback otherwise.
+//
+// Results are cached per mapped URL for ttl, or for
+// DefaultPreflightTTL if ttl is not positive, for up to
+// maxPreflightResults URLs at a time. Checks cut short by the
+// incoming request going away are not cached. Relative URLs are never
+// checked.
+//
+// Preflight adds the latency of a round trip to the destination to
+// every redirect whose result is not cached, so it is off by default.
+func WithPreflight(client *http.Client, ttl time.Duration) Option {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if ttl <= 0 {
+		ttl = DefaultPreflightTTL
+	}
+	return func(c *config) {
+		c.preflight = &preflight{
+			client:  client,
+			ttl:     ttl,
+			results: make(map[string]preflightResult),
+		}
+	}
+}
+
+// preflight checks and caches the reachability of destinations.
+type preflight struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	results map[string]preflightResult
+}
+
+// preflightResult is a cached reachability check.
+type preflightResult struct {
+	reachable bool
+	expires   time.Time
+}
+
+// reachable reports whether dest answered the last HEAD request sent
+// to it with a 2xx status, sending a new one if the cached result is
+// missing or has expired.
+func (p *preflight) reachable(ctx context.Context, dest string) bool {
+	if u, err := url.Parse(dest); err != nil || !u.IsAbs() {
+		return true
+	}
+
+	p.mu.Lock()
+	res, ok := p.results[dest]
+	p.mu.Unlock()
+	if ok && time.Now().Before(res.expires) {
+		return res.reachable
+	}
+
+	reachable := p.check(ctx, dest)
+	if ctx.Err() != nil {
+		return reachable
+	}
+
+	p.mu.Lock()
+	p.add(dest, preflightResult{
+		reachable: reachable,
+		expires:   time.Now().Add(p.ttl),
+	})
+	p.mu.Unlock()
+	return reachable
+}
+
+// add caches res for dest, making room for it first if the cache is
+// full. p.mu must be held.
+func (p *preflight) add(dest string, res preflightResult) {
+	if _, ok := p.results[dest]; !ok && len(p.results) >= maxPreflightResults {
+		now := time.Now()
+		for d, old := range p.results {
+			if !now.Before(old.expires) {
+				delete(p.results, d)
+			}
+		}
+		for d := range p.results {
+			if len(p.results) < maxPreflightResults {
+				break
+			}
+			delete(p.results, d)
+		}
+	}
+	p.results[dest] = res
+}
+
+// check sends a HEAD request to dest.
+func (p *preflight) check(ctx context.Context, dest string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dest, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
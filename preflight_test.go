@@ -0,0 +1,73 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPreflightReachable(t *testing.T) {
+	var heads atomic.Int32
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heads.Add(1)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	h := MapHandler(map[string]string{"/up": up.URL, "/down": down.URL}, notFound,
+		WithPreflight(up.Client(), time.Minute))
+	wantRedirect(t, serve(h, "/up"), http.StatusMovedPermanently, up.URL)
+	wantRedirect(t, serve(h, "/up"), http.StatusMovedPermanently, up.URL)
+	wantStatus(t, serve(h, "/down"), http.StatusNotFound)
+	if got := heads.Load(); got != 1 {
+		t.Errorf("got %d checks of a cached destination, want 1", got)
+	}
+}
+
+func TestPreflightBackup(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	yml := fmt.Sprintf("- path: /a\n  url: %s\n  backup: https://backup.example.com\n", down.URL)
+	h, err := YAMLHandler([]byte(yml), notFound, WithPreflight(down.Client(), 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://backup.example.com")
+}
+
+func TestPreflightIgnoresForwardedQuery(t *testing.T) {
+	var heads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heads.Add(1)
+	}))
+	defer srv.Close()
+
+	h := MapHandler(map[string]string{"/a": srv.URL + "/"}, notFound,
+		WithPreflight(srv.Client(), time.Minute), WithQueryForwarding())
+	for i := range 10 {
+		wantRedirect(t, serve(h, fmt.Sprintf("/a?n=%d", i)), http.StatusMovedPermanently, fmt.Sprintf("%s/?n=%d", srv.URL, i))
+	}
+	if got := heads.Load(); got != 1 {
+		t.Errorf("got %d checks for distinct queries, want 1", got)
+	}
+}
+
+func TestPreflightCacheBounded(t *testing.T) {
+	p := &preflight{ttl: time.Minute, results: make(map[string]preflightResult)}
+	expires := time.Now().Add(time.Minute)
+	for i := range 3 * maxPreflightResults {
+		p.add(fmt.Sprintf("https://%d.example.com", i), preflightResult{reachable: true, expires: expires})
+	}
+	if len(p.results) > maxPreflightResults {
+		t.Errorf("got %d cached results, want at most %d", len(p.results), maxPreflightResults)
+	}
+}
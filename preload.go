@@ -0,0 +1,76 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Preload is an asset of a destination that clients should fetch
+// early, sent as a Link header with rel=preload. As is the kind of
+// the asset, such as "script", "style", "font" or "image", without
+// which browsers do not use the hint.
+type Preload struct {
+	URL string
+	As  string
+}
+
+// WithPreloadHints makes the handler add preload hints to the
+// redirects of HTTP/2 requests to a same-origin destination, for the
+// critical assets of the destination, so that they can be fetched
+// while the redirect is followed:
+//
+//	Link: </static/app.js>; rel=preload; as=script
+//
+// hints maps destinations, as written in the mapping, to the assets
+// of each, so hints are opt-in per entry: entries whose destination
+// is not in hints get none. A destination is same-origin when it is a
+// path, or when its scheme and host are those of the request.
+//
+// The hints are a niche optimization, with caveats. HTTP/2 server
+// push, which servers used to derive from them, is deprecated and
+// ignored by browsers, and browsers may not act on the hints of a
+// redirect itself, so they are mostly useful to a CDN or proxy in
+// front of the handler turning them into 103 Early Hints or pushes.
+// Assets preloaded but not used by the destination only waste
+// bandwidth. Only plain redirects get the hints, not the other
+// responses of the handler.
+func WithPreloadHints(hints map[string][]Preload) Option {
+	return func(c *config) {
+		c.preloadHints = hints
+	}
+}
+
+// addPreloadHints adds the preload hints of mapped, the destination r
+// is mapped to, to w, if r is an HTTP/2 request and dest, the URL it
+// is redirected to, is same-origin.
+func (c *config) addPreloadHints(w http.ResponseWriter, r *http.Request, mapped, dest string) {
+	hints := c.preloadHints[mapped]
+	if len(hints) == 0 || r.ProtoMajor < 2 || !sameOrigin(r, dest) {
+		return
+	}
+	escape := strings.NewReplacer("<", "%3C", ">", "%3E")
+	for _, hint := range hints {
+		link := "<" + escape.Replace(hint.URL) + ">; rel=preload"
+		if hint.As != "" {
+			link += "; as=" + hint.As
+		}
+		w.Header().Add("Link", link)
+	}
+}
+
+// sameOrigin reports whether dest has the origin of r.
+func sameOrigin(r *http.Request, dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return true
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,77 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// serveHTTP2 serves an HTTP/2 GET of target with h.
+func serveHTTP2(h http.Handler, target string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestPreloadHints(t *testing.T) {
+	urls := map[string]string{
+		"/app":    "/app/",
+		"/abs":    "https://example.com/app/",
+		"/other":  "https://cdn.example.net/app/",
+		"/plain":  "/plain/",
+		"/http":   "http://example.com/app/",
+		"/strict": "/strict/",
+	}
+	hints := map[string][]Preload{
+		"/app/":                        {{URL: "/static/app.js", As: "script"}, {URL: "/static/app.css", As: "style"}},
+		"https://example.com/app/":     {{URL: "/static/app.js", As: "script"}},
+		"https://cdn.example.net/app/": {{URL: "/static/app.js", As: "script"}},
+		"http://example.com/app/":      {{URL: "/static/app.js", As: "script"}},
+		"/strict/":                     {{URL: "/static/<x>.js"}},
+	}
+	tests := []struct {
+		name   string
+		target string
+		links  []string
+	}{
+		{"path destination", "https://example.com/app", []string{"</static/app.js>; rel=preload; as=script", "</static/app.css>; rel=preload; as=style"}},
+		{"same origin", "https://example.com/abs", []string{"</static/app.js>; rel=preload; as=script"}},
+		{"other host", "https://example.com/other", nil},
+		{"other scheme", "https://example.com/http", nil},
+		{"no hints for entry", "https://example.com/plain", nil},
+		{"escaped without kind", "https://example.com/strict", []string{"</static/%3Cx%3E.js>; rel=preload"}},
+	}
+	h := MapHandler(urls, notFound, WithPreloadHints(hints))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveHTTP2(h, tt.target)
+			wantStatus(t, w, http.StatusMovedPermanently)
+			if got := w.Header().Values("Link"); !reflect.DeepEqual(got, tt.links) {
+				t.Errorf("got Link %q, want %q", got, tt.links)
+			}
+		})
+	}
+}
+
+func TestPreloadHintsHTTP1(t *testing.T) {
+	h := MapHandler(map[string]string{"/app": "/app/"}, notFound,
+		WithPreloadHints(map[string][]Preload{"/app/": {{URL: "/static/app.js", As: "script"}}}))
+	w := serve(h, "/app")
+	wantRedirect(t, w, http.StatusMovedPermanently, "/app/")
+	if got := w.Header().Values("Link"); got != nil {
+		t.Errorf("got Link %q for an HTTP/1.1 request, want none", got)
+	}
+}
+
+func TestPreloadHintsPlainRedirectsOnly(t *testing.T) {
+	h := MapHandler(map[string]string{"/app": "/app/"}, notFound,
+		WithPreloadHints(map[string][]Preload{"/app/": {{URL: "/static/app.js", As: "script"}}}),
+		WithCanonicalLink())
+	w := serveHTTP2(h, "/app")
+	if got := w.Header().Values("Link"); len(got) != 1 || got[0] != `</app/>; rel="canonical"` {
+		t.Errorf("got Link %q, want only the canonical link", got)
+	}
+}
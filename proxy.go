@@ -0,0 +1,60 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// WithProxy makes the handler reverse proxy matched requests to their
+// destination instead of redirecting the client to it, which keeps
+// the short URL in the address bar and hides the destination. The
+// upstream request is sent with transport, or http.DefaultTransport
+// if nil. Relative destinations cannot be proxied and are still
+// redirected to.
+//
+// The upstream request goes to the destination URL exactly as it
+// would have been redirected to, rather than to the request path
+// joined onto it as with httputil.NewSingleHostReverseProxy. Its Host
+// header is rewritten to the host of the destination, and
+// X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto are set from
+// the incoming request; other headers, hop-by-hop ones aside, are
+// passed through in both directions. Bodies are streamed rather than
+// buffered, and errors reaching the destination are answered with a
+// 502.
+//
+// Proxying makes the handler carry the traffic of the destination, so
+// redirecting remains the default.
+func WithProxy(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.proxy = true
+		c.proxyTransport = transport
+	}
+}
+
+// isAbs reports whether dest is an absolute URL, which can be
+// proxied to.
+func isAbs(dest string) bool {
+	u, err := url.Parse(dest)
+	return err == nil && u.IsAbs()
+}
+
+// proxyTo reverse proxies r to dest, which must be an absolute URL.
+func (c *config) proxyTo(w http.ResponseWriter, r *http.Request, dest string) {
+	target, err := url.Parse(dest)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			u := *target
+			pr.Out.URL = &u
+			pr.Out.Host = ""
+			pr.SetXForwarded()
+		},
+		Transport: c.proxyTransport,
+	}
+	rp.ServeHTTP(w, r)
+}
@@ -0,0 +1,51 @@
+package urlshort
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprintf(w, "%s %s host=%s xff=%s xfh=%s xfp=%s test=%s body=%s",
+			r.Method, r.URL.RequestURI(), r.Host,
+			r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Forwarded-Host"), r.Header.Get("X-Forwarded-Proto"),
+			r.Header.Get("X-Test"), body)
+	}))
+	defer upstream.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	h := MapHandler(map[string]string{
+		"/a":     upstream.URL + "/landing?x=1",
+		"/local": "/landing",
+		"/down":  down.URL,
+	}, notFound, WithProxy(upstream.Client().Transport))
+
+	r := httptest.NewRequest(http.MethodPost, "http://short.example/a?ignored=1", strings.NewReader("hello"))
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Test", "passed")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	wantStatus(t, w, http.StatusTeapot)
+	if got := w.Header().Get("X-Upstream"); got != "yes" {
+		t.Errorf("got X-Upstream %q, want the header of the upstream response", got)
+	}
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	want := "POST /landing?x=1 host=" + host + " xff=192.0.2.1 xfh=short.example xfp=http test=passed body=hello"
+	if got := w.Body.String(); got != want {
+		t.Errorf("got upstream request %q, want %q", got, want)
+	}
+
+	wantRedirect(t, serve(h, "/local"), http.StatusMovedPermanently, "/landing")
+	wantStatus(t, serve(h, "/down"), http.StatusBadGateway)
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+}
@@ -0,0 +1,68 @@
+package urlshort
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// WithPunycode makes the handler convert internationalized host names
+// in destinations, such as "bücher.example", to their ASCII punycode
+// form, here "xn--bcher-kva.example", before sending them, since not
+// every client accepts non-ASCII bytes in the Location header. Host
+// names are converted with the lookup profile of
+// golang.org/x/net/idna, once every other option has had its say on
+// the destination; ASCII host names are left untouched.
+//
+// If a host name cannot be converted, the destination is sent as it
+// is, unless strict is true, in which case the request is answered as
+// one whose destination could not be determined: see
+// WithErrorHandler.
+func WithPunycode(strict bool) Option {
+	return func(c *config) {
+		c.punycode = true
+		c.strictPunycode = strict
+	}
+}
+
+// toASCIIHost returns dest with its host name converted to punycode,
+// if configured.
+func (c *config) toASCIIHost(dest string) (string, error) {
+	if !c.punycode {
+		return dest, nil
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return dest, nil
+	}
+	host := u.Hostname()
+	if isASCII(host) {
+		return dest, nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		if c.strictPunycode {
+			return "", fmt.Errorf("destination %s: invalid host name: %w", dest, err)
+		}
+		return dest, nil
+	}
+	if port := u.Port(); port != "" {
+		ascii = net.JoinHostPort(ascii, port)
+	}
+	u.Host = ascii
+	return u.String(), nil
+}
+
+// isASCII reports whether s only holds ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
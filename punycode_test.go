@@ -0,0 +1,67 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPunycode(t *testing.T) {
+	urls := map[string]string{
+		"/books": "https://bücher.example/neu?q=1",
+		"/port":  "https://Bücher.example:8443/",
+		"/ascii": "https://example.com/ü",
+		"/snow":  "http://☃.example",
+		"/bad":   "https://bü_cher.example/",
+	}
+	tests := []struct {
+		name   string
+		strict bool
+		target string
+		status int
+		loc    string
+	}{
+		{"unicode host", false, "/books", http.StatusMovedPermanently, "https://xn--bcher-kva.example/neu?q=1"},
+		{"port kept", false, "/port", http.StatusMovedPermanently, "https://xn--bcher-kva.example:8443/"},
+		{"ascii host untouched", false, "/ascii", http.StatusMovedPermanently, "https://example.com/ü"},
+		{"symbol", false, "/snow", http.StatusMovedPermanently, "http://xn--n3h.example"},
+		{"strict unicode host", true, "/books", http.StatusMovedPermanently, "https://xn--bcher-kva.example/neu?q=1"},
+		{"lenient invalid host", false, "/bad", http.StatusMovedPermanently, "https://bü_cher.example/"},
+		{"strict invalid host", true, "/bad", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(MapHandler(urls, notFound, WithPunycode(tt.strict)), tt.target)
+			if tt.loc == "" {
+				wantStatus(t, w, tt.status)
+				return
+			}
+			wantRedirect(t, w, tt.status, tt.loc)
+		})
+	}
+}
+
+func TestPunycodeOff(t *testing.T) {
+	h := MapHandler(map[string]string{"/books": "https://bücher.example"}, notFound)
+	wantRedirect(t, serve(h, "/books"), http.StatusMovedPermanently, "https://bücher.example")
+}
+
+func TestPunycodeStrictErrorHandler(t *testing.T) {
+	var got error
+	h := MapHandler(map[string]string{"/bad": "https://bü_cher.example/"}, notFound,
+		WithPunycode(true),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			got = err
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+	wantStatus(t, serve(h, "/bad"), http.StatusBadGateway)
+	if got == nil {
+		t.Error("error handler got no error")
+	}
+}
+
+func TestPunycodeAfterRewriters(t *testing.T) {
+	h := MapHandler(map[string]string{"/books": "https://example.com"}, notFound,
+		WithPunycode(false),
+		WithDestinationRewriter(func(string) string { return "https://bücher.example" }))
+	wantRedirect(t, serve(h, "/books"), http.StatusMovedPermanently, "https://xn--bcher-kva.example")
+}
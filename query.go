@@ -0,0 +1,168 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+)
+
+// queryForwarding selects the query parameters of a request that are
+// forwarded to its destination.
+type queryForwarding struct {
+	allow []string
+	deny  []string
+}
+
+// WithQueryForwarding makes the handler forward the query string of
+// a matched request to its destination, so that "/go?ref=mail" mapped
+// to "https://example.com/page?id=1" is redirected to
+// "https://example.com/page?id=1&ref=mail". A parameter the destination
+// already has is not overridden. Destinations that gain a parameter
+// have their whole query re-encoded with url.Values.Encode.
+//
+// All parameters are forwarded unless restricted with
+// WithForwardQueryAllow or WithForwardQueryDeny, which both imply
+// WithQueryForwarding.
+//
+// For an entry with Variants or a Schedule, the destination is
+// selected first, then the forwarded parameters are merged onto it,
+// and the request is redirected to the result. Every variant thus
+// receives the same parameters, whichever is picked for the request,
+// and the variant picked does not depend on the query.
+func WithQueryForwarding() Option {
+	return func(c *config) {
+		if c.queryForwarding == nil {
+			c.queryForwarding = &queryForwarding{}
+		}
+	}
+}
+
+// WithForwardQueryAllow restricts the query parameters forwarded to
+// destinations to those named. When it is combined with
+// WithForwardQueryDeny, a parameter is forwarded only if it is
+// allowed and not denied, so the deny list wins.
+func WithForwardQueryAllow(names ...string) Option {
+	return func(c *config) {
+		WithQueryForwarding()(c)
+		c.queryForwarding.allow = append(c.queryForwarding.allow, names...)
+	}
+}
+
+// WithForwardQueryDeny prevents the named query parameters, such as
+// tokens, from being forwarded to destinations.
+func WithForwardQueryDeny(names ...string) Option {
+	return func(c *config) {
+		WithQueryForwarding()(c)
+		c.queryForwarding.deny = append(c.queryForwarding.deny, names...)
+	}
+}
+
+// forwards reports whether the query parameter name is forwarded.
+func (f *queryForwarding) forwards(name string) bool {
+	if f.allow != nil && !slices.Contains(f.allow, name) {
+		return false
+	}
+	return !slices.Contains(f.deny, name)
+}
+
+// forwardQuery merges the forwarded query parameters of r into dest.
+func (c *config) forwardQuery(r *http.Request, dest string) string {
+	if c.queryForwarding == nil || r.URL.RawQuery == "" {
+		return dest
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return dest
+	}
+
+	query := u.Query()
+	changed := false
+	for key, values := range r.URL.Query() {
+		if c.queryForwarding.forwards(key) && !query.Has(key) {
+			query[key] = values
+			changed = true
+		}
+	}
+	if !changed {
+		return dest
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// queryDefaults is a set of query parameters added to the
+// destinations of the paths matching pattern.
+type queryDefaults struct {
+	pattern string
+	params  url.Values
+	force   bool
+}
+
+// WithQueryParams adds params to the query of the destination of
+// every matched path that matches pattern, in the syntax of
+// path.Match (for example "/promo-*"). An empty pattern matches every
+// path and a malformed one matches none.
+//
+// A parameter the destination already has is left alone unless force
+// is true, in which case its values are replaced by those in params.
+// When several WithQueryParams options apply to the same path they
+// are merged in the order they were given, so without force the
+// earliest option wins and with force the latest does.
+//
+// Destinations that gain a parameter have their whole query
+// re-encoded with url.Values.Encode, which sorts parameters by key
+// and percent-encodes their values. Destinations that cannot be
+// parsed as URLs are left untouched.
+func WithQueryParams(pattern string, params url.Values, force bool) Option {
+	return func(c *config) {
+		c.queryDefaults = append(c.queryDefaults, queryDefaults{
+			pattern: pattern,
+			params:  params,
+			force:   force,
+		})
+	}
+}
+
+// matches reports whether the defaults apply to requests for p.
+func (d queryDefaults) matches(p string) bool {
+	if d.pattern == "" {
+		return true
+	}
+	ok, err := path.Match(d.pattern, p)
+	return err == nil && ok
+}
+
+// addQueryDefaults merges the query defaults applying to requests
+// for p into dest.
+func (c *config) addQueryDefaults(p, dest string) string {
+	var u *url.URL
+	var query url.Values
+	changed := false
+	for _, d := range c.queryDefaults {
+		if !d.matches(p) {
+			continue
+		}
+		if u == nil {
+			var err error
+			u, err = url.Parse(dest)
+			if err != nil {
+				return dest
+			}
+			query = u.Query()
+		}
+		for key, values := range d.params {
+			if d.force || !query.Has(key) {
+				query[key] = values
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return dest
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
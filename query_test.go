@@ -0,0 +1,113 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestQueryForwarding(t *testing.T) {
+	urls := map[string]string{"/go": "https://example.com/page?id=1"}
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		loc    string
+	}{
+		{"off", nil, "/go?ref=mail", "https://example.com/page?id=1"},
+		{"all", []Option{WithQueryForwarding()}, "/go?ref=mail&token=s3cret", "https://example.com/page?id=1&ref=mail&token=s3cret"},
+		{"no query", []Option{WithQueryForwarding()}, "/go", "https://example.com/page?id=1"},
+		{"no override", []Option{WithQueryForwarding()}, "/go?id=2&ref=mail", "https://example.com/page?id=1&ref=mail"},
+		{"deny", []Option{WithForwardQueryDeny("token")}, "/go?ref=mail&token=s3cret", "https://example.com/page?id=1&ref=mail"},
+		{"allow", []Option{WithForwardQueryAllow("ref")}, "/go?ref=mail&token=s3cret&utm=x", "https://example.com/page?id=1&ref=mail"},
+		{"deny wins", []Option{WithForwardQueryAllow("ref", "token"), WithForwardQueryDeny("token")}, "/go?ref=mail&token=s3cret", "https://example.com/page?id=1&ref=mail"},
+		{"only denied", []Option{WithForwardQueryDeny("token")}, "/go?token=s3cret", "https://example.com/page?id=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, tt.opts...)
+			wantRedirect(t, serve(h, tt.target), http.StatusMovedPermanently, tt.loc)
+		})
+	}
+}
+
+// TestQueryForwardingVariants checks that the forwarded parameters
+// are merged onto whichever variant is picked.
+func TestQueryForwardingVariants(t *testing.T) {
+	h, err := YAMLHandler([]byte(`
+- path: /ab
+  variants:
+    - url: https://a.example.com/?arm=a
+      weight: 1
+    - url: https://b.example.com/
+      weight: 1
+`), notFound, WithWeightedRoundRobin(), WithForwardQueryAllow("ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"https://a.example.com/?arm=a&ref=mail",
+		"https://b.example.com/?ref=mail",
+		"https://a.example.com/?arm=a&ref=mail",
+		"https://b.example.com/?ref=mail",
+	} {
+		wantRedirect(t, serve(h, "/ab?ref=mail&token=s3cret"), http.StatusMovedPermanently, want)
+	}
+}
+
+func TestQueryForwardingSchedule(t *testing.T) {
+	h, err := YAMLHandler([]byte(`
+- path: /contact
+  url: https://example.com/form
+  schedule:
+    - from: "09:00"
+      to: "17:00"
+      url: https://example.com/chat
+`), notFound, WithQueryForwarding(), WithLocation(time.UTC),
+		WithClock(func() time.Time { return time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/contact?ref=mail"), http.StatusMovedPermanently, "https://example.com/chat?ref=mail")
+}
+
+func TestQueryParams(t *testing.T) {
+	urls := map[string]string{
+		"/go":      "https://example.com/page?id=1",
+		"/promo-a": "https://example.com/a?utm_source=mail",
+		"/bad":     "https://example.com/%zz",
+	}
+	utm := url.Values{"utm_source": {"short"}}
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		loc    string
+	}{
+		{"every path", []Option{WithQueryParams("", utm, false)}, "/go", "https://example.com/page?id=1&utm_source=short"},
+		{"pattern", []Option{WithQueryParams("/promo-*", url.Values{"c": {"1"}}, false)}, "/promo-a", "https://example.com/a?c=1&utm_source=mail"},
+		{"pattern mismatch", []Option{WithQueryParams("/promo-*", utm, false)}, "/go", "https://example.com/page?id=1"},
+		{"malformed pattern", []Option{WithQueryParams("[", utm, false)}, "/go", "https://example.com/page?id=1"},
+		{"kept", []Option{WithQueryParams("", utm, false)}, "/promo-a", "https://example.com/a?utm_source=mail"},
+		{"forced", []Option{WithQueryParams("", utm, true)}, "/promo-a", "https://example.com/a?utm_source=short"},
+		{"several values", []Option{WithQueryParams("", url.Values{"tag": {"b", "a"}}, false)}, "/go", "https://example.com/page?id=1&tag=b&tag=a"},
+		{"earliest wins", []Option{
+			WithQueryParams("", url.Values{"v": {"1"}}, false),
+			WithQueryParams("", url.Values{"v": {"2"}}, false),
+		}, "/go", "https://example.com/page?id=1&v=1"},
+		{"latest forced wins", []Option{
+			WithQueryParams("", url.Values{"v": {"1"}}, true),
+			WithQueryParams("", url.Values{"v": {"2"}}, true),
+		}, "/go", "https://example.com/page?id=1&v=2"},
+		{"forwarded kept", []Option{WithQueryForwarding(), WithQueryParams("", url.Values{"ref": {"short"}}, false)}, "/go?ref=mail", "https://example.com/page?id=1&ref=mail"},
+		{"forwarded forced", []Option{WithQueryForwarding(), WithQueryParams("", url.Values{"ref": {"short"}}, true)}, "/go?ref=mail", "https://example.com/page?id=1&ref=short"},
+		{"unparsable", []Option{WithQueryParams("", utm, false)}, "/bad", "https://example.com/%zz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, tt.opts...)
+			wantRedirect(t, serve(h, tt.target), http.StatusMovedPermanently, tt.loc)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package urlshort
+
+import (
+	"net/http"
+	"slices"
+)
+
+// QueryParamHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map the value of the
+// query parameter param of any request to its corresponding URL in
+// valuesToUrls, whatever the path of the request, for legacy sites
+// whose pages are all served from one path and told apart by their
+// query, as in /?page=123. With param "page", the key "123" matches
+// /?page=123. If the parameter is missing or empty, or its value is
+// not provided in the map, then the fallback http.Handler will be
+// called instead. Only the first value of a repeated parameter is
+// matched.
+//
+// The value of the parameter takes the place of the path of the
+// request for the options of the handler: it is what WithNormalizer
+// and WithCaseInsensitive normalize, and what WithCondition and
+// WithHitBudget are keyed by, while WithPathFunc and the options on
+// the paths of requests, such as WithPrefixMatch, WithDirectoryKeys
+// and WithRootRedirect, do not apply. The rest of the query is only
+// forwarded to the destination with WithQueryForwarding, and then
+// param along with it unless it is denied by WithForwardQueryDeny.
+//
+// See MapHandler for the meaning of opts.
+func QueryParamHandler(param string, valuesToUrls map[string]string, fallback http.Handler, opts ...Option) http.HandlerFunc {
+	cfg := newConfig(slices.Concat(opts, []Option{WithPathFunc(func(r *http.Request) string {
+		return r.URL.Query().Get(param)
+	})}))
+	cfg.prefixMatch, cfg.directoryKeys, cfg.rootRedirect = false, DirectoryKeysExact, ""
+	if cfg.caseInsensitive {
+		valuesToUrls = foldKeys(valuesToUrls)
+	}
+	lookup := func(_ *http.Request, value string) (MappingEntry, bool, error) {
+		url, ok := valuesToUrls[value]
+		if value == "" {
+			ok = false
+		}
+		return MappingEntry{Path: value, URL: url}, ok, nil
+	}
+	return newHandler(lookup, fallback, cfg).ServeHTTP
+}
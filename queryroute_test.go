@@ -0,0 +1,91 @@
+package urlshort
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+var pages = map[string]string{
+	"123":   "https://example.com/about",
+	"Legal": "https://example.com/legal",
+}
+
+func TestQueryParamHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		status int
+		loc    string
+	}{
+		{"present", "/?page=123", http.StatusMovedPermanently, "https://example.com/about"},
+		{"any path", "/index.php?page=123", http.StatusMovedPermanently, "https://example.com/about"},
+		{"other params", "/?lang=en&page=123", http.StatusMovedPermanently, "https://example.com/about"},
+		{"first value", "/?page=123&page=Legal", http.StatusMovedPermanently, "https://example.com/about"},
+		{"case-sensitive", "/?page=legal", http.StatusNotFound, ""},
+		{"missing", "/", http.StatusNotFound, ""},
+		{"other param only", "/?id=123", http.StatusNotFound, ""},
+		{"empty", "/?page=", http.StatusNotFound, ""},
+		{"unmatched", "/?page=999", http.StatusNotFound, ""},
+	}
+	h := QueryParamHandler("page", pages, notFound)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.target)
+			if tt.loc == "" {
+				wantStatus(t, w, tt.status)
+				return
+			}
+			wantRedirect(t, w, tt.status, tt.loc)
+		})
+	}
+}
+
+func TestQueryParamHandlerOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		status int
+		loc    string
+	}{
+		{"case-insensitive", []Option{WithCaseInsensitive()}, "/?page=LEGAL", http.StatusMovedPermanently, "https://example.com/legal"},
+		{"normalizer", []Option{WithNormalizer(func(v string) string { return v + "3" })}, "/?page=12", http.StatusMovedPermanently, "https://example.com/about"},
+		{"query dropped", nil, "/?page=123&utm=x", http.StatusMovedPermanently, "https://example.com/about"},
+		{"query forwarded", []Option{WithQueryForwarding()}, "/?page=123&utm=x", http.StatusMovedPermanently, "https://example.com/about?page=123&utm=x"},
+		{"param denied", []Option{WithQueryForwarding(), WithForwardQueryDeny("page")}, "/?page=123&utm=x", http.StatusMovedPermanently, "https://example.com/about?utm=x"},
+		{"prefix match ignored", []Option{WithPrefixMatch()}, "/?page=1234", http.StatusNotFound, ""},
+		{"root redirect ignored", []Option{WithRootRedirect("https://example.com")}, "/", http.StatusNotFound, ""},
+		{"condition on value", []Option{WithCondition("123", func(*http.Request) bool { return false })}, "/?page=123", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(QueryParamHandler("page", pages, notFound, tt.opts...), tt.target)
+			if tt.loc == "" {
+				wantStatus(t, w, tt.status)
+				return
+			}
+			wantRedirect(t, w, tt.status, tt.loc)
+		})
+	}
+}
+
+func TestQueryParamHandlerSharedOptions(t *testing.T) {
+	// The spare capacity of opts belongs to the caller, who may be
+	// building other handlers from it concurrently.
+	opts := make([]Option, 1, 4)
+	opts[0] = WithStatus(http.StatusFound)
+	var wg sync.WaitGroup
+	for _, param := range []string{"page", "id"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := QueryParamHandler(param, pages, notFound, opts...)
+			wantRedirect(t, serve(h, "/?"+param+"=123"), http.StatusFound, "https://example.com/about")
+		}()
+	}
+	wg.Wait()
+	if opts[:2][1] != nil {
+		t.Error("QueryParamHandler wrote to the spare capacity of opts")
+	}
+}
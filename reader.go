@@ -0,0 +1,55 @@
+package urlshort
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// ErrEmptyInput is returned by ReaderHandler and StdinHandler when
+// they read no mapping data, or nothing but white space.
+var ErrEmptyInput = errors.New("empty mapping input")
+
+// ReaderHandler will read all of r, parse it as mapping data in the
+// given format, "yaml" or "json", and then return an http.HandlerFunc
+// (which also implements http.Handler) that will attempt to map any
+// paths to their corresponding URL. If the path is not provided in
+// the data, then the fallback http.Handler will be called instead.
+//
+// Reading no data is an error wrapping ErrEmptyInput, rather than an
+// empty mapping, as it most likely means the data was not provided.
+//
+// See MapHandler for the meaning of opts.
+func ReaderHandler(r io.Reader, format string, fallback http.Handler, opts ...Option) (http.HandlerFunc, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	cfg := newConfig(opts)
+	entries, err := parseMapping(format, data, cfg.decodeOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return entriesHandler(entries, fallback, cfg)
+}
+
+// StdinHandler is like ReaderHandler reading the standard input, for
+// command line programs given their mapping through a pipe:
+//
+//	cat mapping.yaml | server
+//
+// Its errors are prefixed with "stdin".
+func StdinHandler(format string, fallback http.Handler, opts ...Option) (http.HandlerFunc, error) {
+	h, err := ReaderHandler(os.Stdin, format, fallback, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("stdin: %w", err)
+	}
+	return h, nil
+}
@@ -0,0 +1,90 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReaderHandler(t *testing.T) {
+	h, err := ReaderHandler(strings.NewReader("- path: /a\n  url: https://a.example.com\n"), "yaml", notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	wantStatus(t, serve(h, "/b"), http.StatusNotFound)
+
+	h, err = ReaderHandler(strings.NewReader(`[{"path": "/b", "url": "https://b.example.com"}]`), "json", notFound, WithStatus(http.StatusFound))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/b"), http.StatusFound, "https://b.example.com")
+}
+
+func TestReaderHandlerErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	for _, tt := range []struct {
+		name   string
+		data   string
+		format string
+		err    error
+	}{
+		{"empty", "", "yaml", ErrEmptyInput},
+		{"white space", " \n\t\n", "json", ErrEmptyInput},
+		{"invalid", "- path: [", "yaml", nil},
+		{"unknown format", "path = '/a'", "toml", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := ReaderHandler(strings.NewReader(tt.data), tt.format, notFound)
+			if err == nil || h != nil {
+				t.Fatalf("got %v, %v, want an error and no handler", h, err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+	if _, err := ReaderHandler(iotest.ErrReader(errRead), "yaml", notFound); !errors.Is(err, errRead) {
+		t.Errorf("got error %v, want %v", err, errRead)
+	}
+}
+
+// withStdin runs f with the standard input reading data.
+func withStdin(t *testing.T, data string, f func()) {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	saved := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = saved }()
+	f()
+}
+
+func TestStdinHandler(t *testing.T) {
+	withStdin(t, "- path: /a\n  url: https://a.example.com\n", func() {
+		h, err := StdinHandler("yaml", notFound)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	})
+
+	withStdin(t, "", func() {
+		_, err := StdinHandler("yaml", notFound)
+		if !errors.Is(err, ErrEmptyInput) || !strings.HasPrefix(err.Error(), "stdin: ") {
+			t.Errorf("got error %v, want ErrEmptyInput prefixed with stdin", err)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package urlshort
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DefaultReferrerCapacity is the number of referrers tracked per path
+// by NewReferrerTracker when it is given a non-positive capacity.
+const DefaultReferrerCapacity = 100
+
+// ReferrerCount is the estimated number of redirects of a path whose
+// request had Referrer as Referer header. Requests without the header
+// are counted under the empty referrer.
+type ReferrerCount struct {
+	Referrer string
+	Count    uint64
+}
+
+// ReferrerTracker is an http.Handler that tallies the referrers of
+// the requests redirected by the handler of this package it wraps,
+// per mapping key as for HitCounter. It is safe for concurrent use.
+//
+// Memory is bounded by tracking at most a fixed number of referrers
+// per key with the space-saving algorithm: once the limit is reached,
+// a new referrer replaces the least counted one and inherits its
+// count. Counts are therefore overestimated by at most the count of
+// the least counted referrer, but every referrer counted more often
+// than that is tracked, so the top referrers are reliable whenever
+// they stand out from the long tail.
+type ReferrerTracker struct {
+	next     http.Handler
+	capacity int
+
+	mu    sync.Mutex
+	paths map[string]map[string]uint64
+}
+
+// NewReferrerTracker returns a ReferrerTracker serving requests with
+// next and tracking at most capacity referrers per mapping key, or
+// DefaultReferrerCapacity if capacity is not positive.
+func NewReferrerTracker(next http.Handler, capacity int) *ReferrerTracker {
+	if capacity <= 0 {
+		capacity = DefaultReferrerCapacity
+	}
+	return &ReferrerTracker{
+		next:     next,
+		capacity: capacity,
+		paths:    make(map[string]map[string]uint64),
+	}
+}
+
+func (t *ReferrerTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, rec := withRedirect(r)
+	t.next.ServeHTTP(w, r)
+	if !rec.matched {
+		return
+	}
+
+	t.record(rec.key, r.Referer())
+}
+
+// record counts a redirect of key referred by referrer.
+func (t *ReferrerTracker) record(key, referrer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts, ok := t.paths[key]
+	if !ok {
+		counts = make(map[string]uint64)
+		t.paths[key] = counts
+	}
+	if _, ok := counts[referrer]; ok || len(counts) < t.capacity {
+		counts[referrer]++
+		return
+	}
+
+	var least string
+	var low uint64
+	for ref, n := range counts {
+		if low == 0 || n < low || n == low && ref < least {
+			least, low = ref, n
+		}
+	}
+	delete(counts, least)
+	counts[referrer] = low + 1
+}
+
+// TopReferrers returns the n most counted referrers of the mapping
+// key path, most counted first, with ties broken by referrer. It
+// returns every tracked referrer if n is not positive.
+func (t *ReferrerTracker) TopReferrers(path string, n int) []ReferrerCount {
+	t.mu.Lock()
+	top := make([]ReferrerCount, 0, len(t.paths[path]))
+	for ref, count := range t.paths[path] {
+		top = append(top, ReferrerCount{Referrer: ref, Count: count})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Referrer < top[j].Referrer
+	})
+	if n > 0 && n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,69 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestReferrerTracker(t *testing.T) {
+	tr := NewReferrerTracker(MapHandler(map[string]string{
+		"/a": "https://a.example.com",
+		"/b": "https://b.example.com",
+	}, notFound), 0)
+	for _, req := range []struct{ target, referrer string }{
+		{"/a", "https://news.example.com/"},
+		{"/a", "https://news.example.com/"},
+		{"/a", "https://mail.example.com/"},
+		{"/a", ""},
+		{"/b", "https://news.example.com/"},
+		{"/missing", "https://news.example.com/"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, req.target, nil)
+		if req.referrer != "" {
+			r.Header.Set("Referer", req.referrer)
+		}
+		tr.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	want := []ReferrerCount{
+		{"https://news.example.com/", 2},
+		{"", 1},
+		{"https://mail.example.com/", 1},
+	}
+	if got := tr.TopReferrers("/a", 0); !slices.Equal(got, want) {
+		t.Errorf("got referrers %v for /a, want %v", got, want)
+	}
+	if got := tr.TopReferrers("/a", 2); !slices.Equal(got, want[:2]) {
+		t.Errorf("got top 2 referrers %v for /a, want %v", got, want[:2])
+	}
+	if got, want := tr.TopReferrers("/b", 5), []ReferrerCount{{"https://news.example.com/", 1}}; !slices.Equal(got, want) {
+		t.Errorf("got referrers %v for /b, want %v", got, want)
+	}
+	if got := tr.TopReferrers("/missing", 0); len(got) != 0 {
+		t.Errorf("got referrers %v for an unmatched path, want none", got)
+	}
+}
+
+func TestReferrerTrackerCapacity(t *testing.T) {
+	tr := NewReferrerTracker(notFound, 2)
+	for _, referrer := range []string{"x", "x", "x", "y", "z", "z", "z"} {
+		tr.record("/a", referrer)
+	}
+	// z replaced y, inheriting its count, and so is overestimated by 1.
+	want := []ReferrerCount{{"z", 4}, {"x", 3}}
+	if got := tr.TopReferrers("/a", 0); !slices.Equal(got, want) {
+		t.Errorf("got referrers %v, want %v", got, want)
+	}
+
+	// The least counted referrer is replaced, the first by name on ties.
+	tr = NewReferrerTracker(notFound, 2)
+	for _, referrer := range []string{"b", "a", "c"} {
+		tr.record("/a", referrer)
+	}
+	want = []ReferrerCount{{"c", 2}, {"b", 1}}
+	if got := tr.TopReferrers("/a", 0); !slices.Equal(got, want) {
+		t.Errorf("got referrers %v, want %v", got, want)
+	}
+}
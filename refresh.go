@@ -0,0 +1,44 @@
+package urlshort
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WithRefresh makes the handler answer matched requests with a 200
+// and a "Refresh: <seconds>; url=<destination>" header instead of a
+// redirect, so that the browser navigates to the destination after
+// delay, rounded down to whole seconds. The body is a minimal HTML
+// page linking to the destination for clients that ignore the
+// header.
+//
+// The Refresh header was never part of an HTTP RFC, but is specified
+// by the HTML standard and honoured by all major browsers. Other
+// clients, such as crawlers, command line tools and HTTP libraries,
+// generally ignore it and only see a 200, so redirecting remains the
+// default.
+func WithRefresh(delay time.Duration) Option {
+	return func(c *config) {
+		c.refresh = true
+		c.refreshDelay = delay
+	}
+}
+
+// refreshPage is the body of the responses written by refreshTo.
+var refreshPage = template.Must(template.New("refresh").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Redirecting</title></head>
+<body><p>Redirecting to <a href="{{.}}">{{.}}</a>.</p></body>
+</html>
+`))
+
+// refreshTo writes a response sending the client to url after delay.
+func refreshTo(w http.ResponseWriter, url string, delay time.Duration) {
+	seconds := max(int(delay/time.Second), 0)
+	w.Header().Set("Refresh", strconv.Itoa(seconds)+"; url="+url)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	refreshPage.Execute(w, url)
+}
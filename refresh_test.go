@@ -0,0 +1,48 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRefresh(t *testing.T) {
+	urls := map[string]string{
+		"/a":   "https://a.example.com",
+		"/xss": `https://example.com/?a=1&b="><script>`,
+	}
+	for _, tt := range []struct {
+		name    string
+		delay   time.Duration
+		refresh string
+	}{
+		{"rounded down", 5900 * time.Millisecond, "5; url=https://a.example.com"},
+		{"immediate", 0, "0; url=https://a.example.com"},
+		{"negative", -time.Second, "0; url=https://a.example.com"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(MapHandler(urls, notFound, WithRefresh(tt.delay)), "/a")
+			wantStatus(t, w, http.StatusOK)
+			if got := w.Header().Get("Refresh"); got != tt.refresh {
+				t.Errorf("got Refresh %q, want %q", got, tt.refresh)
+			}
+			if got := w.Header().Get("Location"); got != "" {
+				t.Errorf("got Location %q, want none", got)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("got Content-Type %q, want HTML", got)
+			}
+			if body := w.Body.String(); !strings.Contains(body, `<a href="https://a.example.com">https://a.example.com</a>`) {
+				t.Errorf("got body %q, want a link to the destination", body)
+			}
+		})
+	}
+
+	h := MapHandler(urls, notFound, WithRefresh(time.Second))
+	w := serve(h, "/xss")
+	if body := w.Body.String(); strings.Contains(body, "<script>") || !strings.Contains(body, "a=1&amp;b=") {
+		t.Errorf("got body %q, want the destination escaped", body)
+	}
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+}
@@ -0,0 +1,138 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultMaxHops is the number of redirects WithResolveRedirects
+// follows when it is given a non-positive limit.
+const DefaultMaxHops = 5
+
+// WithResolveRedirects makes the handlers built from entries, such as
+// YAMLHandler and DirHandler, replace the URL of every entry that
+// redirects elsewhere with the URL it finally leads to, so that
+// clients get there in one hop. Each destination is resolved once,
+// when the handler is built, by following its redirects with HEAD
+// requests made with client (http.DefaultClient if nil), up to
+// maxHops redirects (DefaultMaxHops if not positive) and for at most
+// timeout (no limit if not positive). Successful results are cached
+// for the lifetime of the option, so destinations are not resolved
+// again when a LayeredHandler is rebuilt.
+//
+// A destination is left as it is if resolving it fails, takes longer
+// than timeout, or leads to more than maxHops redirects. Relative
+// destinations, and the URLs of the schedules, variants and backups
+// of entries, are never resolved.
+//
+// Destinations are resolved one after the other, so building a
+// handler can take up to timeout per destination: this trades a
+// slower start for faster redirects.
+func WithResolveRedirects(client *http.Client, maxHops int, timeout time.Duration) Option {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+	noFollow := *client
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	res := &resolver{
+		client:   &noFollow,
+		maxHops:  maxHops,
+		timeout:  timeout,
+		resolved: make(map[string]string),
+	}
+	return func(c *config) {
+		c.resolver = res
+	}
+}
+
+// resolver resolves the final URL of destinations.
+type resolver struct {
+	client  *http.Client
+	maxHops int
+	timeout time.Duration
+
+	mu       sync.Mutex
+	resolved map[string]string
+}
+
+// resolveEntries returns a copy of entries with their URLs resolved,
+// if configured.
+func (c *config) resolveEntries(entries []MappingEntry) []MappingEntry {
+	if c.resolver == nil {
+		return entries
+	}
+	resolved := make([]MappingEntry, len(entries))
+	for i, entry := range entries {
+		if entry.URL != "" {
+			entry.URL = c.resolver.resolve(entry.URL)
+		}
+		resolved[i] = entry
+	}
+	return resolved
+}
+
+// resolve returns the URL dest finally leads to, or dest if it cannot
+// be determined.
+func (res *resolver) resolve(dest string) string {
+	u, err := url.Parse(dest)
+	if err != nil || !u.IsAbs() {
+		return dest
+	}
+
+	res.mu.Lock()
+	final, ok := res.resolved[dest]
+	res.mu.Unlock()
+	if ok {
+		return final
+	}
+
+	final, ok = res.follow(u)
+	if !ok {
+		return dest
+	}
+	res.mu.Lock()
+	res.resolved[dest] = final
+	res.mu.Unlock()
+	return final
+}
+
+// follow follows the redirects of u, reporting the URL it finally
+// leads to.
+func (res *resolver) follow(u *url.URL) (string, bool) {
+	ctx := context.Background()
+	if res.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, res.timeout)
+		defer cancel()
+	}
+
+	for hops := 0; hops <= res.maxHops; hops++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+		if err != nil {
+			return "", false
+		}
+		resp, err := res.client.Do(req)
+		if err != nil {
+			return "", false
+		}
+		resp.Body.Close()
+
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || loc == "" {
+			return u.String(), true
+		}
+		u, err = u.Parse(loc)
+		if err != nil {
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,94 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// redirectChains is a server whose /hop/n redirects to /hop/n-1 down
+// to /hop/0, which answers with a 200, whose /loop redirects to
+// itself, and whose /slow answers once unblock is closed.
+func redirectChains(t *testing.T) (srv *httptest.Server, requests *atomic.Int32) {
+	requests = new(atomic.Int32)
+	unblock := make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method != http.MethodHead {
+			t.Errorf("got a %s request, want HEAD", r.Method)
+		}
+		switch {
+		case r.URL.Path == "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case r.URL.Path == "/slow":
+			<-unblock
+		case strings.HasPrefix(r.URL.Path, "/hop/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+			if n > 0 {
+				http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusMovedPermanently)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(func() {
+		close(unblock)
+		srv.Close()
+	})
+	return srv, requests
+}
+
+func TestWithResolveRedirects(t *testing.T) {
+	srv, requests := redirectChains(t)
+	yml := []byte(fmt.Sprintf(`
+- path: /two
+  url: %[1]s/hop/2
+- path: /final
+  url: %[1]s/hop/0
+- path: /far
+  url: %[1]s/hop/4
+- path: /loop
+  url: %[1]s/loop
+- path: /missing
+  url: %[1]s/missing
+- path: /slow
+  url: %[1]s/slow
+- path: /relative
+  url: /hop/2
+- path: /old
+  gone: true
+`, srv.URL))
+	opt := WithResolveRedirects(srv.Client(), 3, 200*time.Millisecond)
+	h, err := YAMLHandler(yml, notFound, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"/two":      srv.URL + "/hop/0",
+		"/final":    srv.URL + "/hop/0",
+		"/far":      srv.URL + "/hop/4", // more than 3 hops
+		"/loop":     srv.URL + "/loop",
+		"/missing":  srv.URL + "/missing",
+		"/slow":     srv.URL + "/slow",
+		"/relative": "/hop/2",
+	} {
+		wantRedirect(t, serve(h, path), http.StatusMovedPermanently, want)
+	}
+	wantStatus(t, serve(h, "/old"), http.StatusGone)
+
+	// Successful results are cached across handlers built with opt.
+	before := requests.Load()
+	if _, err := YAMLHandler(yml, notFound, opt); err != nil {
+		t.Fatal(err)
+	}
+	// /far, /loop and /slow are resolved again.
+	if got, want := requests.Load()-before, int32(4+4+1); got != want {
+		t.Errorf("got %d requests rebuilding the handler, want %d", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package urlshort
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DestinationRewriter transforms the destination of a matched path
+// before the request is redirected to it.
+type DestinationRewriter func(url string) string
+
+// WithDestinationRewriter makes the handler pass the destination of
+// every matched path through rewriters, in order, once every other
+// option has had its say on the destination.
+func WithDestinationRewriter(rewriters ...DestinationRewriter) Option {
+	return func(c *config) {
+		c.rewriters = append(c.rewriters, rewriters...)
+	}
+}
+
+// ForceHTTPS is a DestinationRewriter that changes the scheme of
+// http destinations to https.
+func ForceHTTPS(dest string) string {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "http" {
+		return dest
+	}
+
+	u.Scheme = "https"
+	return u.String()
+}
+
+// StripQueryParams returns a DestinationRewriter that removes the
+// query parameters with the given names from destinations, such as
+// tracking parameters. Destinations without any of them are left
+// untouched; the query of the others is re-encoded by
+// url.Values.Encode.
+func StripQueryParams(names ...string) DestinationRewriter {
+	return func(dest string) string {
+		u, err := url.Parse(dest)
+		if err != nil {
+			return dest
+		}
+
+		query := u.Query()
+		changed := false
+		for _, name := range names {
+			if query.Has(name) {
+				query.Del(name)
+				changed = true
+			}
+		}
+		if !changed {
+			return dest
+		}
+
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+}
+
+// ReplaceHost returns a DestinationRewriter that moves destinations
+// on the host from, such as a legacy domain, to the host to. Hosts
+// are compared case-insensitively and include the port, if any.
+func ReplaceHost(from, to string) DestinationRewriter {
+	return func(dest string) string {
+		u, err := url.Parse(dest)
+		if err != nil || !strings.EqualFold(u.Host, from) {
+			return dest
+		}
+
+		u.Host = to
+		return u.String()
+	}
+}
@@ -0,0 +1,47 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRewriters(t *testing.T) {
+	tests := []struct {
+		name     string
+		rewriter DestinationRewriter
+		dest     string
+		want     string
+	}{
+		{"https upgraded", ForceHTTPS, "http://example.com/a?b=1", "https://example.com/a?b=1"},
+		{"https kept", ForceHTTPS, "https://example.com/a", "https://example.com/a"},
+		{"https other scheme", ForceHTTPS, "mailto:a@example.com", "mailto:a@example.com"},
+		{"https relative", ForceHTTPS, "/a", "/a"},
+		{"strip", StripQueryParams("utm_source", "fbclid"), "https://example.com/?id=1&utm_source=x&fbclid=y", "https://example.com/?id=1"},
+		{"strip none", StripQueryParams("utm_source"), "https://example.com/?b=2&a=1", "https://example.com/?b=2&a=1"},
+		{"strip all", StripQueryParams("utm_source"), "https://example.com/?utm_source=x", "https://example.com/"},
+		{"strip unparsable", StripQueryParams("utm_source"), "https://example.com/%zz?utm_source=x", "https://example.com/%zz?utm_source=x"},
+		{"host replaced", ReplaceHost("old.example.com", "new.example.com"), "https://old.example.com/a?b=1", "https://new.example.com/a?b=1"},
+		{"host case", ReplaceHost("old.example.com", "new.example.com"), "https://OLD.example.com/a", "https://new.example.com/a"},
+		{"host other", ReplaceHost("old.example.com", "new.example.com"), "https://older.example.com/a", "https://older.example.com/a"},
+		{"host port", ReplaceHost("old.example.com", "new.example.com"), "https://old.example.com:8443/a", "https://old.example.com:8443/a"},
+		{"host with port", ReplaceHost("old.example.com:8443", "new.example.com"), "https://old.example.com:8443/a", "https://new.example.com/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rewriter(tt.dest); got != tt.want {
+				t.Errorf("got %q for %q, want %q", got, tt.dest, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDestinationRewriter(t *testing.T) {
+	urls := map[string]string{"/a": "http://old.example.com/a?utm_source=x"}
+	h := MapHandler(urls, notFound,
+		WithQueryForwarding(),
+		WithDestinationRewriter(ForceHTTPS, ReplaceHost("old.example.com", "new.example.com")),
+		WithDestinationRewriter(StripQueryParams("utm_source"), strings.ToUpper))
+	wantRedirect(t, serve(h, "/a?ref=mail&utm_source=y"), http.StatusMovedPermanently, "HTTPS://NEW.EXAMPLE.COM/A?REF=MAIL")
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+}
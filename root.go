@@ -0,0 +1,22 @@
+package urlshort
+
+// WithRootRedirect makes the handler redirect requests for the root
+// of the site to url, whether or not the mapping has an entry for "/".
+// All other paths are matched as usual, so unmatched ones still reach
+// the fallback.
+//
+// The root is matched on the request path alone: "/" and the empty
+// path are both treated as the root, while the query string plays no
+// part in matching, so "/?x=1" is the root too. The empty path does
+// not occur in requests received by a server, but does in requests
+// passed on by http.StripPrefix when the path equals the prefix.
+func WithRootRedirect(url string) Option {
+	return func(c *config) {
+		c.rootRedirect = url
+	}
+}
+
+// isRoot reports whether path is the root of the site.
+func isRoot(path string) bool {
+	return path == "" || path == "/"
+}
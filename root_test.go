@@ -0,0 +1,40 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRootRedirect(t *testing.T) {
+	h := MapHandler(map[string]string{"/": "https://mapped.example.com", "/a": "https://a.example.com"}, notFound,
+		WithRootRedirect("https://home.example.com"))
+	tests := []struct {
+		target string
+		status int
+		loc    string
+	}{
+		{"/", http.StatusMovedPermanently, "https://home.example.com"},
+		{"/?x=1", http.StatusMovedPermanently, "https://home.example.com"},
+		{"/a", http.StatusMovedPermanently, "https://a.example.com"},
+		{"/b", http.StatusNotFound, ""},
+		{"//", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(h, tt.target)
+		if w.Code != tt.status || w.Header().Get("Location") != tt.loc {
+			t.Errorf("%s: got %d to %q, want %d to %q", tt.target, w.Code, w.Header().Get("Location"), tt.status, tt.loc)
+		}
+	}
+}
+
+func TestRootRedirectEmptyPath(t *testing.T) {
+	h := http.StripPrefix("/app", MapHandler(nil, notFound, WithRootRedirect("https://home.example.com")))
+	wantRedirect(t, serve(h, "/app"), http.StatusMovedPermanently, "https://home.example.com")
+	wantRedirect(t, serve(h, "/app/"), http.StatusMovedPermanently, "https://home.example.com")
+	wantStatus(t, serve(h, "/app/b"), http.StatusNotFound)
+}
+
+func TestRootRedirectUnset(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, notFound)
+	wantStatus(t, serve(h, "/"), http.StatusNotFound)
+}
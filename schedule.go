@@ -0,0 +1,152 @@
+package urlshort
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScheduleRule redirects to URL, instead of the URL of its entry,
+// during a window of time repeated on the given days.
+//
+// The window starts at From and ends at To, both written as "15:04"
+// in the location of the handler (see WithLocation), and includes
+// From but not To. A window whose end is not after its start spans
+// midnight: with From "22:00" and To "06:00" it lasts from 22:00 on
+// one of the days to 06:00 the day after, and with From and To equal
+// it lasts 24 hours. Days are written "mon" to "sun", in any case,
+// and name the days windows start on; no days means every day.
+//
+// Windows are compared to the wall clock time of the location, so
+// around daylight saving time changes a window lasts an hour less or
+// more than it would otherwise: its part in the hour skipped when
+// clocks go forward never happens, and its part in the hour repeated
+// when they go back happens twice.
+type ScheduleRule struct {
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+	From string   `yaml:"from" json:"from"`
+	To   string   `yaml:"to" json:"to"`
+	URL  string   `yaml:"url" json:"url"`
+}
+
+// weekdays maps the names of the days accepted by ScheduleRule to
+// their time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// validate reports whether the fields of the rule can be parsed.
+func (s ScheduleRule) validate() error {
+	if _, err := parseClock(s.From); err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	if _, err := parseClock(s.To); err != nil {
+		return fmt.Errorf("to: %w", err)
+	}
+	for _, day := range s.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("unknown day %q", day)
+		}
+	}
+	if s.URL == "" {
+		return fmt.Errorf("missing url")
+	}
+	return nil
+}
+
+// parseClock returns the number of minutes since midnight of the
+// time of day s, written as "15:04".
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// activeAt reports whether t, in the location of the handler, falls
+// in a window of the rule. Rules that do not validate never do.
+func (s ScheduleRule) activeAt(t time.Time) bool {
+	from, err := parseClock(s.From)
+	if err != nil {
+		return false
+	}
+	to, err := parseClock(s.To)
+	if err != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if from < to {
+		return s.onDay(day) && from <= now && now < to
+	}
+	yesterday := (day + 6) % 7
+	return s.onDay(day) && now >= from || s.onDay(yesterday) && now < to
+}
+
+// onDay reports whether windows of the rule start on day.
+func (s ScheduleRule) onDay(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, name := range s.Days {
+		if d, ok := weekdays[strings.ToLower(name)]; ok && d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// WithClock makes the handler get the current time from now instead
+// of time.Now, for the schedules and expiry of entries and the windows
+// of WithHitBudget. It is meant for tests.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.clock = now
+	}
+}
+
+// WithLocation sets the time zone the schedules of entries are
+// written in, which is time.Local by default.
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) {
+		c.location = loc
+	}
+}
+
+// now returns the current time.
+func (c *config) now() time.Time {
+	if c.clock != nil {
+		return c.clock()
+	}
+	return time.Now()
+}
+
+// selectURL returns entry redirected to the URL of its first rule
+// active now, if any, or otherwise to one of its variants.
+func (c *config) selectURL(entry MappingEntry) MappingEntry {
+	if len(entry.Schedule) == 0 {
+		return c.vary(entry)
+	}
+
+	loc := time.Local
+	if c.location != nil {
+		loc = c.location
+	}
+
+	t := c.now().In(loc)
+	for _, rule := range entry.Schedule {
+		if rule.activeAt(t) {
+			entry.URL = rule.URL
+			return entry
+		}
+	}
+	return c.vary(entry)
+}
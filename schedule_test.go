@@ -0,0 +1,94 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScheduleRuleActiveAt(t *testing.T) {
+	// 2024-01-05 is a Friday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+	office := ScheduleRule{Days: []string{"Mon", "tue", "wed", "thu", "fri"}, From: "09:00", To: "17:00"}
+	night := ScheduleRule{Days: []string{"fri"}, From: "22:00", To: "06:00"}
+	allDay := ScheduleRule{From: "00:00", To: "00:00"}
+
+	for _, tt := range []struct {
+		name string
+		rule ScheduleRule
+		t    time.Time
+		want bool
+	}{
+		{"office start", office, at(5, 9, 0), true},
+		{"office before", office, at(5, 8, 59), false},
+		{"office end", office, at(5, 17, 0), false},
+		{"office weekend", office, at(6, 10, 0), false},
+		{"night start", night, at(5, 22, 0), true},
+		{"night before midnight", night, at(5, 23, 59), true},
+		{"night after midnight", night, at(6, 5, 59), true},
+		{"night end", night, at(6, 6, 0), false},
+		{"night other start day", night, at(6, 23, 0), false},
+		{"night after other day", night, at(5, 1, 0), false},
+		{"all day", allDay, at(6, 13, 0), true},
+		{"invalid", ScheduleRule{From: "9am", To: "17:00"}, at(5, 10, 0), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.activeAt(tt.t); got != tt.want {
+				t.Errorf("activeAt(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleHandler(t *testing.T) {
+	yml := []byte(`
+- path: /contact
+  url: https://example.com/contact-form
+  schedule:
+    - days: [mon, tue, wed, thu, fri]
+      from: "09:00"
+      to: "17:00"
+      url: https://example.com/live-chat
+    - from: "22:00"
+      to: "06:00"
+      url: https://example.com/night
+`)
+	// The handler is two hours ahead of UTC, so 07:30 UTC on Friday
+	// 2024-01-05 is 09:30 in its location.
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	for _, tt := range []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{"business hours", time.Date(2024, 1, 5, 7, 30, 0, 0, time.UTC), "https://example.com/live-chat"},
+		{"before opening", time.Date(2024, 1, 5, 6, 30, 0, 0, time.UTC), "https://example.com/contact-form"},
+		{"weekend", time.Date(2024, 1, 6, 7, 30, 0, 0, time.UTC), "https://example.com/contact-form"},
+		{"across midnight", time.Date(2024, 1, 5, 22, 30, 0, 0, time.UTC), "https://example.com/night"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := YAMLHandler(yml, notFound,
+				WithClock(func() time.Time { return tt.now }), WithLocation(loc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRedirect(t, serve(h, "/contact"), http.StatusMovedPermanently, tt.want)
+		})
+	}
+}
+
+func TestScheduleInvalid(t *testing.T) {
+	for _, rule := range []string{
+		`{from: "9am", to: "17:00", url: https://a.example.com}`,
+		`{from: "09:00", to: "25:00", url: https://a.example.com}`,
+		`{days: [someday], from: "09:00", to: "17:00", url: https://a.example.com}`,
+		`{from: "09:00", to: "17:00"}`,
+	} {
+		_, err := ParseYAML([]byte("- path: /a\n  url: https://b.example.com\n  schedule: [" + rule + "]\n"))
+		if err == nil {
+			t.Errorf("ParseYAML accepted rule %s", rule)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// WithAllowedSchemes restricts the destinations of the handler to
+// URLs with one of the given schemes, such as "https", or without a
+// scheme, like relative URLs, which stay on the site of the request.
+// Schemes are compared case-insensitively. This guards against
+// mappings sending users to "javascript:" or "data:" URLs, which
+// browsers may run in the context of the site that redirected to
+// them.
+//
+// The handlers built from a static set of redirects, such as New,
+// YAMLHandler and DirHandler, fail to build with an error joining one
+// *EntryError per entry with a disallowed URL, backup, variant or
+// schedule rule. Every handler also checks the final destination of
+// each request, including those computed by FuncHandler or read from
+// a Store, and answers requests for disallowed destinations with a
+// plain text 403 Forbidden if forbid is true, or passes them to the
+// fallback otherwise.
+func WithAllowedSchemes(forbid bool, schemes ...string) Option {
+	return func(c *config) {
+		c.allowedSchemes = make(map[string]bool, len(schemes))
+		for _, scheme := range schemes {
+			c.allowedSchemes[strings.ToLower(scheme)] = true
+		}
+		c.forbidSchemes = forbid
+	}
+}
+
+// allowedScheme reports whether dest has a scheme allowed by the
+// configuration.
+func (c *config) allowedScheme(dest string) bool {
+	if c.allowedSchemes == nil {
+		return true
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || c.allowedSchemes[strings.ToLower(u.Scheme)]
+}
+
+// checkSchemes checks the URLs of entries against the allowed
+// schemes.
+func (c *config) checkSchemes(entries []MappingEntry) error {
+	if c.allowedSchemes == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		check := func(field, dest string) bool {
+			if dest == "" || c.allowedScheme(dest) {
+				return true
+			}
+			errs = append(errs, &EntryError{Path: entry.name(), Problem: fmt.Sprintf("%s %q has a disallowed scheme", field, dest)})
+			return false
+		}
+		if !check("url", entry.URL) || !check("backup", entry.Backup) {
+			continue
+		}
+		for i, v := range entry.Variants {
+			if !check(fmt.Sprintf("variant %d url", i+1), v.URL) {
+				break
+			}
+		}
+		for i, rule := range entry.Schedule {
+			if !check(fmt.Sprintf("schedule rule %d url", i+1), rule.URL) {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
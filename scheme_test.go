@@ -0,0 +1,74 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAllowedSchemesBuild(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		yml  string
+		ok   bool
+	}{
+		{"https", "- path: /a\n  url: https://a.example.com\n", true},
+		{"scheme case", "- path: /a\n  url: HTTPS://a.example.com\n", true},
+		{"relative", "- path: /a\n  url: /b\n", true},
+		{"http", "- path: /a\n  url: http://a.example.com\n", false},
+		{"javascript", "- path: /a\n  url: javascript:alert(1)\n", false},
+		{"javascript case", "- path: /a\n  url: JavaScript:alert(1)\n", false},
+		{"data", "- path: /a\n  url: data:text/html,<script>alert(1)</script>\n", false},
+		{"backup", "- path: /a\n  url: https://a.example.com\n  backup: javascript:alert(1)\n", false},
+		{"variant", "- path: /a\n  variants: [{url: 'data:,x', weight: 1}]\n", false},
+		{"schedule", "- path: /a\n  url: https://a.example.com\n  schedule: [{from: '09:00', to: '17:00', url: 'javascript:alert(1)'}]\n", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := YAMLHandler([]byte(tt.yml), notFound, WithAllowedSchemes(true, "https"))
+			if tt.ok {
+				if err != nil {
+					t.Errorf("got error %v", err)
+				}
+				return
+			}
+			var entryErr *EntryError
+			if !errors.As(err, &entryErr) || !strings.Contains(err.Error(), "disallowed scheme") {
+				t.Errorf("got error %v, want a disallowed scheme *EntryError", err)
+			}
+		})
+	}
+}
+
+func TestAllowedSchemesPerRequest(t *testing.T) {
+	funcs := map[string]func(r *http.Request) (string, error){
+		"/js":   func(*http.Request) (string, error) { return "javascript:alert(1)", nil },
+		"/data": func(*http.Request) (string, error) { return "data:text/html,hi", nil },
+		"/ok":   func(*http.Request) (string, error) { return "https://ok.example.com", nil },
+	}
+	for _, tt := range []struct {
+		name   string
+		forbid bool
+		want   int
+	}{
+		{"forbid", true, http.StatusForbidden},
+		{"fallback", false, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := FuncHandler(funcs, notFound, WithAllowedSchemes(tt.forbid, "https"))
+			for _, path := range []string{"/js", "/data"} {
+				w := serve(h, path)
+				wantStatus(t, w, tt.want)
+				if loc := w.Header().Get("Location"); loc != "" {
+					t.Errorf("%s: got Location %q", path, loc)
+				}
+			}
+			wantRedirect(t, serve(h, "/ok"), http.StatusMovedPermanently, "https://ok.example.com")
+		})
+	}
+}
+
+func TestAllowedSchemesUnset(t *testing.T) {
+	h := MapHandler(map[string]string{"/a": "http://a.example.com"}, notFound)
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "http://a.example.com")
+}
@@ -0,0 +1,57 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSchemeRelative is wrapped by the error reporting a scheme-relative
+// destination rejected by WithSchemeRelative.
+var ErrSchemeRelative = errors.New("scheme-relative destination")
+
+// WithSchemeRelative sets what the handler does with scheme-relative
+// destinations, such as "//cdn.example.com/x", which clients resolve
+// with the scheme they reached the handler with. A client that reached
+// it over plain HTTP is then sent on over plain HTTP, even to a
+// destination that supports HTTPS: a protocol downgrade leaving the
+// rest of its navigation open to eavesdropping and tampering, which
+// an absolute https destination avoids.
+//
+// With a scheme, such as "https", scheme-relative destinations are
+// made absolute with it before they are sent. With an empty scheme,
+// they are rejected: requests for them are answered as for other
+// destinations that cannot be determined, with the fallback or the
+// handler of WithErrorHandler, given an error wrapping
+// ErrSchemeRelative.
+//
+// Destinations starting with a slash and a backslash, or two
+// backslashes, count as scheme-relative, as browsers read them so.
+// The check runs on the final destination, after the rewriters of
+// WithDestinationRewriter. Without this option, scheme-relative
+// destinations are sent as they are.
+func WithSchemeRelative(scheme string) Option {
+	return func(c *config) {
+		c.schemeRelative = true
+		c.schemeRelativeScheme = strings.ToLower(scheme)
+	}
+}
+
+// resolveSchemeRelative returns dest made absolute if it is
+// scheme-relative, or an error if such destinations are rejected.
+func (c *config) resolveSchemeRelative(dest string) (string, error) {
+	if !c.schemeRelative || !isSchemeRelative(dest) {
+		return dest, nil
+	}
+	if c.schemeRelativeScheme == "" {
+		return "", fmt.Errorf("%w %q", ErrSchemeRelative, dest)
+	}
+	return c.schemeRelativeScheme + "://" + dest[2:], nil
+}
+
+// isSchemeRelative reports whether dest is a scheme-relative URL, as
+// read by browsers, which take backslashes for slashes.
+func isSchemeRelative(dest string) bool {
+	isSlash := func(b byte) bool { return b == '/' || b == '\\' }
+	return len(dest) >= 2 && isSlash(dest[0]) && isSlash(dest[1])
+}
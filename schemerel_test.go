@@ -0,0 +1,73 @@
+package urlshort
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSchemeRelative(t *testing.T) {
+	urls := map[string]string{
+		"/cdn":       "//cdn.example.com/x",
+		"/backslash": `\\cdn.example.com/y`,
+		"/mixed":     `/\cdn.example.com/z`,
+		"/abs":       "http://example.com",
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		target string
+		status int
+		loc    string
+	}{
+		{"kept by default", nil, "/cdn", http.StatusMovedPermanently, "//cdn.example.com/x"},
+		{"resolved", []Option{WithSchemeRelative("https")}, "/cdn", http.StatusMovedPermanently, "https://cdn.example.com/x"},
+		{"scheme lower-cased", []Option{WithSchemeRelative("HTTPS")}, "/cdn", http.StatusMovedPermanently, "https://cdn.example.com/x"},
+		{"backslashes", []Option{WithSchemeRelative("https")}, "/backslash", http.StatusMovedPermanently, "https://cdn.example.com/y"},
+		{"slash and backslash", []Option{WithSchemeRelative("https")}, "/mixed", http.StatusMovedPermanently, "https://cdn.example.com/z"},
+		{"absolute untouched", []Option{WithSchemeRelative("https")}, "/abs", http.StatusMovedPermanently, "http://example.com"},
+		{"absolute untouched strict", []Option{WithSchemeRelative("")}, "/abs", http.StatusMovedPermanently, "http://example.com"},
+		{"rejected", []Option{WithSchemeRelative("")}, "/cdn", http.StatusNotFound, ""},
+		{"backslashes rejected", []Option{WithSchemeRelative("")}, "/backslash", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := MapHandler(urls, notFound, tt.opts...)
+			w := serve(h, tt.target)
+			if tt.loc == "" {
+				wantStatus(t, w, tt.status)
+				if loc := w.Header().Get("Location"); loc != "" {
+					t.Errorf("got Location %q, want none", loc)
+				}
+				return
+			}
+			wantRedirect(t, w, tt.status, tt.loc)
+		})
+	}
+}
+
+func TestSchemeRelativeErrorHandler(t *testing.T) {
+	var got error
+	h := MapHandler(map[string]string{"/cdn": "//cdn.example.com/x"}, notFound,
+		WithSchemeRelative(""),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			got = err
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+	wantStatus(t, serve(h, "/cdn"), http.StatusBadGateway)
+	if !errors.Is(got, ErrSchemeRelative) {
+		t.Errorf("got error %v, want one wrapping ErrSchemeRelative", got)
+	}
+}
+
+func TestSchemeRelativeAfterRewriters(t *testing.T) {
+	strip := func(dest string) string { return strings.TrimPrefix(dest, "https:") }
+	h := MapHandler(map[string]string{"/cdn": "https://cdn.example.com/x"}, notFound,
+		WithDestinationRewriter(strip), WithSchemeRelative("https"))
+	wantRedirect(t, serve(h, "/cdn"), http.StatusMovedPermanently, "https://cdn.example.com/x")
+
+	h = MapHandler(map[string]string{"/cdn": "https://cdn.example.com/x"}, notFound,
+		WithDestinationRewriter(strip), WithSchemeRelative(""))
+	wantStatus(t, serve(h, "/cdn"), http.StatusNotFound)
+}
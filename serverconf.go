@@ -0,0 +1,176 @@
+package urlshort
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ExportNginx translates entries into nginx configuration, for
+// migrating the redirects to nginx. Every entry becomes an exact-match
+// location block, to include in a server block:
+//
+//	location = "/some-path" {
+//	    return 301 "https://www.some-url.com/demo";
+//	}
+//
+// Gone entries return 410 instead. Redirects are sent with the status
+// of their entry, if any, or else with status, or with 301 if status
+// is 0, or with its temporary counterpart for temporary entries as by
+// a handler (see MappingEntry), and the query of the request is
+// dropped, as by a handler without WithQueryForwarding. Entries are
+// written sorted by path, and for a path defined by several entries
+// only the last one is written, as it is the one a handler redirects
+// with. Disabled entries are left out, as a handler passes their
+// requests to the fallback.
+//
+// Entries that nginx cannot express are an error: entries without a
+// URL, with a Schedule or Variants, whose path or URL contains control
+// characters, and whose URL contains a "$", which nginx would read as
+// a variable. The error joins one *EntryError per such entry. Backup
+// and Notes are ignored.
+func ExportNginx(entries []MappingEntry, status int) ([]byte, error) {
+	return exportServerConf(entries, status, func(buf *bytes.Buffer, entry MappingEntry, status int) error {
+		if strings.Contains(entry.URL, "$") {
+			return &EntryError{Path: entry.Path, Problem: `url contains "$", which nginx cannot express`}
+		}
+		fmt.Fprintf(buf, "location = %s {\n", nginxQuote(entry.Path))
+		if entry.Gone {
+			fmt.Fprintf(buf, "    return %d;\n", http.StatusGone)
+		} else {
+			fmt.Fprintf(buf, "    return %d %s;\n", status, nginxQuote(escapeConfURL(entry.URL)))
+		}
+		buf.WriteString("}\n")
+		return nil
+	})
+}
+
+// ExportApache translates entries into Apache httpd configuration of
+// mod_alias, for migrating the redirects to Apache. Every entry
+// becomes a RedirectMatch directive anchored on its whole path, to
+// include in a server or virtual host context:
+//
+//	RedirectMatch 301 "^/some-path$" "https://www.some-url.com/demo"
+//
+// Gone entries become "RedirectMatch gone" directives instead. Entries
+// are written as by ExportNginx, and the same entries are an error,
+// except that a "$" in a URL is escaped rather than rejected.
+func ExportApache(entries []MappingEntry, status int) ([]byte, error) {
+	return exportServerConf(entries, status, func(buf *bytes.Buffer, entry MappingEntry, status int) error {
+		pattern := `"^` + apacheRegexp(entry.Path) + `$"`
+		if entry.Gone {
+			fmt.Fprintf(buf, "RedirectMatch gone %s\n", pattern)
+			return nil
+		}
+		url := strings.NewReplacer(`$`, `\$`, `&`, `\&`).Replace(escapeConfURL(entry.URL))
+		fmt.Fprintf(buf, "RedirectMatch %d %s \"%s\"\n", status, pattern, url)
+		return nil
+	})
+}
+
+// exportServerConf writes the entries ExportNginx and ExportApache
+// export with write, after checking them and status.
+func exportServerConf(entries []MappingEntry, status int, write func(buf *bytes.Buffer, entry MappingEntry, status int) error) ([]byte, error) {
+	if status == 0 {
+		status = http.StatusMovedPermanently
+	} else if !isRedirectStatus(status) {
+		return nil, fmt.Errorf("invalid redirect status code %d", status)
+	}
+
+	var buf bytes.Buffer
+	var errs []error
+	for _, entry := range lastByPath(sortByPath(expandPaths(entries))) {
+		if entry.disabled() {
+			continue
+		}
+		if err := checkConfEntry(entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		code := entry.Status
+		if code == 0 {
+			code = redirectStatusOf(status, entry.Temporary)
+		}
+		if err := write(&buf, entry, code); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// lastByPath returns the entries sorted by path, keeping only the
+// last entry of every path.
+func lastByPath(sorted []MappingEntry) []MappingEntry {
+	var last []MappingEntry
+	for i, entry := range sorted {
+		if i+1 < len(sorted) && sorted[i+1].Path == entry.Path {
+			continue
+		}
+		last = append(last, entry)
+	}
+	return last
+}
+
+// checkConfEntry reports whether entry can be expressed in the
+// configuration of a web server.
+func checkConfEntry(entry MappingEntry) error {
+	switch {
+	case len(entry.Schedule) > 0:
+		return &EntryError{Path: entry.Path, Problem: "schedule cannot be exported"}
+	case len(entry.Variants) > 0:
+		return &EntryError{Path: entry.Path, Problem: "variants cannot be exported"}
+	case entry.MaxHits != 0:
+		return &EntryError{Path: entry.Path, Problem: "maxHits cannot be exported"}
+	case entry.Expires != nil:
+		return &EntryError{Path: entry.Path, Problem: "expires cannot be exported"}
+	case !entry.Gone && entry.URL == "":
+		return &EntryError{Path: entry.Path, Problem: "missing url"}
+	case strings.ContainsFunc(entry.Path+entry.URL, isControl):
+		return &EntryError{Path: entry.Path, Problem: "control characters cannot be exported"}
+	}
+	return nil
+}
+
+// isControl reports whether r is an ASCII control character.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// escapeConfURL percent-encodes the characters of url that cannot
+// appear in a URL but would otherwise need quoting in configuration.
+func escapeConfURL(url string) string {
+	return strings.NewReplacer(`"`, "%22", `\`, "%5C", " ", "%20").Replace(url)
+}
+
+// nginxQuote returns s as a double-quoted nginx string.
+func nginxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// apacheRegexp returns a regular expression matching path literally,
+// without double quotes or backslashes other than those escaping a
+// metacharacter, so that it can be double-quoted in Apache
+// configuration as it is.
+func apacheRegexp(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch {
+		case r == '"':
+			b.WriteString(`\x22`)
+		case r == '\\':
+			b.WriteString(`\x5c`)
+		case strings.ContainsRune(`.+*?()|[]{}^$`, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
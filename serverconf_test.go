@@ -0,0 +1,111 @@
+package urlshort
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// serverConfEntries covers the edge cases of ExportNginx and
+// ExportApache that they can express.
+var serverConfEntries = []MappingEntry{
+	{Path: "/plain", URL: "https://example.com/page"},
+	{Path: "/query", URL: "https://example.com/search?q=go&lang=en"},
+	{Path: "/temporary", URL: "https://example.com/tmp", Temporary: true},
+	{Path: "/see-other", URL: "https://example.com/other", Status: http.StatusSeeOther},
+	{Path: "/old", Gone: true},
+	{Path: "/regexp.(a|b)+[x]{2}^$?*", URL: "https://example.com/regexp"},
+	{Path: `/quote"back\slash`, URL: `https://example.com/"quoted" \path`},
+	{Path: "/space%20d", URL: "https://example.com/a b"},
+	{Paths: []string{"/many/1", "/many/2"}, URL: "https://example.com/many"},
+	{Path: "/dup", URL: "https://example.com/first"},
+	{Path: "/dup", URL: "https://example.com/last"},
+	{Path: "/disabled", URL: "https://example.com/disabled", Enabled: new(bool)},
+	{Path: "/backup", URL: "https://example.com/main", Backup: "https://example.com/backup", Notes: map[string]string{"owner": "web"}},
+}
+
+func TestServerConfGolden(t *testing.T) {
+	for _, tt := range []struct {
+		golden string
+		export func([]MappingEntry, int) ([]byte, error)
+		status int
+	}{
+		{"nginx.conf", ExportNginx, 0},
+		{"nginx-307.conf", ExportNginx, http.StatusTemporaryRedirect},
+		{"apache.conf", ExportApache, 0},
+		{"apache-308.conf", ExportApache, http.StatusPermanentRedirect},
+	} {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := tt.export(serverConfEntries, tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestServerConfDollar(t *testing.T) {
+	entries := []MappingEntry{{Path: "/price", URL: "https://example.com/$5&more"}}
+	if _, err := ExportNginx(entries, 0); err == nil {
+		t.Error("ExportNginx accepted a URL with a $")
+	}
+	got, err := ExportApache(entries, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `RedirectMatch 301 "^/price$" "https://example.com/\$5\&more"` + "\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestServerConfInvalid(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []MappingEntry{
+		{Path: "/ok", URL: "https://example.com/ok"},
+		{Path: "/schedule", URL: "https://example.com", Schedule: []ScheduleRule{{From: "09:00", To: "17:00", URL: "https://example.com/day"}}},
+		{Path: "/variants", Variants: []Variant{{URL: "https://example.com/a", Weight: 1}}},
+		{Path: "/hits", URL: "https://example.com", MaxHits: 10},
+		{Path: "/expires", URL: "https://example.com", Expires: &expires},
+		{Path: "/nourl"},
+		{Path: "/control\n", URL: "https://example.com"},
+	}
+	for name, export := range map[string]func([]MappingEntry, int) ([]byte, error){
+		"nginx":  ExportNginx,
+		"apache": ExportApache,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := export(entries, 0)
+			var entryErr *EntryError
+			if !errors.As(err, &entryErr) {
+				t.Fatalf("got error %v, want an *EntryError", err)
+			}
+			if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 6 {
+				t.Errorf("got %d errors, want 6: %v", n, err)
+			}
+			if _, err := export(entries[:1], http.StatusOK); err == nil {
+				t.Error("accepted status 200")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package urlshort
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MapFromRows reads a mapping of paths to URLs, suitable for
+// MapHandler, from rows, whose first column holds the paths and second
+// column the URLs, whatever their names, so that it can be loaded
+// with any query:
+//
+//	rows, err := db.QueryContext(ctx, "SELECT slug, target FROM links WHERE active")
+//	...
+//	pathsToUrls, err := urlshort.MapFromRows(rows)
+//
+// Rows with the path of an earlier row override it. rows is closed
+// when MapFromRows returns. Result sets with other than two columns,
+// NULL values and the errors of scanning and iterating the rows are
+// an error.
+func MapFromRows(rows *sql.Rows) (map[string]string, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) != 2 {
+		return nil, fmt.Errorf("mapping rows: got %d columns, want 2 (path and url)", len(columns))
+	}
+
+	m := make(map[string]string)
+	for n := 1; rows.Next(); n++ {
+		var path, url sql.NullString
+		if err := rows.Scan(&path, &url); err != nil {
+			return nil, fmt.Errorf("mapping rows: row %d: %w", n, err)
+		}
+		if !path.Valid || !url.Valid {
+			return nil, fmt.Errorf("mapping rows: row %d: NULL path or url", n)
+		}
+		m[path.String] = url.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("mapping rows: %w", err)
+	}
+	return m, nil
+}
+
+// MapFromQuery runs query with args on db and reads its result with
+// MapFromRows.
+func MapFromQuery(ctx context.Context, db *sql.DB, query string, args ...any) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return MapFromRows(rows)
+}
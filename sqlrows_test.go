@@ -0,0 +1,197 @@
+package urlshort
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeRows is a result set of driver values, failing with err once
+// they run out, if err is not nil.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+	closed  bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakeConn is a database driver connection answering every query with
+// query, so that MapFromRows and MapFromQuery can be tested without a
+// database.
+type fakeConn struct {
+	query func(query string, args []driver.NamedValue) (driver.Rows, error)
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.query(query, args)
+}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fake database: statements not supported")
+}
+
+func (fakeConn) Close() error { return nil }
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake database: transactions not supported")
+}
+
+func (c fakeConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c fakeConn) Open(name string) (driver.Conn, error)        { return c, nil }
+func (c fakeConn) Driver() driver.Driver                        { return c }
+
+// fakeDB returns a database answering every query with query.
+func fakeDB(t *testing.T, query func(query string, args []driver.NamedValue) (driver.Rows, error)) *sql.DB {
+	db := sql.OpenDB(fakeConn{query})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMapFromRows(t *testing.T) {
+	errRow := errors.New("connection reset")
+	tests := []struct {
+		name string
+		rows *fakeRows
+		want map[string]string
+		err  string
+	}{
+		{
+			name: "ok",
+			rows: &fakeRows{
+				columns: []string{"slug", "target"},
+				values: [][]driver.Value{
+					{"/a", "https://a.example.com"},
+					{"/b", "https://b.example.com"},
+					{"/a", "https://a2.example.com"},
+				},
+			},
+			want: map[string]string{"/a": "https://a2.example.com", "/b": "https://b.example.com"},
+		},
+		{
+			name: "empty",
+			rows: &fakeRows{columns: []string{"slug", "target"}},
+			want: map[string]string{},
+		},
+		{
+			name: "one column",
+			rows: &fakeRows{columns: []string{"slug"}, values: [][]driver.Value{{"/a"}}},
+			err:  "got 1 columns, want 2",
+		},
+		{
+			name: "three columns",
+			rows: &fakeRows{
+				columns: []string{"slug", "target", "owner"},
+				values:  [][]driver.Value{{"/a", "https://a.example.com", "qa"}},
+			},
+			err: "got 3 columns, want 2",
+		},
+		{
+			name: "NULL path",
+			rows: &fakeRows{
+				columns: []string{"slug", "target"},
+				values: [][]driver.Value{
+					{"/a", "https://a.example.com"},
+					{nil, "https://b.example.com"},
+				},
+			},
+			err: "row 2: NULL path or url",
+		},
+		{
+			name: "NULL url",
+			rows: &fakeRows{columns: []string{"slug", "target"}, values: [][]driver.Value{{"/a", nil}}},
+			err:  "row 1: NULL path or url",
+		},
+		{
+			name: "scan error",
+			rows: &fakeRows{columns: []string{"slug", "target"}, values: [][]driver.Value{{"/a", struct{}{}}}},
+			err:  "mapping rows: row 1: ",
+		},
+		{
+			name: "iteration error",
+			rows: &fakeRows{
+				columns: []string{"slug", "target"},
+				values:  [][]driver.Value{{"/a", "https://a.example.com"}},
+				err:     errRow,
+			},
+			err: errRow.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := fakeDB(t, func(string, []driver.NamedValue) (driver.Rows, error) {
+				return tt.rows, nil
+			})
+			rows, err := db.Query("SELECT slug, target FROM links")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := MapFromRows(rows)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got error %v, want one containing %q", err, tt.err)
+				}
+			} else if err != nil || !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+			if !tt.rows.closed {
+				t.Error("rows were not closed")
+			}
+		})
+	}
+}
+
+func TestMapFromQuery(t *testing.T) {
+	errQuery := errors.New("no such table")
+	var gotQuery string
+	var gotArgs []driver.Value
+	db := fakeDB(t, func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		gotQuery, gotArgs = query, nil
+		for _, arg := range args {
+			gotArgs = append(gotArgs, arg.Value)
+		}
+		if len(args) == 0 {
+			return nil, errQuery
+		}
+		return &fakeRows{
+			columns: []string{"slug", "target"},
+			values:  [][]driver.Value{{"/a", "https://a.example.com"}},
+		}, nil
+	})
+
+	const query = "SELECT slug, target FROM links WHERE owner = ?"
+	got, err := MapFromQuery(context.Background(), db, query, "qa")
+	if err != nil || got["/a"] != "https://a.example.com" {
+		t.Errorf("got %v, %v", got, err)
+	}
+	if gotQuery != query || !slices.Equal(gotArgs, []driver.Value{"qa"}) {
+		t.Errorf("got query %q with %v, want %q with [qa]", gotQuery, gotArgs, query)
+	}
+	if _, err := MapFromQuery(context.Background(), db, "SELECT slug, target FROM links"); !errors.Is(err, errQuery) {
+		t.Errorf("got error %v, want %v", err, errQuery)
+	}
+}
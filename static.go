@@ -0,0 +1,49 @@
+package urlshort
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// StaticFallback returns an http.Handler meant to be used as the
+// fallback of the other handlers, so that a single handler can both
+// redirect short links and serve a small static site. Requests for
+// which isAsset reports true, such as those selected by
+// AssetExtensions, are served from root by http.FileServer, for
+// example with root http.Dir("public") or http.FS of an embed.FS.
+// Other requests are passed to notFound, or answered with a plain 404
+// if it is nil.
+//
+// http.FileServer cleans the request path before opening it, so
+// requests cannot escape root with ".." segments. http.Dir does
+// follow symbolic links, including those pointing outside of the
+// directory, and serves files whose names start with a dot, so root
+// should only hold files meant to be public.
+func StaticFallback(root http.FileSystem, isAsset func(r *http.Request) bool, notFound http.Handler) http.Handler {
+	if notFound == nil {
+		notFound = http.NotFoundHandler()
+	}
+	files := http.FileServer(root)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAsset(r) {
+			files.ServeHTTP(w, r)
+			return
+		}
+		notFound.ServeHTTP(w, r)
+	})
+}
+
+// AssetExtensions returns a predicate for StaticFallback reporting
+// whether the extension of the request path is one of exts, such as
+// ".css", ".js" and ".png", compared case-insensitively.
+func AssetExtensions(exts ...string) func(r *http.Request) bool {
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		set[strings.ToLower(ext)] = true
+	}
+	return func(r *http.Request) bool {
+		return set[strings.ToLower(path.Ext(r.URL.Path))]
+	}
+}
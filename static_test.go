@@ -0,0 +1,53 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFallback(t *testing.T) {
+	root := http.FS(fstest.MapFS{
+		"style.css":     {Data: []byte("body {}")},
+		"img/Logo.PNG":  {Data: []byte("png")},
+		"notes.txt":     {Data: []byte("not an asset")},
+		"secret/a.yaml": {Data: []byte("- path: /a")},
+	})
+	gone := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	fallback := StaticFallback(root, AssetExtensions(".css", ".png"), gone)
+	h := MapHandler(map[string]string{"/a": "https://a.example.com"}, fallback)
+
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	w := serve(h, "/style.css")
+	wantStatus(t, w, http.StatusOK)
+	if got := w.Body.String(); got != "body {}" {
+		t.Errorf("got body %q, want the asset", got)
+	}
+	wantStatus(t, serve(h, "/img/Logo.PNG"), http.StatusOK)
+	wantStatus(t, serve(h, "/missing.css"), http.StatusNotFound)
+	wantStatus(t, serve(h, "/notes.txt"), http.StatusGone)
+	wantStatus(t, serve(h, "/b"), http.StatusGone)
+	wantStatus(t, serve(h, "/../secret/x.css"), http.StatusNotFound)
+
+	wantStatus(t, serve(StaticFallback(root, AssetExtensions(".css"), nil), "/b"), http.StatusNotFound)
+}
+
+func TestAssetExtensions(t *testing.T) {
+	isAsset := AssetExtensions(".CSS", ".js")
+	for target, want := range map[string]bool{
+		"/a.css":        true,
+		"/a.Css":        true,
+		"/dir/a.js":     true,
+		"/a.json":       false,
+		"/css":          false,
+		"/a.css/":       false,
+		"/a.js?x=1.png": true,
+	} {
+		r, _ := http.NewRequest(http.MethodGet, target, nil)
+		if got := isAsset(r); got != want {
+			t.Errorf("got %v for %s, want %v", got, target, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithStatus sets the status code of the redirects the handler sends,
+// which is http.StatusMovedPermanently (301) by default. code must be
+// one of:
+//
+//   - http.StatusMovedPermanently (301) and http.StatusFound (302),
+//     which clients may follow with a GET whatever the method of the
+//     original request, dropping its body;
+//   - http.StatusSeeOther (303), which clients always follow with a
+//     GET;
+//   - http.StatusTemporaryRedirect (307) and
+//     http.StatusPermanentRedirect (308), which clients follow with the
+//     method and body of the original request, so that a POST to a
+//     mapped path is re-sent as a POST to its destination.
+//
+// 301 and 308 are permanent and may be cached by clients, while the
+// others are not. WithStatus panics if code is not a redirect status
+// code from the list above.
+func WithStatus(code int) Option {
+	if !isRedirectStatus(code) {
+		panic(fmt.Sprintf("urlshort: invalid redirect status code %d", code))
+	}
+	return func(c *config) {
+		c.status = code
+	}
+}
+
+// isRedirectStatus reports whether code is one of the redirect status
+// codes accepted by WithStatus.
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// WithPreservedBody makes the handler redirect requests for the given
+// mapping keys with a status code that clients must follow with the
+// method and body of the original request, whatever the method, for
+// paths standing in for API endpoints that are sent POST and PUT
+// requests. The status code of the handler (see WithStatus) is
+// replaced by its body-preserving counterpart: 301 by 308, and 302
+// and 303 by 307, while temporary entries get 307. The handler never
+// reads the body of the requests it redirects, leaving it to the
+// client to send it again to the destination.
+//
+// Keys are matched as written in the mapping, or regardless of case
+// with WithCaseInsensitive. The option may be given several times.
+func WithPreservedBody(paths ...string) Option {
+	return func(c *config) {
+		if c.preservedBody == nil {
+			c.preservedBody = make(map[string]bool)
+		}
+		for _, path := range paths {
+			c.preservedBody[path] = true
+		}
+	}
+}
+
+// entryStatus returns the status code of the redirects of entry,
+// mapped by key, which is its own status if it has one, or else that
+// of the handler or its temporary counterpart if entry is temporary,
+// or their body-preserving counterpart if key is set to preserve the
+// body.
+func (c *config) entryStatus(key string, entry MappingEntry) int {
+	code := entry.Status
+	if code == 0 {
+		code = redirectStatusOf(c.redirectStatus(), entry.Temporary)
+	}
+	if c.preservesBody(key) {
+		code = bodyPreservingStatus(code)
+	}
+	return code
+}
+
+// preservesBody reports whether the redirects of key preserve the
+// body of requests.
+func (c *config) preservesBody(key string) bool {
+	if c.preservedBody[key] || !c.caseInsensitive {
+		return c.preservedBody[key]
+	}
+	for path := range c.preservedBody {
+		if strings.EqualFold(path, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyPreservingStatus returns the counterpart of the redirect status
+// code that preserves the method and body of requests.
+func bodyPreservingStatus(code int) int {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		return http.StatusPermanentRedirect
+	}
+	return http.StatusTemporaryRedirect
+}
+
+// redirectStatusOf returns code, or its temporary counterpart if
+// temporary is true.
+func redirectStatusOf(code int, temporary bool) int {
+	if !temporary {
+		return code
+	}
+	switch code {
+	case http.StatusMovedPermanently:
+		return http.StatusFound
+	case http.StatusPermanentRedirect:
+		return http.StatusTemporaryRedirect
+	}
+	return code
+}
+
+// redirectStatus returns the configured status code of redirects.
+func (c *config) redirectStatus() int {
+	if c.status == 0 {
+		return http.StatusMovedPermanently
+	}
+	return c.status
+}
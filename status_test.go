@@ -0,0 +1,193 @@
+package urlshort
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithStatus(t *testing.T) {
+	yml := []byte(`
+- path: /a
+  url: https://a.example.com
+- path: /tmp
+  url: https://tmp.example.com
+  temporary: true
+- path: /own
+  url: https://own.example.com
+  status: 303
+`)
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		path string
+		want int
+	}{
+		{"default", nil, "/a", http.StatusMovedPermanently},
+		{"default temporary", nil, "/tmp", http.StatusFound},
+		{"308", []Option{WithStatus(http.StatusPermanentRedirect)}, "/a", http.StatusPermanentRedirect},
+		{"308 temporary", []Option{WithStatus(http.StatusPermanentRedirect)}, "/tmp", http.StatusTemporaryRedirect},
+		{"303", []Option{WithStatus(http.StatusSeeOther)}, "/a", http.StatusSeeOther},
+		{"entry status", []Option{WithStatus(http.StatusPermanentRedirect)}, "/own", http.StatusSeeOther},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := YAMLHandler(yml, notFound, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantStatus(t, serve(h, tt.path), tt.want)
+		})
+	}
+}
+
+// TestWithStatusPreservesMethod follows a 308 redirect of a POST with
+// an http.Client, which must re-send the request with its method and
+// body to the destination.
+func TestWithStatusPreservesMethod(t *testing.T) {
+	var gotMethod, gotBody string
+	dest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotBody = r.Method, string(body)
+	}))
+	defer dest.Close()
+
+	h := MapHandler(map[string]string{"/api": dest.URL + "/api"}, notFound,
+		WithStatus(http.StatusPermanentRedirect))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api", strings.NewReader("x=1")))
+	wantRedirect(t, w, http.StatusPermanentRedirect, dest.URL+"/api")
+
+	resp, err := srv.Client().Post(srv.URL+"/api", "application/x-www-form-urlencoded", strings.NewReader("x=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if gotMethod != http.MethodPost || gotBody != "x=1" {
+		t.Errorf("destination got %s with body %q, want POST with body %q", gotMethod, gotBody, "x=1")
+	}
+}
+
+func TestWithStatusInvalid(t *testing.T) {
+	for _, code := range []int{http.StatusOK, http.StatusNotModified, http.StatusNotFound} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WithStatus(%d) did not panic", code)
+				}
+			}()
+			WithStatus(code)
+		}()
+	}
+}
+
+func TestTemporaryEntries(t *testing.T) {
+	yml := []byte(`
+- path: /sale
+  url: https://shop.example.com/sale
+  temporary: true
+- path: /about
+  url: https://example.com/about
+`)
+	jsn := []byte(`[
+  {"path": "/sale", "url": "https://shop.example.com/sale", "temporary": true},
+  {"path": "/about", "url": "https://example.com/about"}
+]`)
+	for _, tt := range []struct {
+		name  string
+		build func() (http.HandlerFunc, error)
+	}{
+		{"yaml", func() (http.HandlerFunc, error) { return YAMLHandler(yml, notFound) }},
+		{"json", func() (http.HandlerFunc, error) { return JSONHandler(jsn, notFound) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRedirect(t, serve(h, "/sale"), http.StatusFound, "https://shop.example.com/sale")
+			wantRedirect(t, serve(h, "/about"), http.StatusMovedPermanently, "https://example.com/about")
+		})
+	}
+}
+
+func TestTemporaryWithStatus(t *testing.T) {
+	_, err := ParseYAML([]byte(`
+- path: /sale
+  url: https://shop.example.com/sale
+  temporary: true
+  status: 301
+`))
+	if err == nil {
+		t.Error("accepted an entry setting both temporary and status")
+	}
+}
+
+// untouchedBody is a request body recording whether it was read or
+// closed.
+type untouchedBody struct {
+	read, closed bool
+}
+
+func (b *untouchedBody) Read([]byte) (int, error) {
+	b.read = true
+	return 0, io.EOF
+}
+
+func (b *untouchedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestWithPreservedBody(t *testing.T) {
+	yml := []byte(`
+- path: /api
+  url: https://api.example.com/v1
+- path: /api-tmp
+  url: https://api.example.com/tmp
+  temporary: true
+- path: /Upload
+  url: https://upload.example.com
+- path: /page
+  url: https://example.com/page
+`)
+	for _, tt := range []struct {
+		name   string
+		opts   []Option
+		target string
+		want   int
+	}{
+		{"permanent", nil, "/api", http.StatusPermanentRedirect},
+		{"temporary", nil, "/api-tmp", http.StatusTemporaryRedirect},
+		{"302", []Option{WithStatus(http.StatusFound)}, "/api", http.StatusTemporaryRedirect},
+		{"303", []Option{WithStatus(http.StatusSeeOther)}, "/api", http.StatusTemporaryRedirect},
+		{"other path", nil, "/page", http.StatusMovedPermanently},
+		{"case-insensitive", []Option{WithCaseInsensitive()}, "/upload", http.StatusPermanentRedirect},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithPreservedBody("/api", "/api-tmp"), WithPreservedBody("/upload")}, tt.opts...)
+			h, err := YAMLHandler(yml, notFound, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := &untouchedBody{}
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			r.Body = body
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			wantStatus(t, w, tt.want)
+			if w.Header().Get("Location") == "" {
+				t.Error("got no Location")
+			}
+			if body.read || body.closed {
+				t.Errorf("handler read (%v) or closed (%v) the request body", body.read, body.closed)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package urlshort
+
+import (
+	"context"
+	"net/http"
+)
+
+// Store is a source of redirects kept outside the process, such as a
+// database or a cache server, that is queried for every request.
+type Store interface {
+	// Get returns the URL path is mapped to. A non-nil error means the
+	// store could not be queried, which is distinct from path not
+	// being mapped.
+	Get(ctx context.Context, path string) (url string, ok bool, err error)
+}
+
+// StoreHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map any paths to
+// their corresponding URL in store, queried with the context of the
+// request. If the path is not provided in the store, then the
+// fallback http.Handler will be called instead.
+//
+// If store returns an error, the request is answered with a plain
+// text 502 Bad Gateway rather than passed to the fallback, so that a
+// broken backend is not mistaken for a missing redirect. This can be
+// changed with WithErrorHandler.
+//
+// See MapHandler for the meaning of opts, and WithCoalescedLookups
+// for sharing the queries of concurrent requests for a path.
+func StoreHandler(store Store, fallback http.Handler, opts ...Option) http.HandlerFunc {
+	cfg := newConfig(opts)
+	if cfg.errorHandler == nil {
+		cfg.errorHandler = ErrorStatus(http.StatusBadGateway)
+	}
+	lookup := storeLookup(store)
+	if cfg.coalesceLookups {
+		lookup = coalescedLookup(store)
+	}
+	return newHandler(lookup, fallback, cfg).ServeHTTP
+}
+
+// MappingsStore returns a Store looking paths up in m, which never
+// fails, so that a StoreHandler can be backed by an in-memory Map or
+// DynamicHandler, for example in tests or while migrating between
+// backends.
+func MappingsStore(m Mappings) Store {
+	return StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		url, ok := m.Lookup(path)
+		return url, ok, nil
+	})
+}
+
+// storeLookup returns a lookupFunc querying store.
+func storeLookup(store Store) lookupFunc {
+	return func(r *http.Request, path string) (MappingEntry, bool, error) {
+		url, ok, err := store.Get(r.Context(), path)
+		if err != nil || !ok {
+			return MappingEntry{}, false, err
+		}
+		return MappingEntry{Path: path, URL: url}, true, nil
+	}
+}
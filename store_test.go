@@ -0,0 +1,67 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeStore is a Store mapping /a, failing for /down.
+var fakeStore = StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+	switch path {
+	case "/a":
+		return "https://a.example.com", true, nil
+	case "/down":
+		return "", false, errors.New("connection refused")
+	}
+	return "", false, nil
+})
+
+func TestStoreHandler(t *testing.T) {
+	h := StoreHandler(fakeStore, notFound)
+
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+
+	w := serve(h, "/down")
+	wantStatus(t, w, http.StatusBadGateway)
+	if got := w.Body.String(); got != "Bad Gateway\n" {
+		t.Errorf("got body %q, want the plain status text", got)
+	}
+}
+
+func TestStoreHandlerErrorHandler(t *testing.T) {
+	var got error
+	h := StoreHandler(fakeStore, notFound, WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	wantStatus(t, serve(h, "/down"), http.StatusServiceUnavailable)
+	if got == nil || got.Error() != "connection refused" {
+		t.Errorf("got error %v, want the one of the store", got)
+	}
+	wantStatus(t, serve(h, "/missing"), http.StatusNotFound)
+}
+
+func TestStoreHandlerContext(t *testing.T) {
+	type key struct{}
+	var got any
+	store := StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		got = ctx.Value(key{})
+		return "", false, nil
+	})
+	r := httptest.NewRequest(http.MethodGet, "/a", nil)
+	r = r.WithContext(context.WithValue(r.Context(), key{}, "request"))
+	StoreHandler(store, notFound).ServeHTTP(httptest.NewRecorder(), r)
+	if got != "request" {
+		t.Errorf("store got context value %v, want the one of the request", got)
+	}
+}
+
+func TestMappingsStore(t *testing.T) {
+	h := StoreHandler(MappingsStore(Map{"/a": "https://a.example.com"}), notFound)
+	wantRedirect(t, serve(h, "/a"), http.StatusMovedPermanently, "https://a.example.com")
+	wantStatus(t, serve(h, "/b"), http.StatusNotFound)
+}
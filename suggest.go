@@ -0,0 +1,98 @@
+package urlshort
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+)
+
+// SuggestingFallback returns an http.Handler meant to be used as the
+// fallback of the other handlers while debugging broken links. It
+// answers every request with a 404 whose plain text body lists up to
+// maxSuggestions paths of entries that are close to the requested
+// path, nearest first.
+//
+// A path is close if its Levenshtein distance to the requested path
+// is at most a third of the length of the requested path, and at
+// least 2. Paths at the same distance are listed in alphabetical
+// order.
+func SuggestingFallback(entries []MappingEntry, maxSuggestions int) http.Handler {
+	paths := make([]string, 0, len(entries))
+	for _, entry := range expandPaths(entries) {
+		paths = append(paths, entry.Path)
+	}
+	sort.Strings(paths)
+	paths = slices.Compact(paths)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suggestions := suggest(paths, r.URL.Path, maxSuggestions)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, "404 page not found")
+		if len(suggestions) > 0 {
+			fmt.Fprintln(w, "\nDid you mean:")
+			for _, s := range suggestions {
+				fmt.Fprintln(w, "  "+s)
+			}
+		}
+	})
+}
+
+// suggest returns up to n of the sorted paths that are close to
+// path, nearest first.
+func suggest(paths []string, path string, n int) []string {
+	type candidate struct {
+		path     string
+		distance int
+	}
+
+	limit := len([]rune(path)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	var candidates []candidate
+	for _, p := range paths {
+		if d := levenshtein(p, path); d <= limit && p != path {
+			candidates = append(candidates, candidate{p, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < n; i++ {
+		suggestions = append(suggestions, candidates[i].path)
+	}
+	return suggestions
+}
+
+// levenshtein returns the number of single rune insertions,
+// deletions and substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	if a == b {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,72 @@
+package urlshort
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSuggestingFallback(t *testing.T) {
+	entries := []MappingEntry{
+		{Path: "/docs", URL: "https://docs.example.com"},
+		{Paths: []string{"/dogs", "/blog"}, URL: "https://blog.example.com"},
+		{Path: "/about", URL: "https://example.com/about"},
+		{Path: "/docs", URL: "https://docs.example.com/v2"},
+	}
+	tests := []struct {
+		name   string
+		max    int
+		target string
+		body   string
+	}{
+		{"nearest first", 3, "/docz", "404 page not found\n\nDid you mean:\n  /docs\n  /dogs\n"},
+		{"ties sorted", 3, "/dous", "404 page not found\n\nDid you mean:\n  /docs\n  /dogs\n"},
+		{"at most max", 1, "/docz", "404 page not found\n\nDid you mean:\n  /docs\n"},
+		{"not the path itself", 3, "/docs", "404 page not found\n\nDid you mean:\n  /dogs\n"},
+		{"long path", 3, "/about-us", "404 page not found\n\nDid you mean:\n  /about\n"},
+		{"none close", 3, "/pricing", "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(SuggestingFallback(entries, tt.max), tt.target)
+			wantStatus(t, w, http.StatusNotFound)
+			if got := w.Body.String(); got != tt.body {
+				t.Errorf("got body %q, want %q", got, tt.body)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Errorf("got Content-Type %q, want plain text", got)
+			}
+		})
+	}
+}
+
+func TestSuggestingFallbackAsFallback(t *testing.T) {
+	entries := []MappingEntry{{Path: "/docs", URL: "https://docs.example.com"}}
+	h, err := NewMapHandler(map[string]string{"/docs": "https://docs.example.com"}, SuggestingFallback(entries, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRedirect(t, serve(h, "/docs"), http.StatusMovedPermanently, "https://docs.example.com")
+	w := serve(h, "/doc")
+	wantStatus(t, w, http.StatusNotFound)
+	if got, want := w.Body.String(), "404 page not found\n\nDid you mean:\n  /docs\n"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"/über", "/uber", 1},
+		{"/a/b", "/a/b", 0},
+	} {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+)
+
+// TenantHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map any paths to
+// their corresponding URL in the map of the tenant named by the
+// header of the request, such as "X-Tenant-ID". tenantMaps holds the
+// map of each tenant, keyed by tenant name; it is copied, and so are
+// the maps it holds. If the path is not provided in the map of the
+// tenant, then the fallback http.Handler will be called instead.
+//
+// Requests without the header, or with an empty one, are mapped with
+// defaultMap, which can be nil to map nothing. Requests naming a
+// tenant that is not in tenantMaps are passed to the fallback rather
+// than mapped with defaultMap, so that a typo in a tenant name is not
+// silently served the redirects of another tenant. Tenant names are
+// compared exactly.
+//
+// See MapHandler for the meaning of opts.
+func TenantHandler(header string, tenantMaps map[string]map[string]string, defaultMap map[string]string, fallback http.Handler, opts ...Option) http.HandlerFunc {
+	tenants := make(map[string]Map, len(tenantMaps))
+	for tenant, m := range tenantMaps {
+		tenants[tenant] = maps.Clone(m)
+	}
+	defaults := Map(maps.Clone(defaultMap))
+
+	lookup := func(r *http.Request, path string) (MappingEntry, bool, error) {
+		m := defaults
+		if tenant := r.Header.Get(header); tenant != "" {
+			m = tenants[tenant]
+		}
+		url, ok := m.Lookup(path)
+		return MappingEntry{Path: path, URL: url}, ok, nil
+	}
+	return newHandler(lookup, fallback, newConfig(opts)).ServeHTTP
+}
@@ -0,0 +1,56 @@
+package urlshort
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenantHandler(t *testing.T) {
+	tenantMaps := map[string]map[string]string{
+		"acme":   {"/a": "https://acme.example.com/a"},
+		"globex": {"/a": "https://globex.example.com/a", "/g": "https://globex.example.com/g"},
+	}
+	defaultMap := map[string]string{"/a": "https://example.com/a"}
+	h := TenantHandler("X-Tenant-ID", tenantMaps, defaultMap, notFound, WithStatus(http.StatusFound))
+
+	// The maps are copied.
+	tenantMaps["acme"]["/new"] = "https://acme.example.com/new"
+	defaultMap["/new"] = "https://example.com/new"
+
+	tests := []struct {
+		name   string
+		tenant string
+		target string
+		loc    string
+	}{
+		{"tenant", "acme", "/a", "https://acme.example.com/a"},
+		{"other tenant", "globex", "/a", "https://globex.example.com/a"},
+		{"not in tenant", "acme", "/g", ""},
+		{"no header", "", "/a", "https://example.com/a"},
+		{"unknown tenant", "initech", "/a", ""},
+		{"case sensitive", "ACME", "/a", ""},
+		{"added after", "acme", "/new", ""},
+		{"added to default after", "", "/new", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.tenant != "" {
+				r.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if tt.loc == "" {
+				wantStatus(t, w, http.StatusNotFound)
+			} else {
+				wantRedirect(t, w, http.StatusFound, tt.loc)
+			}
+		})
+	}
+}
+
+func TestTenantHandlerNoDefault(t *testing.T) {
+	h := TenantHandler("X-Tenant-ID", map[string]map[string]string{"acme": {"/a": "https://acme.example.com/a"}}, nil, notFound)
+	wantStatus(t, serve(h, "/a"), http.StatusNotFound)
+}
@@ -0,0 +1,34 @@
+package urlshort
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WithServerTiming makes the handler add a Server-Timing header to
+// every response, reporting the time taken to match the request, its
+// lookup included, as a "match" metric in milliseconds, such as
+// "match;dur=0.042". It is set before the response is written,
+// whether the request is redirected, passed to the fallback or
+// answered with an error, and is shown by the developer tools of
+// browsers, which makes it useful to debug slow lookups, such as
+// those of a StoreHandler.
+//
+// It is meant for debugging, and off by default, as it reveals how
+// long lookups take to anyone.
+func WithServerTiming() Option {
+	return func(c *config) {
+		c.serverTiming = true
+	}
+}
+
+// addServerTiming adds the match metric of duration d to the
+// Server-Timing header of w, if configured.
+func (c *config) addServerTiming(w http.ResponseWriter, d time.Duration) {
+	if !c.serverTiming {
+		return
+	}
+	ms := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	w.Header().Add("Server-Timing", "match;dur="+ms)
+}
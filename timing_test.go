@@ -0,0 +1,60 @@
+package urlshort
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithServerTiming(t *testing.T) {
+	store := StoreFunc(func(ctx context.Context, path string) (string, bool, error) {
+		time.Sleep(2 * time.Millisecond)
+		switch path {
+		case "/a":
+			return "https://a.example.com", true, nil
+		case "/down":
+			return "", false, errors.New("connection refused")
+		}
+		return "", false, nil
+	})
+	h := StoreHandler(store, notFound, WithServerTiming())
+	for _, tt := range []struct {
+		target string
+		status int
+	}{
+		{"/a", http.StatusMovedPermanently},
+		{"/missing", http.StatusNotFound},
+		{"/down", http.StatusBadGateway},
+	} {
+		w := serve(h, tt.target)
+		wantStatus(t, w, tt.status)
+		timing := w.Header().Values("Server-Timing")
+		if len(timing) != 1 || !strings.HasPrefix(timing[0], "match;dur=") {
+			t.Errorf("got Server-Timing %q for %s, want one match metric", timing, tt.target)
+			continue
+		}
+		ms, err := strconv.ParseFloat(strings.TrimPrefix(timing[0], "match;dur="), 64)
+		if err != nil || ms < 2 {
+			t.Errorf("got Server-Timing %q for %s, want the time of the lookup", timing, tt.target)
+		}
+	}
+
+	w := serve(StoreHandler(store, notFound), "/a")
+	if got := w.Header().Values("Server-Timing"); got != nil {
+		t.Errorf("got Server-Timing %q by default, want none", got)
+	}
+}
+
+func TestAddServerTiming(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Server-Timing", "db;dur=1")
+	newConfig([]Option{WithServerTiming()}).addServerTiming(w, 42*time.Microsecond)
+	if got, want := w.Header().Values("Server-Timing"), []string{"db;dur=1", "match;dur=0.042"}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got Server-Timing %q, want %q", got, want)
+	}
+}
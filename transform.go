@@ -0,0 +1,82 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+)
+
+// TransformHandler returns an http.Handler that serves requests with
+// inner, and passes the redirects it answers with, that is the 3xx
+// responses with a Location header, to fn before they reach the
+// client. fn returns the location and status code to redirect with
+// instead, which are those it was given to let the redirect through
+// unchanged, or false to veto the redirect, in which case the request
+// is served by fallback as if inner had never handled it. Other
+// responses are passed through unchanged.
+//
+// inner may be any handler, not only one of this package, such as a
+// MountAt of several of them, so fn sees the redirects of all of
+// them. The body of a redirect whose location is changed is dropped,
+// as it would link to the old location, and the headers inner set on
+// a vetoed redirect are dropped too.
+func TransformHandler(inner http.Handler, fn func(loc string, status int) (string, int, bool), fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header().Clone()
+		tw := &transformWriter{ResponseWriter: w, fn: fn}
+		inner.ServeHTTP(tw, r)
+		if !tw.vetoed {
+			return
+		}
+
+		clear(w.Header())
+		maps.Copy(w.Header(), header)
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// transformWriter is an http.ResponseWriter passing the redirect it is
+// written to its fn.
+type transformWriter struct {
+	http.ResponseWriter
+	fn func(loc string, status int) (string, int, bool)
+
+	wroteHeader bool
+	// vetoed is set when fn vetoed the redirect, and discard when its
+	// body is to be dropped, both without writing anything.
+	vetoed  bool
+	discard bool
+}
+
+func (w *transformWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	loc := w.Header().Get("Location")
+	if code < 300 || code > 399 || loc == "" {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	newLoc, newCode, ok := w.fn(loc, code)
+	if !ok {
+		w.vetoed = true
+		return
+	}
+	if newLoc != loc {
+		w.Header().Set("Location", newLoc)
+		w.Header().Del("Content-Length")
+		w.discard = true
+	}
+	w.ResponseWriter.WriteHeader(newCode)
+}
+
+func (w *transformWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.vetoed || w.discard {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
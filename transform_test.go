@@ -0,0 +1,109 @@
+package urlshort
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// transformInner redirects /a with a body, as http.Redirect does, and
+// answers other paths with a 404.
+var transformInner = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/a" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("X-Inner", "1")
+	http.Redirect(w, r, "https://a.example.com", http.StatusFound)
+})
+
+func TestTransformHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(loc string, status int) (string, int, bool)
+		status int
+		loc    string
+		body   bool
+	}{
+		{
+			name:   "passthrough",
+			fn:     func(loc string, status int) (string, int, bool) { return loc, status, true },
+			status: http.StatusFound,
+			loc:    "https://a.example.com",
+			body:   true,
+		},
+		{
+			name: "rewrite location",
+			fn: func(loc string, status int) (string, int, bool) {
+				return strings.Replace(loc, "a.example.com", "b.example.com", 1), status, true
+			},
+			status: http.StatusFound,
+			loc:    "https://b.example.com",
+		},
+		{
+			name:   "rewrite status",
+			fn:     func(loc string, status int) (string, int, bool) { return loc, http.StatusTemporaryRedirect, true },
+			status: http.StatusTemporaryRedirect,
+			loc:    "https://a.example.com",
+			body:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(TransformHandler(transformInner, tt.fn, notFound), "/a")
+			wantRedirect(t, w, tt.status, tt.loc)
+			if got := w.Body.Len() > 0; got != tt.body {
+				t.Errorf("got body %q, want a body: %v", w.Body, tt.body)
+			}
+			if w.Header().Get("X-Inner") != "1" {
+				t.Error("header set by inner handler was dropped")
+			}
+		})
+	}
+}
+
+func TestTransformHandlerVeto(t *testing.T) {
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Fallback", "1")
+		http.Error(w, "fallback", http.StatusTeapot)
+	})
+	veto := func(loc string, status int) (string, int, bool) { return "", 0, false }
+	h := TransformHandler(transformInner, veto, fallback)
+
+	w := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Outer", "1")
+		h.ServeHTTP(w, r)
+	}), "/a")
+	wantStatus(t, w, http.StatusTeapot)
+	if got := w.Body.String(); got != "fallback\n" {
+		t.Errorf("got body %q, want the one of the fallback", got)
+	}
+	for name, want := range map[string]string{"Location": "", "X-Inner": "", "X-Outer": "1", "X-Fallback": "1"} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("got header %s %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTransformHandlerNonRedirect(t *testing.T) {
+	called := false
+	fn := func(loc string, status int) (string, int, bool) {
+		called = true
+		return "", 0, false
+	}
+	w := serve(TransformHandler(transformInner, fn, http.RedirectHandler("/", http.StatusFound)), "/b")
+	wantStatus(t, w, http.StatusNotFound)
+	if called {
+		t.Error("fn was called for a response that is not a redirect")
+	}
+}
+
+func TestTransformHandlerMapHandler(t *testing.T) {
+	inner := MapHandler(map[string]string{"/a": "http://a.example.com"}, notFound)
+	upgrade := func(loc string, status int) (string, int, bool) {
+		return strings.Replace(loc, "http:", "https:", 1), http.StatusPermanentRedirect, true
+	}
+	h := TransformHandler(inner, upgrade, notFound)
+	wantRedirect(t, serve(h, "/a"), http.StatusPermanentRedirect, "https://a.example.com")
+	wantStatus(t, serve(h, "/b"), http.StatusNotFound)
+}
@@ -0,0 +1,165 @@
+// Package urlshortbolt backs the handlers of urlshort with a BoltDB
+// bucket mapping paths to URLs, so that the mappings persist across
+// restarts:
+//
+//	db, err := bolt.Open("urls.db", 0o600, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := urlshortbolt.SeedYAML(db, "urls", yml); err != nil {
+//		log.Fatal(err)
+//	}
+//	http.ListenAndServe(":8080", urlshortbolt.BoltHandler(db, "urls", http.NotFoundHandler()))
+//
+// It lives in a module of its own, apart from package urlshort, so
+// that only programs using BoltDB depend on bbolt.
+package urlshortbolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	urlshort "github.com/salehzaidan/gophercises-urlshort"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltHandler will return an http.HandlerFunc (which also
+// implements http.Handler) that will attempt to map any paths to
+// their corresponding URL in bucket of db, read on every request. If
+// the path is not provided in the bucket, or the bucket does not
+// exist yet, then the fallback http.Handler will be called instead.
+//
+// It is a urlshort.StoreHandler of Store(db, bucket), so errors of
+// db are answered as by StoreHandler. See urlshort.MapHandler for the
+// meaning of opts.
+func BoltHandler(db *bolt.DB, bucket string, fallback http.Handler, opts ...urlshort.Option) http.HandlerFunc {
+	return urlshort.StoreHandler(Store(db, bucket), fallback, opts...)
+}
+
+// Store returns a urlshort.Store looking paths up in bucket of db,
+// whose keys are paths and whose values are URLs. A missing bucket
+// maps no path.
+func Store(db *bolt.DB, bucket string) urlshort.Store {
+	return urlshort.StoreFunc(func(ctx context.Context, path string) (url string, ok bool, err error) {
+		err = db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(bucket))
+			if b == nil {
+				return nil
+			}
+			// The value is only valid during the transaction, and
+			// converting it to a string copies it.
+			if v := b.Get([]byte(path)); v != nil {
+				url, ok = string(v), true
+			}
+			return nil
+		})
+		return url, ok, err
+	})
+}
+
+// Seed stores the redirects of entries in bucket of db, creating the
+// bucket if needed, in a single transaction. Paths already in the
+// bucket are overwritten, and the others are left as they are.
+// Disabled entries are skipped.
+//
+// A bucket only maps paths to URLs, so entries needing more are an
+// error, and nothing is stored then: invalid entries, as reported by
+// MappingEntry.Validate, entries without a path or a URL,
+// gone entries, and entries with any of Temporary, Status, Schedule,
+// Variants, Backup, MaxHits or Expires. The error joins one
+// *urlshort.EntryError per such entry. Notes are ignored.
+func Seed(db *bolt.DB, bucket string, entries []urlshort.MappingEntry) error {
+	var errs []error
+	for _, entry := range entries {
+		if err := checkEntry(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Enabled != nil && !*entry.Enabled {
+				continue
+			}
+			for _, path := range entryPaths(entry) {
+				if err := b.Put([]byte(path), []byte(entry.URL)); err != nil {
+					return fmt.Errorf("put %s: %w", path, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SeedYAML parses yml, as by urlshort.ParseYAML, and stores its
+// redirects in bucket of db as by Seed.
+func SeedYAML(db *bolt.DB, bucket string, yml []byte, opts ...urlshort.DecodeOption) error {
+	entries, err := urlshort.ParseYAML(yml, opts...)
+	if err != nil {
+		return err
+	}
+	return Seed(db, bucket, entries)
+}
+
+// SeedJSON parses jsn, as by urlshort.ParseJSON, and stores its
+// redirects in bucket of db as by Seed.
+func SeedJSON(db *bolt.DB, bucket string, jsn []byte, opts ...urlshort.DecodeOption) error {
+	entries, err := urlshort.ParseJSON(jsn, opts...)
+	if err != nil {
+		return err
+	}
+	return Seed(db, bucket, entries)
+}
+
+// checkEntry reports whether entry is valid and can be stored in a
+// bucket.
+func checkEntry(entry urlshort.MappingEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	path := entry.Path
+	if path == "" && len(entry.Paths) > 0 {
+		path = entry.Paths[0]
+	}
+	problem := ""
+	switch {
+	case path == "":
+		problem = "missing path"
+	case entry.Gone:
+		problem = "gone entries cannot be stored"
+	case entry.URL == "":
+		problem = "missing url"
+	case entry.Temporary || entry.Status != 0:
+		problem = "status cannot be stored"
+	case len(entry.Schedule) > 0:
+		problem = "schedule cannot be stored"
+	case len(entry.Variants) > 0:
+		problem = "variants cannot be stored"
+	case entry.Backup != "":
+		problem = "backup cannot be stored"
+	case entry.MaxHits != 0:
+		problem = "maxHits cannot be stored"
+	case entry.Expires != nil:
+		problem = "expires cannot be stored"
+	default:
+		return nil
+	}
+	return &urlshort.EntryError{Path: path, Problem: problem}
+}
+
+// entryPaths returns the paths entry maps.
+func entryPaths(entry urlshort.MappingEntry) []string {
+	if entry.Path != "" {
+		return append([]string{entry.Path}, entry.Paths...)
+	}
+	return entry.Paths
+}
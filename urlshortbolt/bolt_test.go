@@ -0,0 +1,108 @@
+package urlshortbolt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	urlshort "github.com/salehzaidan/gophercises-urlshort"
+	bolt "go.etcd.io/bbolt"
+)
+
+// openDB opens a database in a temporary directory, closed at the end
+// of the test.
+func openDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "urls.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// serve serves a GET request for target with h and returns the
+// response.
+func serve(h http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestBoltHandler(t *testing.T) {
+	db := openDB(t)
+	h := BoltHandler(db, "urls", http.NotFoundHandler())
+	if w := serve(h, "/a"); w.Code != http.StatusNotFound {
+		t.Errorf("got %d before the bucket exists, want 404", w.Code)
+	}
+
+	yml := "- paths: [/a, /b]\n  url: https://a.example.com\n- path: /off\n  url: https://off.example.com\n  enabled: false\n"
+	if err := SeedYAML(db, "urls", []byte(yml)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SeedJSON(db, "urls", []byte(`[{"path": "/c", "url": "https://c.example.com"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path   string
+		status int
+		loc    string
+	}{
+		{"/a", http.StatusMovedPermanently, "https://a.example.com"},
+		{"/b", http.StatusMovedPermanently, "https://a.example.com"},
+		{"/c", http.StatusMovedPermanently, "https://c.example.com"},
+		{"/off", http.StatusNotFound, ""},
+		{"/missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(h, tt.path)
+		if w.Code != tt.status || w.Header().Get("Location") != tt.loc {
+			t.Errorf("%s: got %d to %q, want %d to %q", tt.path, w.Code, w.Header().Get("Location"), tt.status, tt.loc)
+		}
+	}
+}
+
+func TestSeedOverwrites(t *testing.T) {
+	db := openDB(t)
+	if err := Seed(db, "urls", []urlshort.MappingEntry{{Path: "/a", URL: "https://old.example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Seed(db, "urls", []urlshort.MappingEntry{{Path: "/a", URL: "https://new.example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	url, ok, err := Store(db, "urls").Get(context.Background(), "/a")
+	if err != nil || !ok || url != "https://new.example.com" {
+		t.Errorf("got %q, %v, %v, want the new URL", url, ok, err)
+	}
+}
+
+func TestSeedRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry urlshort.MappingEntry
+	}{
+		{"missing path", urlshort.MappingEntry{URL: "https://a.example.com"}},
+		{"missing url", urlshort.MappingEntry{Path: "/a"}},
+		{"gone", urlshort.MappingEntry{Path: "/a", Gone: true}},
+		{"temporary", urlshort.MappingEntry{Path: "/a", URL: "https://a.example.com", Temporary: true}},
+		{"maxHits", urlshort.MappingEntry{Path: "/a", URL: "https://a.example.com", MaxHits: 3}},
+		{"empty path in paths", urlshort.MappingEntry{Paths: []string{"/a", ""}, URL: "https://a.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			entries := []urlshort.MappingEntry{{Path: "/ok", URL: "https://ok.example.com"}, tt.entry}
+			err := Seed(db, "urls", entries)
+			var entryErr *urlshort.EntryError
+			if !errors.As(err, &entryErr) {
+				t.Fatalf("got error %v, want an *EntryError", err)
+			}
+			if _, ok, _ := Store(db, "urls").Get(context.Background(), "/ok"); ok {
+				t.Error("valid entry stored along with an invalid one")
+			}
+		})
+	}
+}
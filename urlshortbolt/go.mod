@@ -0,0 +1,15 @@
+module github.com/salehzaidan/gophercises-urlshort/urlshortbolt
+
+go 1.22.1
+
+require (
+	github.com/salehzaidan/gophercises-urlshort v0.0.0-00010101000000-000000000000
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/salehzaidan/gophercises-urlshort => ../
@@ -0,0 +1,5 @@
+module github.com/salehzaidan/gophercises-urlshort/urlshortlambda
+
+go 1.22.1
+
+require github.com/aws/aws-lambda-go v1.47.0
@@ -0,0 +1,161 @@
+// Package urlshortlambda adapts the handlers of urlshort to AWS
+// Lambda functions behind API Gateway, so that the same mappings can
+// be served without a server:
+//
+//	h, err := urlshort.YAMLHandler(yml, http.NotFoundHandler())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	lambda.Start(urlshortlambda.ProxyHandler(h))
+//
+// It lives in a module of its own, apart from package urlshort, so
+// that only programs running in Lambda depend on aws-lambda-go.
+package urlshortlambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ProxyHandler returns a Lambda handler serving the events of the
+// REST APIs of API Gateway and of Application Load Balancers, in the
+// format of Lambda proxy integrations (payload version 1.0), with h.
+// The event is translated into an *http.Request with the context of
+// the invocation, and the response written by h back into a proxy
+// response.
+func ProxyHandler(h http.Handler) func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		query := url.Values(event.MultiValueQueryStringParameters)
+		if len(query) == 0 {
+			query = url.Values{}
+			for name, value := range event.QueryStringParameters {
+				query.Set(name, value)
+			}
+		}
+		header := http.Header{}
+		for name, value := range event.Headers {
+			header.Set(name, value)
+		}
+		for name, values := range event.MultiValueHeaders {
+			header[http.CanonicalHeaderKey(name)] = values
+		}
+
+		r, err := newRequest(ctx, event.HTTPMethod, event.Path, query.Encode(), header, event.Body, event.IsBase64Encoded)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		r.RemoteAddr = event.RequestContext.Identity.SourceIP
+
+		w := newResponseWriter()
+		h.ServeHTTP(w, r)
+		body, encoded := w.body()
+		return events.APIGatewayProxyResponse{
+			StatusCode:        w.status,
+			MultiValueHeaders: w.header,
+			Body:              body,
+			IsBase64Encoded:   encoded,
+		}, nil
+	}
+}
+
+// HTTPAPIHandler returns a Lambda handler serving the events of the
+// HTTP APIs of API Gateway and of Lambda function URLs (payload
+// version 2.0) with h, as ProxyHandler does for payload version 1.0.
+func HTTPAPIHandler(h http.Handler) func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		header := http.Header{}
+		for name, value := range event.Headers {
+			header.Set(name, value)
+		}
+		if len(event.Cookies) > 0 {
+			header.Set("Cookie", strings.Join(event.Cookies, "; "))
+		}
+
+		r, err := newRequest(ctx, event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString, header, event.Body, event.IsBase64Encoded)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{}, err
+		}
+		r.RemoteAddr = event.RequestContext.HTTP.SourceIP
+
+		w := newResponseWriter()
+		h.ServeHTTP(w, r)
+		cookies := w.header.Values("Set-Cookie")
+		w.header.Del("Set-Cookie")
+		body, encoded := w.body()
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode:        w.status,
+			MultiValueHeaders: w.header,
+			Body:              body,
+			IsBase64Encoded:   encoded,
+			Cookies:           cookies,
+		}, nil
+	}
+}
+
+// newRequest returns the request of an event.
+func newRequest(ctx context.Context, method, path, rawQuery string, header http.Header, body string, base64Encoded bool) (*http.Request, error) {
+	data := []byte(body)
+	if base64Encoded {
+		var err error
+		data, err = base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	u := &url.URL{Path: path, RawQuery: rawQuery}
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	r.Header = header
+	r.Host = header.Get("Host")
+	r.RequestURI = u.RequestURI()
+	return r, nil
+}
+
+// responseWriter is an http.ResponseWriter buffering the response to
+// an event.
+type responseWriter struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: http.Header{}}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.buf.Write(b)
+}
+
+// body returns the body of the response, base64-encoded if it is not
+// valid UTF-8, along with whether it was encoded.
+func (w *responseWriter) body() (string, bool) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if utf8.Valid(w.buf.Bytes()) {
+		return w.buf.String(), false
+	}
+	return base64.StdEncoding.EncodeToString(w.buf.Bytes()), true
+}
@@ -0,0 +1,122 @@
+package urlshortlambda
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// redirects redirects /go to https://example.com with its query and
+// echoes the other requests.
+var redirects = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/go" {
+		http.Redirect(w, r, "https://example.com/?"+r.URL.RawQuery, http.StatusMovedPermanently)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	http.SetCookie(w, &http.Cookie{Name: "seen", Value: "1"})
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "%s %s host=%s ip=%s cookie=%s body=%s", r.Method, r.URL.RequestURI(), r.Host, r.RemoteAddr, r.Header.Get("Cookie"), body)
+})
+
+func TestProxyHandler(t *testing.T) {
+	h := ProxyHandler(redirects)
+
+	resp, err := h(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		Path:                  "/go",
+		QueryStringParameters: map[string]string{"ref": "mail"},
+		Headers:               map[string]string{"Host": "short.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusMovedPermanently)
+	}
+	if got := http.Header(resp.MultiValueHeaders).Get("Location"); got != "https://example.com/?ref=mail" {
+		t.Errorf("got Location %q, want %q", got, "https://example.com/?ref=mail")
+	}
+
+	resp, err = h(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:                      http.MethodPost,
+		Path:                            "/echo",
+		MultiValueQueryStringParameters: map[string][]string{"a": {"1", "2"}},
+		MultiValueHeaders:               map[string][]string{"host": {"short.example.com"}},
+		Body:                            base64.StdEncoding.EncodeToString([]byte("hello")),
+		IsBase64Encoded:                 true,
+		RequestContext:                  events.APIGatewayProxyRequestContext{Identity: events.APIGatewayRequestIdentity{SourceIP: "192.0.2.1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "POST /echo?a=1&a=2 host=short.example.com ip=192.0.2.1 cookie= body=hello"
+	if resp.StatusCode != http.StatusOK || resp.Body != want || resp.IsBase64Encoded {
+		t.Errorf("got %d %q (base64 %v), want 200 %q", resp.StatusCode, resp.Body, resp.IsBase64Encoded, want)
+	}
+}
+
+func TestProxyHandlerInvalidBody(t *testing.T) {
+	_, err := ProxyHandler(redirects)(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:      http.MethodPost,
+		Path:            "/echo",
+		Body:            "not base64!",
+		IsBase64Encoded: true,
+	})
+	if err == nil {
+		t.Error("accepted a body that is not base64")
+	}
+}
+
+func TestHTTPAPIHandler(t *testing.T) {
+	h := HTTPAPIHandler(redirects)
+
+	event := events.APIGatewayV2HTTPRequest{
+		RawPath:        "/go",
+		RawQueryString: "ref=mail",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet, SourceIP: "192.0.2.1"},
+		},
+	}
+	resp, err := h(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusMovedPermanently || http.Header(resp.MultiValueHeaders).Get("Location") != "https://example.com/?ref=mail" {
+		t.Errorf("got %d to %q, want 301 to %q", resp.StatusCode, http.Header(resp.MultiValueHeaders).Get("Location"), "https://example.com/?ref=mail")
+	}
+
+	event.RawPath = "/echo"
+	event.RawQueryString = ""
+	event.Cookies = []string{"a=1", "b=2"}
+	event.Headers = map[string]string{"host": "short.example.com"}
+	resp, err = h(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GET /echo host=short.example.com ip=192.0.2.1 cookie=a=1; b=2 body="
+	if resp.Body != want {
+		t.Errorf("got body %q, want %q", resp.Body, want)
+	}
+	if len(resp.Cookies) != 1 || resp.Cookies[0] != "seen=1" || resp.MultiValueHeaders["Set-Cookie"] != nil {
+		t.Errorf("got cookies %q and headers %v, want the cookie seen=1 in Cookies only", resp.Cookies, resp.MultiValueHeaders)
+	}
+}
+
+func TestBinaryBody(t *testing.T) {
+	h := ProxyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0xff, 0x00})
+	}))
+	resp, err := h(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsBase64Encoded || resp.Body != base64.StdEncoding.EncodeToString([]byte{0xff, 0x00}) {
+		t.Errorf("got body %q (base64 %v), want it base64-encoded", resp.Body, resp.IsBase64Encoded)
+	}
+}
@@ -0,0 +1,121 @@
+// Package urlshortqr serves QR codes of the short URLs of urlshort,
+// for print materials, so that scanning one hits the redirect:
+//
+//	mux.Handle("/qr", urlshortqr.QRHandler("https://sho.rt"))
+//
+// A GET of /qr?path=/some-path then returns a PNG encoding
+// "https://sho.rt/some-path", whatever that path redirects to.
+//
+// It lives in a module of its own, apart from package urlshort, so
+// that only programs serving QR codes depend on go-qrcode.
+package urlshortqr
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Level is the error correction level of a QR code: the higher the
+// level, the more of the code can be damaged or covered while it can
+// still be scanned, and the denser the code.
+type Level int
+
+const (
+	// Low recovers from 7% of the code being damaged.
+	Low Level = iota
+	// Medium recovers from 15% of the code being damaged.
+	Medium
+	// High recovers from 25% of the code being damaged.
+	High
+	// Highest recovers from 30% of the code being damaged.
+	Highest
+)
+
+// Option customises the QR codes served by QRHandler.
+type Option func(*config)
+
+// config holds the QR codes selected by a list of Options.
+type config struct {
+	size  int
+	level Level
+}
+
+// WithSize sets the width and height of the QR codes in pixels, 256
+// by default. WithSize panics if size is not positive.
+func WithSize(size int) Option {
+	if size <= 0 {
+		panic(fmt.Sprintf("urlshortqr: invalid size %d", size))
+	}
+	return func(c *config) {
+		c.size = size
+	}
+}
+
+// WithLevel sets the error correction level of the QR codes, Medium
+// by default. WithLevel panics if level is not Low, Medium, High or
+// Highest.
+func WithLevel(level Level) Option {
+	if level < Low || level > Highest {
+		panic(fmt.Sprintf("urlshortqr: invalid level %d", level))
+	}
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// QRHandler returns an http.HandlerFunc answering GET requests with a
+// "path" query parameter, as in "?path=/foo", with a PNG image of the
+// QR code of baseURL followed by the path, such as
+// "https://sho.rt/foo" for the base URL "https://sho.rt". The path is
+// not looked up: the QR code encodes the short URL, not its
+// destination, so that it follows later changes of the mapping.
+//
+// Requests without a path, or with a path that is not absolute, are
+// answered with a plain text 400 Bad Request.
+func QRHandler(baseURL string, opts ...Option) http.HandlerFunc {
+	cfg := &config{size: 256, level: Medium}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		path := r.URL.Query().Get("path")
+		if !strings.HasPrefix(path, "/") {
+			http.Error(w, "missing or invalid path parameter", http.StatusBadRequest)
+			return
+		}
+
+		png, err := qrcode.Encode(baseURL+path, recoveryLevel(cfg.level), cfg.size)
+		if err != nil {
+			// The content is too long to fit in a QR code.
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+		w.Write(png)
+	}
+}
+
+// recoveryLevel returns the go-qrcode recovery level of level.
+func recoveryLevel(level Level) qrcode.RecoveryLevel {
+	switch level {
+	case Low:
+		return qrcode.Low
+	case High:
+		return qrcode.High
+	case Highest:
+		return qrcode.Highest
+	}
+	return qrcode.Medium
+}
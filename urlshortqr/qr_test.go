@@ -0,0 +1,111 @@
+package urlshortqr
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// get serves a GET of /qr with the path parameter path with h.
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/qr?path="+url.QueryEscape(path), nil))
+	return w
+}
+
+func TestQRHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		opts    []Option
+		content string
+		level   qrcode.RecoveryLevel
+		size    int
+	}{
+		{"defaults", "https://sho.rt", nil, "https://sho.rt/promo", qrcode.Medium, 256},
+		{"base with slash", "https://sho.rt/", nil, "https://sho.rt/promo", qrcode.Medium, 256},
+		{"size", "https://sho.rt", []Option{WithSize(128)}, "https://sho.rt/promo", qrcode.Medium, 128},
+		{"low", "https://sho.rt", []Option{WithLevel(Low)}, "https://sho.rt/promo", qrcode.Low, 256},
+		{"highest", "https://sho.rt", []Option{WithLevel(Highest), WithSize(512)}, "https://sho.rt/promo", qrcode.Highest, 512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(QRHandler(tt.base, tt.opts...), "/promo")
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want 200: %s", w.Code, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("got Content-Type %q, want image/png", ct)
+			}
+
+			img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("response is not a valid PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.size || b.Dy() != tt.size {
+				t.Errorf("got a %dx%d image, want %dx%d", b.Dx(), b.Dy(), tt.size, tt.size)
+			}
+
+			want, err := qrcode.Encode(tt.content, tt.level, tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(w.Body.Bytes(), want) {
+				t.Errorf("image does not encode %q at level %d", tt.content, tt.level)
+			}
+		})
+	}
+}
+
+func TestQRHandlerBadRequest(t *testing.T) {
+	h := QRHandler("https://sho.rt")
+	for _, target := range []string{"/qr", "/qr?path=", "/qr?path=promo", "/qr?path=https://evil.example.com"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", target, w.Code)
+		}
+	}
+
+	// Content too long to fit in a QR code.
+	long := "/" + string(bytes.Repeat([]byte("a"), 4000))
+	if w := get(h, long); w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for a too long path, want 400", w.Code)
+	}
+}
+
+func TestQRHandlerMethod(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodHead, http.MethodDelete} {
+		w := httptest.NewRecorder()
+		QRHandler("https://sho.rt").ServeHTTP(w, httptest.NewRequest(method, "/qr?path=/promo", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: got status %d, want 405", method, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != http.MethodGet {
+			t.Errorf("%s: got Allow %q, want GET", method, allow)
+		}
+	}
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	for name, opt := range map[string]func(){
+		"WithSize(0)":   func() { WithSize(0) },
+		"WithSize(-1)":  func() { WithSize(-1) },
+		"WithLevel(-1)": func() { WithLevel(-1) },
+		"WithLevel(4)":  func() { WithLevel(Highest + 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			opt()
+		}()
+	}
+}
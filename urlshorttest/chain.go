@@ -0,0 +1,80 @@
+// Package urlshorttest provides utilities for testing redirect
+// handlers and the stores behind them.
+package urlshorttest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+// ErrLoop is returned by FollowChain when a redirect chain comes back
+// to a URL it has already visited.
+var ErrLoop = errors.New("redirect loop")
+
+// ErrTooManyHops is returned by FollowChain when a redirect chain is
+// longer than allowed.
+var ErrTooManyHops = errors.New("too many redirects")
+
+// FollowChain issues a GET request for startPath to handler and
+// follows the Location header of every redirect it answers with,
+// resolving relative locations against the URL they answered. Every
+// request goes to handler, whatever the host of its URL, so
+// destinations outside handler are followed only as far as handler
+// maps their paths.
+//
+// It returns the sequence of URLs visited, starting with startPath
+// resolved against http://example.com and ending with the first one
+// answered with anything but a redirect. If a URL is visited twice,
+// or more than maxHops redirects are followed, it returns the URLs
+// visited so far along with an error wrapping ErrLoop or
+// ErrTooManyHops.
+func FollowChain(handler http.Handler, startPath string, maxHops int) ([]string, error) {
+	base, _ := url.Parse("http://example.com/")
+	u, err := base.Parse(startPath)
+	if err != nil {
+		return nil, fmt.Errorf("urlshorttest: invalid start path %q: %w", startPath, err)
+	}
+
+	chain := []string{u.String()}
+	seen := map[string]bool{u.String(): true}
+	for hops := 0; ; hops++ {
+		loc, ok, err := next(handler, u)
+		if err != nil || !ok {
+			return chain, err
+		}
+		if hops == maxHops {
+			return chain, fmt.Errorf("urlshorttest: %w after %d hops", ErrTooManyHops, maxHops)
+		}
+
+		u = loc
+		chain = append(chain, u.String())
+		if seen[u.String()] {
+			return chain, fmt.Errorf("urlshorttest: %w at %s", ErrLoop, u)
+		}
+		seen[u.String()] = true
+	}
+}
+
+// next serves a GET request for u with handler and returns the URL it
+// redirects to, if it answers with a redirect.
+func next(handler http.Handler, u *url.URL) (*url.URL, bool, error) {
+	r := httptest.NewRequest(http.MethodGet, u.String(), nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code < 300 || w.Code > 399 {
+		return nil, false, nil
+	}
+	loc := w.Header().Get("Location")
+	if loc == "" {
+		return nil, false, nil
+	}
+	next, err := u.Parse(loc)
+	if err != nil {
+		return nil, false, fmt.Errorf("urlshorttest: invalid Location %q from %s: %w", loc, u, err)
+	}
+	return next, true, nil
+}
@@ -0,0 +1,76 @@
+package urlshorttest
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	urlshort "github.com/salehzaidan/gophercises-urlshort"
+)
+
+func TestFollowChain(t *testing.T) {
+	h := urlshort.MapHandler(map[string]string{
+		"/a":        "/b",
+		"/b":        "http://example.com/c?x=1",
+		"/c":        "../d",
+		"/loop":     "/loop2",
+		"/loop2":    "/loop",
+		"/external": "https://other.example.net/b",
+		"/bad":      "http://[::1",
+	}, http.NotFoundHandler())
+
+	tests := []struct {
+		name  string
+		start string
+		hops  int
+		chain []string
+		err   error
+	}{
+		{
+			name: "chain", start: "/a", hops: 5,
+			chain: []string{"http://example.com/a", "http://example.com/b", "http://example.com/c?x=1", "http://example.com/d"},
+		},
+		{
+			name: "no redirect", start: "/d", hops: 5,
+			chain: []string{"http://example.com/d"},
+		},
+		{
+			name: "exact hops", start: "/a", hops: 3,
+			chain: []string{"http://example.com/a", "http://example.com/b", "http://example.com/c?x=1", "http://example.com/d"},
+		},
+		{
+			name: "too many hops", start: "/a", hops: 2,
+			chain: []string{"http://example.com/a", "http://example.com/b", "http://example.com/c?x=1"},
+			err:   ErrTooManyHops,
+		},
+		{
+			name: "loop", start: "/loop", hops: 5,
+			chain: []string{"http://example.com/loop", "http://example.com/loop2", "http://example.com/loop"},
+			err:   ErrLoop,
+		},
+		{
+			// Every request goes to h, which maps /b whatever the host.
+			name: "other host", start: "/external", hops: 5,
+			chain: []string{"http://example.com/external", "https://other.example.net/b", "http://example.com/c?x=1", "http://example.com/d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := FollowChain(h, tt.start, tt.hops)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(chain, tt.chain) {
+				t.Errorf("got chain %q, want %q", chain, tt.chain)
+			}
+		})
+	}
+
+	if _, err := FollowChain(h, "/bad", 5); err == nil {
+		t.Error("got no error for an invalid Location")
+	}
+	if _, err := FollowChain(h, "%zz", 5); err == nil {
+		t.Error("got no error for an invalid start path")
+	}
+}
@@ -0,0 +1,108 @@
+package urlshorttest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemStore is an in-memory urlshort.Store for tests, which can be
+// made to fail or slow down to exercise the error handling of the
+// code using it. The zero value is an empty store ready to use, and
+// it is safe for concurrent use.
+type MemStore struct {
+	mu       sync.Mutex
+	urls     map[string]string
+	failures []error
+	delay    time.Duration
+	calls    int
+}
+
+// NewMemStore returns a MemStore holding a copy of pathsToUrls.
+func NewMemStore(pathsToUrls map[string]string) *MemStore {
+	s := &MemStore{urls: make(map[string]string, len(pathsToUrls))}
+	for path, url := range pathsToUrls {
+		s.urls[path] = url
+	}
+	return s
+}
+
+// Get returns the URL path is mapped to, after the delay set by
+// Delay, unless a failure was queued by FailNext. If ctx is done
+// before the delay has elapsed, it returns ctx.Err() at once.
+func (s *MemStore) Get(ctx context.Context, path string) (string, bool, error) {
+	s.mu.Lock()
+	s.calls++
+	delay := s.delay
+	var err error
+	if len(s.failures) > 0 {
+		err = s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		}
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url, ok := s.urls[path]
+	return url, ok, nil
+}
+
+// Set maps path to url.
+func (s *MemStore) Set(path, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.urls == nil {
+		s.urls = make(map[string]string)
+	}
+	s.urls[path] = url
+}
+
+// Delete removes the mapping of path, if any.
+func (s *MemStore) Delete(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.urls, path)
+}
+
+// FailNext makes the next call to Get return err. Failures queued by
+// successive calls are returned by successive calls to Get, in order.
+func (s *MemStore) FailNext(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, err)
+}
+
+// Delay makes every following call to Get wait for d before
+// returning, or not wait at all if d is not positive.
+func (s *MemStore) Delay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = d
+}
+
+// Calls returns the number of calls to Get so far, for asserting
+// that a cache or a circuit breaker spared the store.
+func (s *MemStore) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
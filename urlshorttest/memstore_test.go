@@ -0,0 +1,109 @@
+package urlshorttest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	urlshort "github.com/salehzaidan/gophercises-urlshort"
+)
+
+// get looks path up in s, failing t on an error.
+func get(t *testing.T, s *MemStore, path string) (string, bool) {
+	t.Helper()
+	url, ok, err := s.Get(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", path, err)
+	}
+	return url, ok
+}
+
+func TestMemStore(t *testing.T) {
+	m := map[string]string{"/a": "https://a.example.com"}
+	s := NewMemStore(m)
+	m["/b"] = "https://b.example.com"
+
+	if url, ok := get(t, s, "/a"); !ok || url != "https://a.example.com" {
+		t.Errorf("got %q, %v for /a, want its URL", url, ok)
+	}
+	if _, ok := get(t, s, "/b"); ok {
+		t.Error("got /b mapped after changing the map given to NewMemStore")
+	}
+
+	s.Set("/b", "https://b2.example.com")
+	s.Delete("/a")
+	s.Delete("/missing")
+	if url, ok := get(t, s, "/b"); !ok || url != "https://b2.example.com" {
+		t.Errorf("got %q, %v for /b after Set, want its URL", url, ok)
+	}
+	if _, ok := get(t, s, "/a"); ok {
+		t.Error("got /a mapped after Delete")
+	}
+	if n := s.Calls(); n != 4 {
+		t.Errorf("got %d calls, want 4", n)
+	}
+}
+
+func TestMemStoreZero(t *testing.T) {
+	var s MemStore
+	if _, ok := get(t, &s, "/a"); ok {
+		t.Error("got /a mapped in an empty store")
+	}
+	s.Set("/a", "https://a.example.com")
+	if url, ok := get(t, &s, "/a"); !ok || url != "https://a.example.com" {
+		t.Errorf("got %q, %v for /a, want its URL", url, ok)
+	}
+}
+
+func TestMemStoreFailNext(t *testing.T) {
+	s := NewMemStore(map[string]string{"/a": "https://a.example.com"})
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	s.FailNext(errFirst)
+	s.FailNext(errSecond)
+
+	for _, want := range []error{errFirst, errSecond} {
+		if _, _, err := s.Get(context.Background(), "/a"); err != want {
+			t.Errorf("got error %v, want %v", err, want)
+		}
+	}
+	if _, ok := get(t, s, "/a"); !ok {
+		t.Error("got /a unmapped once the failures were used up")
+	}
+
+	s.FailNext(errFirst)
+	h := urlshort.StoreHandler(s, http.NotFoundHandler())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/a", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got status %d from StoreHandler, want %d", w.Code, http.StatusBadGateway)
+	}
+}
+
+func TestMemStoreDelay(t *testing.T) {
+	s := NewMemStore(map[string]string{"/a": "https://a.example.com"})
+	s.Delay(10 * time.Millisecond)
+	start := time.Now()
+	if _, ok := get(t, s, "/a"); !ok {
+		t.Error("got /a unmapped")
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Errorf("got an answer after %v, want it delayed", d)
+	}
+
+	s.Delay(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := s.Get(ctx, "/a"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// A failure is returned once the delay has elapsed.
+	s.Delay(time.Millisecond)
+	s.FailNext(errors.New("down"))
+	if _, _, err := s.Get(context.Background(), "/a"); err == nil || err.Error() != "down" {
+		t.Errorf("got error %v after the delay, want the queued failure", err)
+	}
+}
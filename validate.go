@@ -0,0 +1,260 @@
+package urlshort
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EntryError describes a problem with the entry for Path.
+type EntryError struct {
+	Path    string
+	Problem string
+
+	// Line is the line of the entry in the YAML data it was parsed
+	// from, if known, and 0 otherwise.
+	Line int
+	// Index is the position, counted from 1, of the entry in the JSON
+	// data it was parsed from, if known, and 0 otherwise.
+	Index int
+}
+
+func (e *EntryError) Error() string {
+	switch {
+	case e.Line > 0 && e.Path == "":
+		return fmt.Sprintf("line %d: entry: %s", e.Line, e.Problem)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: entry %s: %s", e.Line, e.Path, e.Problem)
+	case e.Index > 0 && e.Path == "":
+		return fmt.Sprintf("entry %d: %s", e.Index, e.Problem)
+	case e.Index > 0:
+		return fmt.Sprintf("entry %d (%s): %s", e.Index, e.Path, e.Problem)
+	case e.Path == "":
+		return "entry: " + e.Problem
+	}
+	return fmt.Sprintf("entry %s: %s", e.Path, e.Problem)
+}
+
+// Validate reports whether the fields of e are consistent with each
+// other. Fields left unset are never an error on their own, so that
+// entries can use only the fields they need. The returned error is an
+// *EntryError.
+func (e MappingEntry) Validate() error {
+	if e.Path != "" && len(e.Paths) > 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both path and paths"}
+	}
+	if slices.Contains(e.Paths, "") {
+		return &EntryError{Path: e.name(), Problem: "empty path in paths"}
+	}
+	if e.Gone && e.URL != "" {
+		return &EntryError{Path: e.name(), Problem: "cannot set both url and gone"}
+	}
+	if e.Gone && len(e.Schedule) > 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both schedule and gone"}
+	}
+	if e.Gone && len(e.Variants) > 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both variants and gone"}
+	}
+	if e.Gone && e.Backup != "" {
+		return &EntryError{Path: e.name(), Problem: "cannot set both backup and gone"}
+	}
+	if e.Gone && e.Temporary {
+		return &EntryError{Path: e.name(), Problem: "cannot set both temporary and gone"}
+	}
+	if e.Status != 0 && !isRedirectStatus(e.Status) {
+		return &EntryError{Path: e.name(), Problem: fmt.Sprintf("invalid redirect status %d", e.Status)}
+	}
+	if e.Gone && e.Status != 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both status and gone"}
+	}
+	if e.Temporary && e.Status != 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both status and temporary"}
+	}
+	if e.Gone && e.MaxHits != 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both maxHits and gone"}
+	}
+	if e.MaxHits < 0 {
+		return &EntryError{Path: e.name(), Problem: "maxHits must not be negative"}
+	}
+	if e.URL != "" && len(e.Variants) > 0 {
+		return &EntryError{Path: e.name(), Problem: "cannot set both url and variants"}
+	}
+	for i, v := range e.Variants {
+		if err := v.validate(); err != nil {
+			return &EntryError{Path: e.name(), Problem: fmt.Sprintf("variant %d: %v", i+1, err)}
+		}
+	}
+	for i, rule := range e.Schedule {
+		if err := rule.validate(); err != nil {
+			return &EntryError{Path: e.name(), Problem: fmt.Sprintf("schedule rule %d: %v", i+1, err)}
+		}
+	}
+	return nil
+}
+
+// ValidateEntries validates every entry of entries, returning the
+// errors of all the invalid ones joined together.
+func ValidateEntries(entries []MappingEntry) error {
+	return validateEntries(entries, nil)
+}
+
+// validateEntries is like ValidateEntries, except that the error of
+// entries[i], if any, is passed to locate, if not nil, to set its
+// position in the data the entries were parsed from.
+func validateEntries(entries []MappingEntry, locate func(i int, err *EntryError)) error {
+	var errs []error
+	for i, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			var entryErr *EntryError
+			if locate != nil && errors.As(err, &entryErr) {
+				locate(i, entryErr)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// yamlLocator returns a function for validateEntries setting the line
+// of the errors of the entries parsed from the YAML document node.
+func yamlLocator(node *yaml.Node) func(i int, err *EntryError) {
+	var seq *yaml.Node
+	if len(node.Content) > 0 {
+		seq = node.Content[0]
+	}
+	if seq != nil && seq.Kind == yaml.MappingNode {
+		redirects := seq
+		seq = nil
+		for i := 0; i+1 < len(redirects.Content); i += 2 {
+			if redirects.Content[i].Value == "redirects" {
+				seq = redirects.Content[i+1]
+			}
+		}
+	}
+	return func(i int, err *EntryError) {
+		if seq != nil && seq.Kind == yaml.SequenceNode && i < len(seq.Content) {
+			err.Line = seq.Content[i].Line
+		}
+	}
+}
+
+// jsonLocator sets the index of err, the error of entries[i] parsed
+// from JSON data, for validateEntries. Unlike the YAML decoder, the
+// JSON decoder does not keep the positions of values.
+func jsonLocator(i int, err *EntryError) {
+	err.Index = i + 1
+}
+
+// Validate parses data in the given format, "yaml" or "json", and
+// checks its entries without building a handler, for use as a lint
+// step. It returns the number of entries parsed along with an error
+// reporting every problem found.
+//
+// Besides the errors of ParseYAML and ParseJSON, the problems
+// reported are entries:
+//
+//   - without a path;
+//   - with the path of an earlier entry, which they override;
+//   - without a URL, unless they are gone or have variants;
+//   - whose URL cannot be parsed;
+//   - redirecting to their own path;
+//   - part of a cycle of redirects to the paths of other entries.
+//
+// If parsing fails, no further checks are run. Otherwise the error
+// joins one *EntryError per problem, which can be listed by the
+// Unwrap() []error method of the error.
+func Validate(data []byte, format string) (int, error) {
+	entries, err := parseMapping(format, data)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(entries), lint(entries)
+}
+
+// lint runs the checks of Validate on entries that were already
+// validated one by one.
+func lint(entries []MappingEntry) error {
+	var errs []error
+	problem := func(path, format string, args ...any) {
+		errs = append(errs, &EntryError{Path: path, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	next := make(map[string]string)
+	var paths []string
+	for _, entry := range expandPaths(entries) {
+		if entry.Path == "" {
+			problem(entry.Path, "missing path")
+			continue
+		}
+		if _, ok := next[entry.Path]; ok {
+			problem(entry.Path, "duplicate path")
+		} else {
+			paths = append(paths, entry.Path)
+		}
+		next[entry.Path] = ""
+		if entry.Gone || len(entry.Variants) > 0 {
+			continue
+		}
+		if entry.URL == "" {
+			problem(entry.Path, "missing url")
+			continue
+		}
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			problem(entry.Path, "invalid url: %v", err)
+			continue
+		}
+		if u.Scheme != "" || u.Host != "" {
+			continue
+		}
+		if u.Path == entry.Path {
+			problem(entry.Path, "redirects to itself")
+			continue
+		}
+		next[entry.Path] = u.Path
+	}
+
+	for _, cycle := range findCycles(paths, next) {
+		problem(cycle[0], "redirect cycle %s", strings.Join(cycle, " -> "))
+	}
+	return errors.Join(errs...)
+}
+
+// findCycles returns the cycles of the graph where each path of paths
+// leads to next[path], if it is one of paths, in the order they are
+// first reached from paths. Each cycle is returned once, starting and
+// ending with the same path.
+func findCycles(paths []string, next map[string]string) [][]string {
+	done := make(map[string]bool)
+	var cycles [][]string
+	for _, start := range paths {
+		var walk []string
+		pos := make(map[string]int)
+		path := start
+		for {
+			if done[path] {
+				break
+			}
+			if i, ok := pos[path]; ok {
+				cycles = append(cycles, append(walk[i:], path))
+				break
+			}
+			pos[path] = len(walk)
+			walk = append(walk, path)
+			n, ok := next[path]
+			if !ok || n == "" {
+				break
+			}
+			path = n
+		}
+		for _, path := range walk {
+			done[path] = true
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,220 @@
+package urlshort
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// entryErrors returns the *EntryError values joined in err.
+func entryErrors(t *testing.T, err error) []*EntryError {
+	t.Helper()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	var entryErrs []*EntryError
+	for _, err := range errs {
+		var entryErr *EntryError
+		if !errors.As(err, &entryErr) {
+			t.Fatalf("got error %v, want an *EntryError", err)
+		}
+		entryErrs = append(entryErrs, entryErr)
+	}
+	return entryErrs
+}
+
+func TestEntryErrorYAMLLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		yml   string
+		lines []int
+		msg   string
+	}{
+		{
+			name: "list",
+			yml: `- path: /ok
+  url: https://example.com
+
+- path: /bad
+  url: https://example.com
+  gone: true
+`,
+			lines: []int{4},
+			msg:   "line 4: entry /bad: cannot set both url and gone",
+		},
+		{
+			name: "several",
+			yml: `- path: /a
+  maxHits: -1
+- path: /ok
+  url: https://example.com
+- paths: ["/b", ""]
+  url: https://example.com
+`,
+			lines: []int{1, 5},
+			msg:   "line 1: entry /a: maxHits must not be negative\nline 5: entry /b, : empty path in paths",
+		},
+		{
+			name: "document",
+			yml: `aliases:
+  docs: https://docs.example.com
+redirects:
+  - path: /ok
+    url: docs:/
+  - path: /bad
+    url: https://example.com
+    status: 200
+`,
+			lines: []int{6},
+			msg:   "line 6: entry /bad: invalid redirect status 200",
+		},
+		{
+			name: "without path",
+			yml: `- url: https://example.com
+  temporary: true
+  status: 301
+`,
+			lines: []int{1},
+			msg:   "line 1: entry: cannot set both status and temporary",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML([]byte(tt.yml))
+			entryErrs := entryErrors(t, err)
+			if len(entryErrs) != len(tt.lines) {
+				t.Fatalf("got %d errors, want %d: %v", len(entryErrs), len(tt.lines), err)
+			}
+			for i, entryErr := range entryErrs {
+				if entryErr.Line != tt.lines[i] || entryErr.Index != 0 {
+					t.Errorf("error %d: got line %d, index %d, want line %d", i, entryErr.Line, entryErr.Index, tt.lines[i])
+				}
+			}
+			if err.Error() != tt.msg {
+				t.Errorf("got message %q, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestEntryErrorJSONIndex(t *testing.T) {
+	jsn := `[
+		{"path": "/ok", "url": "https://example.com"},
+		{"path": "/bad", "url": "https://example.com", "gone": true},
+		{"url": "https://example.com", "maxHits": -1}
+	]`
+	_, err := ParseJSON([]byte(jsn))
+	entryErrs := entryErrors(t, err)
+	if len(entryErrs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(entryErrs), err)
+	}
+	for i, want := range []int{2, 3} {
+		if entryErrs[i].Index != want || entryErrs[i].Line != 0 {
+			t.Errorf("error %d: got index %d, line %d, want index %d", i, entryErrs[i].Index, entryErrs[i].Line, want)
+		}
+	}
+	want := "entry 2 (/bad): cannot set both url and gone\nentry 3: maxHits must not be negative"
+	if err.Error() != want {
+		t.Errorf("got message %q, want %q", err, want)
+	}
+}
+
+func TestValidateEntriesUnlocated(t *testing.T) {
+	err := ValidateEntries([]MappingEntry{
+		{Path: "/ok", URL: "https://example.com"},
+		{Path: "/bad", URL: "https://example.com", Gone: true},
+	})
+	entryErrs := entryErrors(t, err)
+	if len(entryErrs) != 1 || entryErrs[0].Line != 0 || entryErrs[0].Index != 0 {
+		t.Fatalf("got errors %+v, want one without a position", entryErrs)
+	}
+	if want := "entry /bad: cannot set both url and gone"; err.Error() != want {
+		t.Errorf("got message %q, want %q", err, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		format   string
+		n        int
+		problems []string
+	}{
+		{
+			name:   "ok",
+			data:   "- path: /a\n  url: https://a.example.com\n- path: /b\n  url: /a\n- path: /old\n  gone: true\n",
+			format: "yaml", n: 3,
+		},
+		{
+			name:   "duplicate",
+			data:   "- path: /a\n  url: https://a.example.com\n- path: /a\n  url: https://b.example.com\n",
+			format: "yaml", n: 2,
+			problems: []string{"entry /a: duplicate path"},
+		},
+		{
+			name:   "missing url",
+			data:   `[{"path": "/a"}, {"path": "/b", "url": "https://b.example.com"}]`,
+			format: "json", n: 2,
+			problems: []string{"entry /a: missing url"},
+		},
+		{
+			name:   "invalid url",
+			data:   `[{"path": "/a", "url": "https://a.example.com/%zz"}]`,
+			format: "json", n: 1,
+			problems: []string{`entry /a: invalid url: parse "https://a.example.com/%zz": invalid URL escape "%zz"`},
+		},
+		{
+			name:   "itself",
+			data:   "- path: /a\n  url: /a?b=1\n",
+			format: "yaml", n: 1,
+			problems: []string{"entry /a: redirects to itself"},
+		},
+		{
+			name:   "cycles",
+			data:   "- path: /a\n  url: /b\n- path: /b\n  url: /c\n- path: /c\n  url: /a\n- path: /d\n  url: /a\n- path: /x\n  url: /y\n- path: /y\n  url: /x\n",
+			format: "yaml", n: 6,
+			problems: []string{"entry /a: redirect cycle /a -> /b -> /c -> /a", "entry /x: redirect cycle /x -> /y -> /x"},
+		},
+		{
+			name:   "several problems",
+			data:   "- path: /a\n- path: /b\n  url: /b\n",
+			format: "yaml", n: 2,
+			problems: []string{"entry /a: missing url", "entry /b: redirects to itself"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Validate([]byte(tt.data), tt.format)
+			if n != tt.n {
+				t.Errorf("got %d entries, want %d", n, tt.n)
+			}
+			if tt.problems == nil {
+				if err != nil {
+					t.Errorf("got error %v, want none", err)
+				}
+				return
+			}
+			var problems []string
+			for _, err := range entryErrors(t, err) {
+				problems = append(problems, err.Error())
+			}
+			if !slices.Equal(problems, tt.problems) {
+				t.Errorf("got problems %q, want %q", problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestValidateParseError(t *testing.T) {
+	if n, err := Validate([]byte("- path: ["), "yaml"); n != 0 || err == nil {
+		t.Errorf("got %d, %v for invalid YAML, want a parse error", n, err)
+	}
+	if n, err := Validate([]byte("[]"), "toml"); n != 0 || err == nil {
+		t.Errorf("got %d, %v for an unknown format, want an error", n, err)
+	}
+}
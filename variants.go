@@ -0,0 +1,96 @@
+package urlshort
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// Variant is one of the destinations an entry splits its traffic
+// between, such as the arms of an A/B test or mirror CDNs, in
+// proportion to Weight.
+type Variant struct {
+	URL    string `yaml:"url" json:"url"`
+	Weight int    `yaml:"weight" json:"weight"`
+}
+
+// validate reports whether the fields of the variant are valid.
+func (v Variant) validate() error {
+	if v.URL == "" {
+		return fmt.Errorf("missing url")
+	}
+	if v.Weight <= 0 {
+		return fmt.Errorf("weight must be positive")
+	}
+	return nil
+}
+
+// WithWeightedRoundRobin makes the handler pick among the variants of
+// an entry by smooth weighted round-robin, as nginx does for upstream
+// servers, instead of at random. Every cycle of as many requests as
+// the total weight of the variants of a path then sends each variant
+// exactly its weight in requests, interleaved rather than in bursts,
+// where random picks only match the weights on average and can
+// stray from them over short periods. Each handler keeps its own
+// position in the cycle of every path.
+func WithWeightedRoundRobin() Option {
+	return func(c *config) {
+		c.roundRobin = &roundRobin{current: make(map[string][]int)}
+	}
+}
+
+// roundRobin holds the state of smooth weighted round-robin for every
+// path.
+type roundRobin struct {
+	mu      sync.Mutex
+	current map[string][]int
+}
+
+// pick returns the index of the next variant of path.
+func (rr *roundRobin) pick(path string, variants []Variant) int {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	current := rr.current[path]
+	if len(current) != len(variants) {
+		current = make([]int, len(variants))
+		rr.current[path] = current
+	}
+
+	total, best := 0, 0
+	for i, v := range variants {
+		current[i] += v.Weight
+		total += v.Weight
+		if current[i] > current[best] {
+			best = i
+		}
+	}
+	current[best] -= total
+	return best
+}
+
+// vary returns entry redirected to one of its variants, if it has
+// any.
+func (c *config) vary(entry MappingEntry) MappingEntry {
+	if len(entry.Variants) == 0 {
+		return entry
+	}
+	if c.roundRobin != nil {
+		entry.URL = entry.Variants[c.roundRobin.pick(entry.Path, entry.Variants)].URL
+		return entry
+	}
+
+	total := 0
+	for _, v := range entry.Variants {
+		total += v.Weight
+	}
+	n := rand.IntN(total)
+	for _, v := range entry.Variants {
+		n -= v.Weight
+		if n < 0 {
+			entry.URL = v.URL
+			break
+		}
+	}
+	return entry
+}
@@ -0,0 +1,105 @@
+package urlshort
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// variantsYAML maps /cdn to three mirrors weighted 5, 1 and 1.
+var variantsYAML = []byte(`
+- path: /cdn
+  variants:
+    - url: https://a.example.com
+      weight: 5
+    - url: https://b.example.com
+      weight: 1
+    - url: https://c.example.com
+      weight: 1
+`)
+
+func TestWeightedRoundRobin(t *testing.T) {
+	h, err := YAMLHandler(variantsYAML, notFound, WithWeightedRoundRobin())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for range 2 * 7 {
+		got = append(got, serve(h, "/cdn").Header().Get("Location"))
+	}
+	a, b, c := "https://a.example.com", "https://b.example.com", "https://c.example.com"
+	cycle := []string{a, a, b, a, c, a, a}
+	if want := append(slices.Clone(cycle), cycle...); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestWeightedRoundRobinConcurrent serves whole cycles from several
+// goroutines at once, as many goroutines as the total weight each
+// sending one request per cycle, and checks that each variant still
+// gets exactly its weight in requests.
+func TestWeightedRoundRobinConcurrent(t *testing.T) {
+	const workers, cycles = 7, 50
+	h, err := YAMLHandler(variantsYAML, notFound, WithWeightedRoundRobin())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range cycles {
+				loc := serve(h, "/cdn").Header().Get("Location")
+				mu.Lock()
+				counts[loc]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := map[string]int{
+		"https://a.example.com": 5 * cycles,
+		"https://b.example.com": cycles,
+		"https://c.example.com": cycles,
+	}
+	if !maps.Equal(counts, want) {
+		t.Errorf("got %v, want %v", counts, want)
+	}
+}
+
+func TestRandomVariants(t *testing.T) {
+	h, err := YAMLHandler(variantsYAML, notFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 100 {
+		w := serve(h, "/cdn")
+		switch loc := w.Header().Get("Location"); loc {
+		case "https://a.example.com", "https://b.example.com", "https://c.example.com":
+			wantStatus(t, w, http.StatusMovedPermanently)
+		default:
+			t.Fatalf("got redirect to %q, want one of the variants", loc)
+		}
+	}
+}
+
+func TestVariantsInvalid(t *testing.T) {
+	for _, variants := range []string{
+		`[{url: https://a.example.com, weight: 0}]`,
+		`[{url: https://a.example.com, weight: -1}]`,
+		`[{weight: 1}]`,
+	} {
+		_, err := ParseYAML([]byte("- path: /a\n  variants: " + variants + "\n"))
+		if err == nil {
+			t.Errorf("ParseYAML accepted variants %s", variants)
+		}
+	}
+}